@@ -0,0 +1,109 @@
+package cameraservices
+
+import (
+	"context"
+	"image"
+)
+
+// The optional capabilities a camera may have.
+const (
+	CapabilityAdmin            = "admin"
+	CapabilityJPEGStream       = "jpegStream"
+	CapabilitySnapshot         = "snapshot"
+	CapabilitySpeed            = "speed"
+	CapabilityAbsolutePosition = "absolutePosition"
+	CapabilityPresets          = "presets"
+	CapabilityStatus           = "status"
+	CapabilityFocus            = "focus"
+	CapabilityOnePushFocus     = "onePushFocus"
+	CapabilityExposure         = "exposure"
+	CapabilityWhiteBalance     = "whiteBalance"
+)
+
+// SnapshotCamera is a camera that can return a single frame.
+type SnapshotCamera interface {
+	Snapshot(context.Context) (image.Image, error)
+}
+
+// SpeedCamera is a camera that can pan/tilt and zoom at a given speed.
+// Negative speeds move left/down/out, and 0 stops.
+type SpeedCamera interface {
+	PanTilt(ctx context.Context, panSpeed, tiltSpeed int) error
+	Zoom(ctx context.Context, speed int) error
+}
+
+// Position is the absolute pan, tilt, and zoom position of a camera, in the camera's units.
+type Position struct {
+	Pan  float64 `json:"pan"`
+	Tilt float64 `json:"tilt"`
+	Zoom float64 `json:"zoom"`
+}
+
+// PositionCamera is a camera that can report and move to an absolute position.
+type PositionCamera interface {
+	Position(context.Context) (Position, error)
+	SetPosition(context.Context, Position) error
+}
+
+// Limits is the valid range of an argument to a capability.
+type Limits struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// LimitedCamera is a camera that can report the limits of its capabilities,
+// keyed by capability and then by argument (ie. limits["speed"]["pan"]).
+type LimitedCamera interface {
+	Limits() map[string]map[string]Limits
+}
+
+// Capability describes if a camera supports a capability, and the limits of that capability's arguments.
+type Capability struct {
+	Supported bool              `json:"supported"`
+	Limits    map[string]Limits `json:"limits,omitempty"`
+}
+
+// Capabilities is every optional capability of a camera, keyed by capability name.
+type Capabilities map[string]Capability
+
+// CapabilitiesOf returns the optional capabilities that cam implements.
+func CapabilitiesOf(cam Camera) Capabilities {
+	_, admin := cam.(CameraAdmin)
+	_, jpeg := cam.(JPEGCamera)
+	_, snapshot := cam.(SnapshotCamera)
+	_, speed := cam.(SpeedCamera)
+	_, position := cam.(PositionCamera)
+	_, status := cam.(StatusCamera)
+	_, focus := cam.(FocusCamera)
+	_, onePushFocus := cam.(OnePushFocusCamera)
+	_, exposure := cam.(ExposureCamera)
+	_, whiteBalance := cam.(WhiteBalanceCamera)
+
+	caps := Capabilities{
+		CapabilityAdmin:            {Supported: admin},
+		CapabilityJPEGStream:       {Supported: jpeg},
+		CapabilitySnapshot:         {Supported: snapshot},
+		CapabilitySpeed:            {Supported: speed},
+		CapabilityAbsolutePosition: {Supported: position},
+		CapabilityPresets:          {Supported: true},
+		CapabilityStatus:           {Supported: status},
+		CapabilityFocus:            {Supported: focus},
+		CapabilityOnePushFocus:     {Supported: onePushFocus},
+		CapabilityExposure:         {Supported: exposure},
+		CapabilityWhiteBalance:     {Supported: whiteBalance},
+	}
+
+	if l, ok := cam.(LimitedCamera); ok {
+		for name, limits := range l.Limits() {
+			c, ok := caps[name]
+			if !ok || !c.Supported {
+				continue
+			}
+
+			c.Limits = limits
+			caps[name] = c
+		}
+	}
+
+	return caps
+}
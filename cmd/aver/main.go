@@ -2,22 +2,21 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"os"
-	"strings"
-	"sync"
 	"time"
 
-	"github.com/byuoitav/aver"
 	cameraservices "github.com/byuoitav/camera-services"
 	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/drivers/pro520"
 	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
 	"github.com/byuoitav/camera-services/handlers"
 	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/camera-services/server"
 	"github.com/byuoitav/visca"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -28,15 +27,18 @@ import (
 
 func main() {
 	var (
-		port     int
-		logLevel string
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
 
 		keyServiceAddr string
 
-		dbAddr     string
-		dbUsername string
-		dbPassword string
-		dbInsecure bool
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
 
 		eventURL string
 		name     string
@@ -49,6 +51,7 @@ func main() {
 	// List of flags
 	pflag.IntVarP(&port, "port", "P", 8080, "port to run the server on")
 	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
 	pflag.StringVar(&eventURL, "event-url", "", "url to send events to")
 	pflag.StringVar(&name, "name", "", "the name of this service to include in events generated by it")
 	pflag.StringVar(&dnsAddr, "dns-addr", "", "dns server to use for reverse ip lookups")
@@ -59,6 +62,8 @@ func main() {
 	pflag.StringVar(&dbUsername, "db-username", "", "database username")
 	pflag.StringVar(&dbPassword, "db-password", "", "database password")
 	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
 	pflag.Parse()
 
 	var level zapcore.Level
@@ -67,27 +72,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// build the couch config service
-	if dbInsecure {
-		dbAddr = "http://" + dbAddr
-	} else {
-		dbAddr = "https://" + dbAddr
-	}
-
-	var csOpts []couch.Option
-	if dbUsername != "" {
-		csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
-	}
-
-	// context for setup
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	cs, err := couch.New(ctx, dbAddr, csOpts...)
-	if err != nil {
-		log.Fatal("unable to create config service", zap.Error(err))
-	}
-
 	// build the logger
 	config := zap.Config{
 		Level:       zap.NewAtomicLevelAt(level),
@@ -127,6 +111,48 @@ func main() {
 		log.Fatal("--name is required. use --help for more details")
 	}
 
+	// build the config service
+	var cs cameraservices.ConfigService
+	if configDir != "" {
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = fc
+	} else {
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
+
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
+
+		// context for setup
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, err := couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+
+		cs = db
+		if configCache {
+			cache := couch.NewCache(db, couch.WithCacheLogger(log))
+			defer cache.Close()
+
+			cs = cache
+		}
+	}
+
 	resolver := &net.Resolver{}
 	if len(dnsAddr) > 0 {
 		log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
@@ -137,45 +163,22 @@ func main() {
 		}
 	}
 
-	cameras := &sync.Map{}
 	middleware := handlers.Middleware{
 		Logger: log,
 	}
+	cameras := manager.New(manager.WithLogger(log))
+	defer cameras.Close()
+
 	handlers := handlers.NewCameraController(cs)
 	handlers.Logger = log
-	handlers.CreateCamera = func(ctx context.Context, addr string) (cameraservices.Camera, error) {
-		// TODO need to make this function better if New() does much of anything (see av-control-api/drivers)
-		if cam, ok := cameras.Load(addr); ok {
-			return cam.(*aver.Pro520), nil
-		}
-
-		addrNoPort := addr
-		if strings.Contains(addrNoPort, ":") {
-			var err error
-			if addrNoPort, _, err = net.SplitHostPort(addrNoPort); err != nil {
-				return nil, err
-			}
-		}
-
-		cam := &aver.Pro520{
-			Camera:   visca.New(addr, visca.WithLogger(log.Sugar().Named(addr))),
-			Address:  addrNoPort,
-			Username: camUsername,
-			Password: camPassword,
-		}
-
-		cameras.Store(addr, cam)
-		return cam, nil
-	}
 	handlers.EventPublisher = &event.Publisher{
 		GeneratingSystem: name,
 		URL:              eventURL,
 		Resolver:         resolver,
 	}
 
-	handlers.ControlKeyService = &keys.ControlKeyService{
-		Address: keyServiceAddr,
-	}
+	keyCache := keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr}, keys.WithCacheLogger(log))
+	handlers.ControlKeyService = keyCache
 
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -185,6 +188,21 @@ func main() {
 	debug.GET("/healthz", func(c *gin.Context) {
 		c.String(http.StatusOK, "healthy")
 	})
+	debug.GET("/cameras", func(c *gin.Context) {
+		c.JSON(http.StatusOK, cameras.Stats())
+	})
+	debug.GET("/keys", func(c *gin.Context) {
+		c.JSON(http.StatusOK, keyCache.Stats())
+	})
+	debug.GET("/config", func(c *gin.Context) {
+		cache, ok := cs.(*couch.Cache)
+		if !ok {
+			c.String(http.StatusNotFound, "config isn't cached")
+			return
+		}
+
+		c.JSON(http.StatusOK, cache.Stats())
+	})
 	debug.GET("/logz", func(c *gin.Context) {
 		c.String(http.StatusOK, config.Level.String())
 	})
@@ -200,30 +218,40 @@ func main() {
 		c.String(http.StatusOK, config.Level.String())
 	})
 
-	pro520 := r.Group("/v1/Pro520/:address", middleware.RequestID, middleware.Log, handlers.CameraMiddleware)
-	pro520.GET("/pantilt/up", handlers.Publish("TiltUp"), handlers.TiltUp)
-	pro520.GET("/pantilt/down", handlers.Publish("TiltDown"), handlers.TiltDown)
-	pro520.GET("/pantilt/left", handlers.Publish("PanLeft"), handlers.PanLeft)
-	pro520.GET("/pantilt/right", handlers.Publish("PanRight"), handlers.PanRight)
-	pro520.GET("/pantilt/stop", handlers.Publish("PanTiltStop"), handlers.PanTiltStop)
-	pro520.GET("/zoom/in", handlers.Publish("ZoomIn"), handlers.ZoomIn)
-	pro520.GET("/zoom/out", handlers.Publish("ZoomOut"), handlers.ZoomOut)
-	pro520.GET("/zoom/stop", handlers.Publish("ZoomStop"), handlers.ZoomStop)
-	pro520.GET("/preset/:preset", handlers.Publish("GoToPreset"), handlers.GoToPreset)
-	pro520.GET("/stream", handlers.Publish("Stream"), handlers.Stream)
-	pro520.GET("/reboot", handlers.Publish("Reboot"), handlers.Reboot)
-	pro520.GET("/savePreset/:preset", handlers.Publish("SavePreset"), handlers.SavePreset)
+	var drivers cameraservices.Registry
+	if err := drivers.Register(pro520Driver(cameras, camUsername, camPassword, log)); err != nil {
+		log.Fatal("unable to register driver", zap.Error(err))
+	}
+
+	handlers.AddDriverRoutes(r, middleware, &drivers)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Fatal("unable to bind listener", zap.Error(err))
 	}
 
+	ctx, stop := server.SignalContext()
+	defer stop()
+
 	log.Info("Starting server", zap.String("on", lis.Addr().String()))
-	err = r.RunListener(lis)
-	switch {
-	case errors.Is(err, http.ErrServerClosed):
-	case err != nil:
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(handlers.Shutdown),
+	)
+	if err != nil {
 		log.Fatal("failed to serve", zap.Error(err))
 	}
 }
+
+// pro520Driver returns the driver for Pro520s, with cameras cached by cameras.
+func pro520Driver(cameras *manager.Manager, username, password string, log *zap.Logger, opts ...visca.Option) cameraservices.Driver {
+	return cameraservices.Driver{
+		Model: "Pro520",
+		NewCamera: cameras.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+			opts := append([]visca.Option{visca.WithLogger(log.Sugar().Named(addr))}, opts...)
+			return pro520.New(addr, username, password, opts...)
+		}),
+		Capabilities: cameraservices.CapabilitiesOf(&pro520.Camera{}),
+	}
+}
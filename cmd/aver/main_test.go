@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/viscaip/viscatest"
+	"github.com/byuoitav/camera-services/handlers"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/visca"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testKeys struct{}
+
+func (testKeys) RoomAndControlGroup(ctx context.Context, key string) (string, string, error) {
+	return "ITB-1101", "ITB-1101", nil
+}
+
+// testConfig is a room with one camera, at addr.
+type testConfig struct {
+	addr string
+}
+
+func (t testConfig) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	return []cameraservices.CameraConfig{
+		{Stream: "http://aver.av.byu.edu/v1/Pro520/" + t.addr + "/stream"},
+	}, nil
+}
+
+func (t testConfig) ControlIP(ctx context.Context, room string) ([]string, error) {
+	return []string{"http://aver.av.byu.edu/v1/Pro520/" + t.addr + "/stream"}, nil
+}
+
+type testPublisher struct{}
+
+func (testPublisher) Publish(ctx context.Context, info cameraservices.RequestInfo) error {
+	return nil
+}
+
+func (testPublisher) Error(ctx context.Context, err cameraservices.RequestError) error {
+	return nil
+}
+
+// newTestServer creates the aver service's router, controlling a Pro520 emulated by the returned emulator.
+func newTestServer(t *testing.T) (*gin.Engine, *viscatest.Emulator, string) {
+	gin.SetMode(gin.TestMode)
+
+	emu := viscatest.New(viscatest.WithShortLength())
+	t.Cleanup(func() { emu.Close() })
+
+	addr, err := emu.ListenUDP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	log := zap.NewNop()
+	h := handlers.NewCameraController(testConfig{addr: addr})
+	h.Logger = log
+	h.ControlKeyService = testKeys{}
+	h.EventPublisher = testPublisher{}
+
+	cameras := manager.New()
+	t.Cleanup(func() { cameras.Close() })
+
+	var drivers cameraservices.Registry
+	require.NoError(t, drivers.Register(pro520Driver(cameras, "admin", "password", log, visca.WithDelay(0))))
+
+	r := gin.New()
+	h.AddDriverRoutes(r, handlers.Middleware{Logger: log}, &drivers)
+
+	return r, emu, "/v1/Pro520/" + addr
+}
+
+func get(ctx context.Context, r *gin.Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	req.AddCookie(&http.Cookie{Name: "control-key", Value: "1234"})
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestHandlers(t *testing.T) {
+	r, emu, base := newTestServer(t)
+	ctx := context.Background()
+
+	// stream and reboot use the camera's http api, which isn't emulated
+	tests := []struct {
+		path  string
+		check func(viscatest.State)
+	}{
+		{"/pantilt/up", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DirectionUp), s.TiltDirection)
+			require.Equal(t, byte(0x0e), s.TiltSpeed)
+		}},
+		{"/pantilt/down", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DirectionDown), s.TiltDirection)
+		}},
+		{"/pantilt/left", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DirectionLeft), s.PanDirection)
+			require.Equal(t, byte(viscatest.DirectionStop), s.TiltDirection)
+			require.Equal(t, byte(0x0b), s.PanSpeed)
+		}},
+		{"/pantilt/right", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DirectionRight), s.PanDirection)
+		}},
+		{"/pantilt/stop", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DirectionStop), s.PanDirection)
+			require.Equal(t, byte(viscatest.DirectionStop), s.TiltDirection)
+		}},
+		{"/zoom/in", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveTele), s.ZoomDirection)
+		}},
+		{"/zoom/out", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveWide), s.ZoomDirection)
+		}},
+		{"/zoom/stop", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveStop), s.ZoomDirection)
+		}},
+		{"/savePreset/4", func(s viscatest.State) {
+			require.Contains(t, s.Presets, byte(4))
+		}},
+		{"/preset/4", func(s viscatest.State) {
+			require.Equal(t, []byte{4}, s.Recalls)
+		}},
+		{"/focus/mode/manual", func(s viscatest.State) {
+			require.False(t, s.FocusAuto)
+		}},
+		{"/focus/near", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveWide), s.FocusDirection)
+		}},
+		{"/focus/far", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveTele), s.FocusDirection)
+		}},
+		{"/focus/stop", func(s viscatest.State) {
+			require.Equal(t, byte(viscatest.DriveStop), s.FocusDirection)
+		}},
+		{"/focus/onePush", func(s viscatest.State) {
+			require.Equal(t, 1, s.OnePushFocuses)
+		}},
+		{"/exposure/mode/manual", func(s viscatest.State) {
+			require.Equal(t, byte(0x03), s.ExposureMode)
+		}},
+		{"/exposure/iris/9", func(s viscatest.State) {
+			require.Equal(t, 9, s.Iris)
+		}},
+		{"/exposure/brightness/10", func(s viscatest.State) {
+			require.True(t, s.ExposureCompensation)
+			require.Equal(t, 10, s.Brightness)
+		}},
+		{"/whiteBalance/indoor", func(s viscatest.State) {
+			require.Equal(t, byte(0x01), s.WhiteBalance)
+		}},
+	}
+
+	for _, tt := range tests {
+		resp := get(ctx, r, base+tt.path)
+		require.Equal(t, http.StatusOK, resp.Code, "%s: %s", tt.path, resp.Body.String())
+		tt.check(emu.State())
+	}
+
+	resp := get(ctx, r, base+"/status")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var status cameraservices.Status
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	require.Equal(t, cameraservices.PowerOn, status.Power)
+	require.Equal(t, "Pro520", status.Model)
+	require.Equal(t, "0100", status.Firmware)
+	require.NotNil(t, status.Position)
+
+	resp = get(ctx, r, base+"/capabilities")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var caps cameraservices.Capabilities
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &caps))
+	require.True(t, caps[cameraservices.CapabilityFocus].Supported)
+	require.Equal(t, cameraservices.Limits{Min: 0, Max: 0x7f}, caps[cameraservices.CapabilityPresets].Limits["preset"])
+}
+
+func TestHandlerErrors(t *testing.T) {
+	r, emu, base := newTestServer(t)
+	ctx := context.Background()
+
+	emu.Fail(viscatest.Fault{Match: []byte{0x01, 0x06, 0x01}, Error: viscatest.ErrorBufferFull, Count: 1})
+	resp := get(ctx, r, base+"/pantilt/up")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.Equal(t, visca.ErrCommandBufferFull.Error(), resp.Body.String())
+
+	emu.Fail(viscatest.Fault{Match: []byte{0x01, 0x04, 0x3f}, Count: 1})
+
+	timeout, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+	defer cancel()
+
+	resp = get(timeout, r, base+"/preset/1")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// the camera works again once the faults are gone
+	resp = get(ctx, r, base+"/preset/1")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	// out of range
+	resp = get(ctx, r, base+"/exposure/iris/18")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.Equal(t, 0, emu.State().Iris)
+
+	// standby
+	emu.SetState(func(s *viscatest.State) { s.Power = false })
+	resp = get(ctx, r, base+"/focus/near")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	resp = get(ctx, r, base+"/status")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"power":"standby","model":"Pro520"}`, resp.Body.String())
+}
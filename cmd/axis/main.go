@@ -2,21 +2,21 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"os"
-	"sync"
 	"time"
 
-	"github.com/byuoitav/axis"
 	cameraservices "github.com/byuoitav/camera-services"
 	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/drivers/vapix"
 	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
 	"github.com/byuoitav/camera-services/handlers"
 	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/camera-services/server"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/pflag"
@@ -26,15 +26,18 @@ import (
 
 func main() {
 	var (
-		port     int
-		logLevel string
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
 
 		keyServiceAddr string
 
-		dbAddr     string
-		dbUsername string
-		dbPassword string
-		dbInsecure bool
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
 
 		eventURL string
 		name     string
@@ -43,6 +46,7 @@ func main() {
 
 	pflag.IntVarP(&port, "port", "P", 8080, "port to run the server on")
 	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
 	pflag.StringVar(&eventURL, "event-url", "", "url to send events to")
 	pflag.StringVar(&name, "name", "", "the name of this service to include in events generated by it")
 	pflag.StringVar(&keyServiceAddr, "key-service", "control-keys.av.byu.edu", "address of the control keys service")
@@ -51,6 +55,8 @@ func main() {
 	pflag.StringVar(&dbUsername, "db-username", "", "database username")
 	pflag.StringVar(&dbPassword, "db-password", "", "database password")
 	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
 	pflag.Parse()
 
 	var level zapcore.Level
@@ -59,27 +65,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// build the couch config service
-	if dbInsecure {
-		dbAddr = "http://" + dbAddr
-	} else {
-		dbAddr = "https://" + dbAddr
-	}
-
-	var csOpts []couch.Option
-	if dbUsername != "" {
-		csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
-	}
-
-	// context for setup
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	cs, err := couch.New(ctx, dbAddr, csOpts...)
-	if err != nil {
-		log.Fatal("unable to create config service", zap.Error(err))
-	}
-
 	// build the logger
 	config := zap.Config{
 		Level:       zap.NewAtomicLevelAt(level),
@@ -119,6 +104,48 @@ func main() {
 		log.Fatal("--name is required. use --help for more details")
 	}
 
+	// build the config service
+	var cs cameraservices.ConfigService
+	if configDir != "" {
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = fc
+	} else {
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
+
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
+
+		// context for setup
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, err := couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+
+		cs = db
+		if configCache {
+			cache := couch.NewCache(db, couch.WithCacheLogger(log))
+			defer cache.Close()
+
+			cs = cache
+		}
+	}
+
 	resolver := &net.Resolver{}
 	if len(dnsAddr) > 0 {
 		log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
@@ -129,63 +156,43 @@ func main() {
 		}
 	}
 
-	cameras := &sync.Map{}
+	cameras := manager.New(manager.WithLogger(log))
+	defer cameras.Close()
+
 	middleware := handlers.Middleware{
 		Logger: log,
 	}
-	p5414EHandlers := handlers.NewCameraController(cs)
-	p5414EHandlers.Logger = log
-	p5414EHandlers.CreateCamera = func(ctx context.Context, addr string) (cameraservices.Camera, error) {
-		// TODO need to make this function better if New() does much of anything (see av-control-api/drivers)
-		if cam, ok := cameras.Load(addr); ok {
-			if c, ok := cam.(*axis.P5414E); ok {
-				return c, nil
-			}
-		}
-
-		cam := &axis.P5414E{
-			Address:       addr,
-			StreamProfile: "control",
-		}
-
-		cameras.Store(addr, cam)
-		return cam, nil
-	}
-	p5414EHandlers.EventPublisher = &event.Publisher{
+	handlers := handlers.NewCameraController(cs)
+	handlers.Logger = log
+	handlers.EventPublisher = &event.Publisher{
 		GeneratingSystem: name,
 		URL:              eventURL,
 		Resolver:         resolver,
 	}
 
-	p5414EHandlers.ControlKeyService = &keys.ControlKeyService{
-		Address: keyServiceAddr,
-	}
-
-	v5915Handlers := handlers.NewCameraController(cs)
-	v5915Handlers.Logger = log
-	v5915Handlers.CreateCamera = func(ctx context.Context, addr string) (cameraservices.Camera, error) {
-		if cam, ok := cameras.Load(addr); ok {
-			if c, ok := cam.(*axis.V5915); ok {
-				return c, nil
-			}
-		}
+	keyCache := keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr}, keys.WithCacheLogger(log))
+	handlers.ControlKeyService = keyCache
 
-		cam := &axis.V5915{
-			Address:       addr,
-			StreamProfile: "control",
+	var drivers cameraservices.Registry
+	for _, d := range []cameraservices.Driver{
+		{
+			Model: "P5414-E",
+			NewCamera: cameras.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+				return vapix.NewP5414E(addr, "control"), nil
+			}),
+			Capabilities: cameraservices.CapabilitiesOf(&vapix.P5414E{}),
+		},
+		{
+			Model: "V5915",
+			NewCamera: cameras.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+				return vapix.NewV5915(addr, "control"), nil
+			}),
+			Capabilities: cameraservices.CapabilitiesOf(&vapix.V5915{}),
+		},
+	} {
+		if err := drivers.Register(d); err != nil {
+			log.Fatal("unable to register driver", zap.Error(err))
 		}
-
-		cameras.Store(addr, cam)
-		return cam, nil
-	}
-	v5915Handlers.EventPublisher = &event.Publisher{
-		GeneratingSystem: name,
-		URL:              eventURL,
-		Resolver:         resolver,
-	}
-
-	v5915Handlers.ControlKeyService = &keys.ControlKeyService{
-		Address: keyServiceAddr,
 	}
 
 	r := gin.New()
@@ -196,6 +203,21 @@ func main() {
 	debug.GET("/healthz", func(c *gin.Context) {
 		c.String(http.StatusOK, "healthy")
 	})
+	debug.GET("/cameras", func(c *gin.Context) {
+		c.JSON(http.StatusOK, cameras.Stats())
+	})
+	debug.GET("/keys", func(c *gin.Context) {
+		c.JSON(http.StatusOK, keyCache.Stats())
+	})
+	debug.GET("/config", func(c *gin.Context) {
+		cache, ok := cs.(*couch.Cache)
+		if !ok {
+			c.String(http.StatusNotFound, "config isn't cached")
+			return
+		}
+
+		c.JSON(http.StatusOK, cache.Stats())
+	})
 	debug.GET("/logz", func(c *gin.Context) {
 		c.String(http.StatusOK, config.Level.String())
 	})
@@ -211,39 +233,23 @@ func main() {
 		c.String(http.StatusOK, config.Level.String())
 	})
 
-	p5414E := r.Group("/v1/P5414-E/:address", middleware.RequestID, middleware.Log, p5414EHandlers.CameraMiddleware)
-	p5414E.GET("/pantilt/up", p5414EHandlers.Publish("TiltUp"), p5414EHandlers.TiltUp)
-	p5414E.GET("/pantilt/down", p5414EHandlers.Publish("TiltDown"), p5414EHandlers.TiltDown)
-	p5414E.GET("/pantilt/left", p5414EHandlers.Publish("PanLeft"), p5414EHandlers.PanLeft)
-	p5414E.GET("/pantilt/right", p5414EHandlers.Publish("PanRight"), p5414EHandlers.PanRight)
-	p5414E.GET("/pantilt/stop", p5414EHandlers.Publish("PanTiltStop"), p5414EHandlers.PanTiltStop)
-	p5414E.GET("/zoom/in", p5414EHandlers.Publish("ZoomIn"), p5414EHandlers.ZoomIn)
-	p5414E.GET("/zoom/out", p5414EHandlers.Publish("ZoomOut"), p5414EHandlers.ZoomOut)
-	p5414E.GET("/zoom/stop", p5414EHandlers.Publish("ZoomStop"), p5414EHandlers.ZoomStop)
-	p5414E.GET("/preset/:preset", p5414EHandlers.Publish("GoToPreset"), p5414EHandlers.GoToPreset)
-	p5414E.GET("/stream", p5414EHandlers.Publish("Stream"), p5414EHandlers.Stream)
-	v5915 := r.Group("/v1/V5915/:address", middleware.RequestID, middleware.Log, v5915Handlers.CameraMiddleware)
-	v5915.GET("/pantilt/up", v5915Handlers.Publish("TiltUp"), v5915Handlers.TiltUp)
-	v5915.GET("/pantilt/down", v5915Handlers.Publish("TiltDown"), v5915Handlers.TiltDown)
-	v5915.GET("/pantilt/left", v5915Handlers.Publish("PanLeft"), v5915Handlers.PanLeft)
-	v5915.GET("/pantilt/right", v5915Handlers.Publish("PanRight"), v5915Handlers.PanRight)
-	v5915.GET("/pantilt/stop", v5915Handlers.Publish("PanTiltStop"), v5915Handlers.PanTiltStop)
-	v5915.GET("/zoom/in", v5915Handlers.Publish("ZoomIn"), v5915Handlers.ZoomIn)
-	v5915.GET("/zoom/out", v5915Handlers.Publish("ZoomOut"), v5915Handlers.ZoomOut)
-	v5915.GET("/zoom/stop", v5915Handlers.Publish("ZoomStop"), v5915Handlers.ZoomStop)
-	v5915.GET("/preset/:preset", v5915Handlers.Publish("GoToPreset"), v5915Handlers.GoToPreset)
-	v5915.GET("/stream", v5915Handlers.Publish("Stream"), v5915Handlers.Stream)
+	handlers.AddDriverRoutes(r, middleware, &drivers)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Fatal("unable to bind listener", zap.Error(err))
 	}
 
+	ctx, stop := server.SignalContext()
+	defer stop()
+
 	log.Info("Starting server", zap.String("on", lis.Addr().String()))
-	err = r.RunListener(lis)
-	switch {
-	case errors.Is(err, http.ErrServerClosed):
-	case err != nil:
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(handlers.Shutdown),
+	)
+	if err != nil {
 		log.Fatal("failed to serve", zap.Error(err))
 	}
 }
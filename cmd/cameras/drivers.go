@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/onvif"
+	"github.com/byuoitav/camera-services/drivers/pro520"
+	"github.com/byuoitav/camera-services/drivers/sim"
+	"github.com/byuoitav/camera-services/drivers/vapix"
+	"github.com/byuoitav/camera-services/drivers/viscacam"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/visca"
+	"go.uber.org/zap"
+)
+
+// config is the config file. Only the drivers in it are enabled.
+type config struct {
+	// Drivers is each driver's config, keyed by model
+	Drivers map[string]json.RawMessage `json:"drivers"`
+}
+
+// driverConfig are the fields every driver's config has.
+type driverConfig struct {
+	// Driver is the driver to use for the model. Defaults to the model,
+	// but can be set to serve a driver under another model (ie. a PTZOptics camera using the VISCA driver).
+	Driver string `json:"driver"`
+}
+
+// builder parses a driver's config, returning the function used to create its cameras
+// and a zero value camera used to get the driver's capabilities.
+type builder func(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error)
+
+// builders are every driver this service can run, keyed by driver name.
+var builders = map[string]builder{
+	"Pro520":  buildPro520,
+	"P5414-E": buildP5414E,
+	"V5915":   buildV5915,
+	"VISCA":   buildVISCA,
+	"ONVIF":   buildONVIF,
+	"Sim":     buildSim,
+}
+
+// loadDrivers reads the config file at path and registers the drivers it enables, with their cameras cached by cameras.
+func loadDrivers(path string, cameras *manager.Manager, log *zap.Logger) (*cameraservices.Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open config: %w", err)
+	}
+	defer f.Close()
+
+	var cfg config
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode config: %w", err)
+	}
+
+	if len(cfg.Drivers) == 0 {
+		return nil, fmt.Errorf("no drivers are enabled")
+	}
+
+	reg := &cameraservices.Registry{}
+	for model, raw := range cfg.Drivers {
+		var dc driverConfig
+		if err := json.Unmarshal(raw, &dc); err != nil {
+			return nil, fmt.Errorf("%s: unable to decode config: %w", model, err)
+		}
+
+		if dc.Driver == "" {
+			dc.Driver = model
+		}
+
+		build, ok := builders[dc.Driver]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown driver %q (must be one of %s)", model, dc.Driver, strings.Join(driverNames(), ", "))
+		}
+
+		create, zero, err := build(raw, log.Named(model))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", model, err)
+		}
+
+		err = reg.Register(cameraservices.Driver{
+			Model:        model,
+			NewCamera:    cameras.Cameras(create),
+			Capabilities: cameraservices.CapabilitiesOf(zero),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reg, nil
+}
+
+func driverNames() []string {
+	var names []string
+	for name := range builders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// duration is a time.Duration that is a string (ie. "125ms") in json.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = duration(parsed)
+	return nil
+}
+
+func buildPro520(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	var cfg struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return pro520.New(addr, cfg.Username, cfg.Password, visca.WithLogger(log.Sugar().Named(addr)))
+	}, &pro520.Camera{}, nil
+}
+
+type vapixConfig struct {
+	StreamProfile string `json:"streamProfile"`
+}
+
+func (c *vapixConfig) parse(raw json.RawMessage) error {
+	c.StreamProfile = "control"
+	return json.Unmarshal(raw, c)
+}
+
+func buildP5414E(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	var cfg vapixConfig
+	if err := cfg.parse(raw); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return vapix.NewP5414E(addr, cfg.StreamProfile), nil
+	}, &vapix.P5414E{}, nil
+}
+
+func buildV5915(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	var cfg vapixConfig
+	if err := cfg.parse(raw); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return vapix.NewV5915(addr, cfg.StreamProfile), nil
+	}, &vapix.V5915{}, nil
+}
+
+func buildVISCA(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	cfg := struct {
+		StreamURL   string `json:"streamURL"`
+		SnapshotURL string `json:"snapshotURL"`
+		PanSpeed    byte   `json:"panSpeed"`
+		TiltSpeed   byte   `json:"tiltSpeed"`
+	}{
+		PanSpeed:  0x0b,
+		TiltSpeed: 0x0e,
+	}
+
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		host := addr
+		if h, _, err := net.SplitHostPort(addr); err == nil {
+			host = h
+		}
+
+		opts := []viscacam.Option{
+			viscacam.WithPanTiltSpeed(cfg.PanSpeed, cfg.TiltSpeed),
+			viscacam.WithViscaOptions(visca.WithLogger(log.Sugar().Named(addr))),
+		}
+
+		if cfg.StreamURL != "" {
+			opts = append(opts, viscacam.WithStreamURL(fmt.Sprintf(cfg.StreamURL, host)))
+		}
+
+		if cfg.SnapshotURL != "" {
+			opts = append(opts, viscacam.WithSnapshotURL(fmt.Sprintf(cfg.SnapshotURL, host)))
+		}
+
+		return viscacam.New(addr, opts...)
+	}, &viscacam.Camera{}, nil
+}
+
+func buildONVIF(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	cfg := struct {
+		Username      string   `json:"username"`
+		Password      string   `json:"password"`
+		Profile       string   `json:"profile"`
+		PanTiltSpeed  float64  `json:"panTiltSpeed"`
+		ZoomSpeed     float64  `json:"zoomSpeed"`
+		FrameInterval duration `json:"frameInterval"`
+	}{
+		PanTiltSpeed:  0.5,
+		ZoomSpeed:     0.5,
+		FrameInterval: duration(125 * time.Millisecond),
+	}
+
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		opts := []onvif.Option{
+			onvif.WithCredentials(cfg.Username, cfg.Password),
+			onvif.WithSpeed(cfg.PanTiltSpeed, cfg.ZoomSpeed),
+			onvif.WithFrameInterval(time.Duration(cfg.FrameInterval)),
+		}
+
+		if cfg.Profile != "" {
+			opts = append(opts, onvif.WithProfile(cfg.Profile))
+		}
+
+		return onvif.New(addr, opts...), nil
+	}, &onvif.Camera{}, nil
+}
+
+func buildSim(raw json.RawMessage, log *zap.Logger) (cameraservices.NewCameraFunc, cameraservices.Camera, error) {
+	cfg := struct {
+		Latency       duration `json:"latency"`
+		Jitter        duration `json:"jitter"`
+		FailureRate   float64  `json:"failureRate"`
+		FrameInterval duration `json:"frameInterval"`
+		FrameWidth    int      `json:"frameWidth"`
+		FrameHeight   int      `json:"frameHeight"`
+		RebootTime    duration `json:"rebootTime"`
+	}{
+		FrameInterval: duration(125 * time.Millisecond),
+		FrameWidth:    640,
+		FrameHeight:   360,
+		RebootTime:    duration(5 * time.Second),
+	}
+
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return sim.New(addr,
+			sim.WithLatency(time.Duration(cfg.Latency), time.Duration(cfg.Jitter)),
+			sim.WithFailureRate(cfg.FailureRate),
+			sim.WithFrameInterval(time.Duration(cfg.FrameInterval)),
+			sim.WithFrameSize(cfg.FrameWidth, cfg.FrameHeight),
+			sim.WithRebootTime(time.Duration(cfg.RebootTime)),
+		), nil
+	}, &sim.Camera{}, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/sim"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeConfig(t *testing.T, config string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	return path
+}
+
+func TestLoadDrivers(t *testing.T) {
+	path := writeConfig(t, `{
+		"drivers": {
+			"P5414-E": {"streamProfile": "control"},
+			"Sim": {"latency": "10ms", "failureRate": 0.1},
+			"Test": {"driver": "Sim", "frameWidth": 320, "frameHeight": 180}
+		}
+	}`)
+
+	cameras := manager.New()
+	defer cameras.Close()
+
+	drivers, err := loadDrivers(path, cameras, zap.NewNop())
+	require.NoError(t, err)
+
+	var models []string
+	for _, d := range drivers.Drivers() {
+		models = append(models, d.Model)
+	}
+	require.Equal(t, []string{"P5414-E", "Sim", "Test"}, models)
+
+	d, ok := drivers.Driver("Test")
+	require.True(t, ok)
+	require.True(t, d.Supports(cameraservices.CapabilityAbsolutePosition))
+	require.False(t, d.Supports(cameraservices.CapabilityFocus))
+
+	ctx := context.Background()
+
+	cam, err := d.NewCamera(ctx, "sim-1")
+	require.NoError(t, err)
+	require.IsType(t, &sim.Camera{}, cam)
+
+	again, err := d.NewCamera(ctx, "sim-1")
+	require.NoError(t, err)
+	require.Same(t, cam, again)
+
+	d, ok = drivers.Driver("P5414-E")
+	require.True(t, ok)
+	require.True(t, d.Supports(cameraservices.CapabilityExposure))
+	require.False(t, d.Supports(cameraservices.CapabilityAdmin))
+}
+
+func TestLoadDriversErrors(t *testing.T) {
+	tests := map[string]string{
+		"no drivers":     `{"drivers": {}}`,
+		"unknown driver": `{"drivers": {"Test": {"driver": "Unknown"}}}`,
+		"bad duration":   `{"drivers": {"Sim": {"latency": "soon"}}}`,
+		"bad json":       `{"drivers": `,
+	}
+
+	cameras := manager.New()
+	defer cameras.Close()
+
+	for name, config := range tests {
+		_, err := loadDrivers(writeConfig(t, config), cameras, zap.NewNop())
+		require.Error(t, err, name)
+	}
+
+	_, err := loadDrivers(filepath.Join(t.TempDir(), "missing.json"), cameras, zap.NewNop())
+	require.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byuoitav/camera-services/configlint"
+	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/fileconfig"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	var (
+		dbAddr     string
+		dbUsername string
+		dbPassword string
+		dbInsecure bool
+
+		format string
+		failOn string
+	)
+
+	pflag.StringVar(&dbAddr, "db-address", "", "database address to lint the documents of, instead of files")
+	pflag.StringVar(&dbUsername, "db-username", "", "database username")
+	pflag.StringVar(&dbPassword, "db-password", "", "database password")
+	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&format, "format", "json", "format of the report (json or text)")
+	pflag.StringVar(&failOn, "fail-on", "error", "exit with status 1 if an issue of at least this severity is found (error, warning, or none)")
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: configlint [flags] [file or directory...]\n")
+		pflag.PrintDefaults()
+	}
+	pflag.Parse()
+
+	if format != "json" && format != "text" {
+		fatalf("invalid format %q", format)
+	}
+
+	if failOn != "error" && failOn != "warning" && failOn != "none" {
+		fatalf("invalid --fail-on %q", failOn)
+	}
+
+	if dbAddr == "" && pflag.NArg() == 0 {
+		pflag.Usage()
+		os.Exit(2)
+	}
+
+	var docs []document
+	var err error
+	if dbAddr != "" {
+		docs, err = dbDocuments(dbAddr, dbUsername, dbPassword, dbInsecure)
+	} else {
+		docs, err = fileDocuments(pflag.Args())
+	}
+
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].name < docs[j].name
+	})
+
+	linter := configlint.New()
+	report := configlint.Report{
+		Issues: []configlint.Issue{},
+	}
+
+	for _, doc := range docs {
+		if doc.err != nil {
+			report.Add([]configlint.Issue{{
+				Document: doc.name,
+				Rule:     configlint.RuleParse,
+				Severity: configlint.SeverityError,
+				Message:  doc.err.Error(),
+			}})
+
+			continue
+		}
+
+		report.Add(linter.Document(doc.name, doc.data))
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			fatalf("unable to write report: %s", err)
+		}
+	case "text":
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+
+		fmt.Printf("%d documents, %d errors, %d warnings\n", report.Documents, report.Errors, report.Warnings)
+	}
+
+	switch {
+	case failOn == "error" && report.Errors > 0:
+		os.Exit(1)
+	case failOn == "warning" && report.Errors+report.Warnings > 0:
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "configlint: "+format+"\n", a...)
+	os.Exit(2)
+}
+
+// document is a document to lint.
+type document struct {
+	name string
+	data []byte
+
+	// err is set if the document couldn't be read
+	err error
+}
+
+// fileDocuments reads the documents at each path. Directories are read the same way --config-dir is.
+// A document's room defaults to its file name, so documents are named by their file name
+// unless more than one file has that name.
+func fileDocuments(paths []string) ([]document, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		docs, err := fileconfig.Documents(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		files = append(files, docs...)
+	}
+
+	count := make(map[string]int)
+	for _, file := range files {
+		count[roomName(file)]++
+	}
+
+	docs := make([]document, 0, len(files))
+	for _, file := range files {
+		doc := document{name: roomName(file)}
+		if count[doc.name] > 1 {
+			doc.name = file
+		}
+
+		doc.data, doc.err = fileconfig.ReadDocument(file)
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func roomName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// dbDocuments reads every document in the ui config database, named by room.
+func dbDocuments(addr, username, password string, insecure bool) ([]document, error) {
+	if insecure {
+		addr = "http://" + addr
+	} else {
+		addr = "https://" + addr
+	}
+
+	var opts []couch.Option
+	if username != "" {
+		opts = append(opts, couch.WithBasicAuth(username, password))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cs, err := couch.New(ctx, addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	rooms, err := cs.UIConfigDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]document, 0, len(rooms))
+	for room, data := range rooms {
+		docs = append(docs, document{name: room, data: data})
+	}
+
+	return docs, nil
+}
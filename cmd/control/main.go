@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,14 +9,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
+	cameraservices "github.com/byuoitav/camera-services"
 	"github.com/byuoitav/camera-services/auth/session/cookiestore"
 	"github.com/byuoitav/camera-services/auth/wso2"
 	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
 	"github.com/byuoitav/camera-services/handlers"
 	"github.com/byuoitav/camera-services/keys"
 	"github.com/byuoitav/camera-services/opa"
+	"github.com/byuoitav/camera-services/proxy"
+	"github.com/byuoitav/camera-services/server"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	adapter "github.com/gwatts/gin-adapter"
@@ -30,15 +35,24 @@ const (
 	sessionName = "camera-services-control"
 )
 
+// configService is where the control service gets cameras and scenes from.
+type configService interface {
+	cameraservices.ConfigService
+	cameraservices.SceneService
+}
+
 func main() {
 	var (
-		port     int
-		logLevel string
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
 
-		dbAddr     string
-		dbUsername string
-		dbPassword string
-		dbInsecure bool
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
 
 		keyServiceAddr string
 
@@ -53,16 +67,27 @@ func main() {
 
 		signingSecret string
 
-		averProxy string
-		axisProxy string
+		averProxy    string
+		averHosts    []string
+		axisProxy    string
+		axisHosts    []string
+		backendsFile string
+		modelURLs    map[string]string
+
+		eventURL string
+		name     string
+		dnsAddr  string
 	)
 
 	pflag.CommandLine.IntVarP(&port, "port", "P", 8080, "port to run the server on")
 	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
 	pflag.StringVar(&dbAddr, "db-address", "", "database address")
 	pflag.StringVar(&dbUsername, "db-username", "", "database username")
 	pflag.StringVar(&dbPassword, "db-password", "", "database password")
 	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
 	pflag.StringVar(&keyServiceAddr, "key-service", "control-keys.av.byu.edu", "address of the control keys service")
 	pflag.StringVar(&callbackURL, "callback-url", "http://localhost:8080", "wso2 callback url")
 	pflag.StringVar(&clientID, "client-id", "", "wso2 client ID")
@@ -72,8 +97,15 @@ func main() {
 	pflag.StringVar(&opaToken, "opa-token", "", "The token to use for OPA")
 	pflag.BoolVar(&disableAuth, "disable-auth", false, "Disable all auth z/n checks")
 	pflag.StringVar(&signingSecret, "signing-secret", "", "secret to sign JWT tokens with")
-	pflag.StringVar(&averProxy, "aver-proxy", "", "base url to proxy camera control requests through")
-	pflag.StringVar(&axisProxy, "axis-proxy", "", "base url to proxy camera control requests through")
+	pflag.StringVar(&averProxy, "aver-proxy", "", "base url to proxy aver camera control requests through. shorthand for an aver backend with one upstream")
+	pflag.StringSliceVar(&averHosts, "aver-hosts", []string{"aver.av.byu.edu"}, "hosts of camera urls in room configs that belong to the --aver-proxy backend")
+	pflag.StringVar(&axisProxy, "axis-proxy", "", "base url to proxy axis camera control requests through. shorthand for an axis backend with one upstream")
+	pflag.StringSliceVar(&axisHosts, "axis-hosts", []string{"axis.av.byu.edu"}, "hosts of camera urls in room configs that belong to the --axis-proxy backend")
+	pflag.StringVar(&backendsFile, "backends", "", "yaml file of camera service backends to proxy camera control requests through")
+	pflag.StringToStringVar(&modelURLs, "model-url", nil, "url template that cameras added through the admin api use for each model, ie. Pro520=http://aver.av.byu.edu/v1/Pro520/{address}")
+	pflag.StringVar(&eventURL, "event-url", "", "url to send events to")
+	pflag.StringVar(&name, "name", "camera-services-control", "the name of this service to include in events generated by it")
+	pflag.StringVar(&dnsAddr, "dns-addr", "", "dns server to use for reverse ip lookups")
 
 	pflag.Parse()
 
@@ -118,40 +150,88 @@ func main() {
 	}()
 
 	// validate flags
-	averProxyURL, err := url.Parse(averProxy)
-	if err != nil {
-		log.Fatal("unable to parse aver proxy url", zap.Error(err))
+	routes := make(map[string]proxy.Config)
+	if backendsFile != "" {
+		routes, err = proxy.Load(backendsFile)
+		if err != nil {
+			log.Fatal("unable to load backends", zap.Error(err))
+		}
+	}
+
+	shorthands := map[string]proxy.Config{
+		"aver": {Upstreams: []string{averProxy}, Hosts: averHosts},
+		"axis": {Upstreams: []string{axisProxy}, Hosts: axisHosts},
+	}
+
+	for name, config := range shorthands {
+		if config.Upstreams[0] == "" {
+			continue
+		}
+
+		if _, ok := routes[name]; ok {
+			log.Fatal("backend is configured twice", zap.String("backend", name))
+		}
+
+		routes[name] = config
 	}
 
-	axisProxyURL, err := url.Parse(axisProxy)
+	backends, err := proxy.New(routes, proxy.WithLogger(log))
 	if err != nil {
-		log.Fatal("unable to parse axis proxy url", zap.Error(err))
+		log.Fatal("unable to build backends", zap.Error(err))
 	}
+	defer backends.Close()
 
 	myURL, err := url.Parse(callbackURL)
 	if err != nil {
 		log.Fatal("unable to parse my url", zap.Error(err))
 	}
 
+	for model, template := range modelURLs {
+		if !strings.Contains(template, "{address}") {
+			log.Fatal("model url must contain {address}", zap.String("model", model), zap.String("url", template))
+		}
+	}
+
 	// context for setup
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// build the config service
-	if dbInsecure {
-		dbAddr = "http://" + dbAddr
+	var cs configService
+	if configDir != "" {
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = fc
 	} else {
-		dbAddr = "https://" + dbAddr
-	}
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
 
-	var csOpts []couch.Option
-	if dbUsername != "" {
-		csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
-	}
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
 
-	cs, err := couch.New(ctx, dbAddr, csOpts...)
-	if err != nil {
-		log.Fatal("unable to create config service", zap.Error(err))
+		db, err := couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+
+		cs = db
+		if configCache {
+			cache := couch.NewCache(db, couch.WithCacheLogger(log))
+			defer cache.Close()
+
+			cs = cache
+		}
 	}
 
 	middleware := handlers.Middleware{
@@ -166,6 +246,25 @@ func main() {
 		sessionStore = cookiestore.NewStore()
 	}
 
+	resolver := &net.Resolver{}
+	if len(dnsAddr) > 0 {
+		log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
+
+		resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialer := &net.Dialer{}
+			return dialer.DialContext(ctx, "udp", dnsAddr)
+		}
+	}
+
+	var publisher cameraservices.EventPublisher
+	if eventURL != "" {
+		publisher = &event.Publisher{
+			GeneratingSystem: name,
+			URL:              eventURL,
+			Resolver:         resolver,
+		}
+	}
+
 	auth := &opa.Client{
 		Address:  opaURL,
 		Endpoint: "/v1/data/cameras",
@@ -175,16 +274,18 @@ func main() {
 	}
 
 	handlers := handlers.ControlHandlers{
-		ConfigService: cs,
-		ControlKeyService: &keys.ControlKeyService{
-			Address: keyServiceAddr,
-		},
-		AuthService:  auth,
-		Me:           myURL,
-		Logger:       log,
-		SessionStore: sessionStore,
-		SessionName:  sessionName,
-		DisableAuth:  disableAuth,
+		ConfigService:     cs,
+		ControlKeyService: keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr}, keys.WithCacheLogger(log)),
+		AuthService:       auth,
+		Me:                myURL,
+		Logger:            log,
+		SessionStore:      sessionStore,
+		SessionName:       sessionName,
+		DisableAuth:       disableAuth,
+		Backends:          backends,
+		EventPublisher:    publisher,
+		SceneService:      cs,
+		ModelURLs:         modelURLs,
 	}
 
 	r := gin.New()
@@ -210,20 +311,46 @@ func main() {
 	api := r.Group("/api/v1/", auth.AuthorizeFor("allow"))
 	api.GET("/controlInfo", handlers.GetControlInfo)
 	api.GET("/cameras", handlers.GetCameras)
+	api.GET("/cameras/preset/:preset", middleware.RequestID, middleware.Log, handlers.GroupPreset)
+	api.GET("/cameras/stop", middleware.RequestID, middleware.Log, handlers.GroupStop)
+
+	api.GET("/scenes", handlers.GetScenes)
+	api.GET("/scenes/:scene", handlers.GetScene)
+	api.PUT("/scenes/:scene", auth.AuthorizeFor("editScene"), handlers.SetScene)
+	api.DELETE("/scenes/:scene", auth.AuthorizeFor("editScene"), handlers.DeleteScene)
+	api.GET("/scenes/:scene/recall", auth.AuthorizeFor("recallScene"), middleware.RequestID, middleware.Log, handlers.RecallScene)
+
+	// cameras can only be edited when they are read from the database
+	if inventory, ok := cs.(cameraservices.InventoryService); ok {
+		handlers.InventoryService = inventory
+
+		admin := api.Group("/admin/rooms/:room/controlGroups/:controlGroup/cameras", auth.AuthorizeFor("manageCameras"))
+		admin.POST("", handlers.AddCamera)
+		admin.PUT("/:camera", handlers.SetCamera)
+		admin.DELETE("/:camera", handlers.DeleteCamera)
+	}
 
-	r.GET("/proxy/aver/*uri", handlers.AuthorizeProxy, middleware.RequestID, middleware.Log, handlers.Proxy(averProxyURL))
-	r.GET("/proxy/axis/*uri", handlers.AuthorizeProxy, middleware.RequestID, middleware.Log, handlers.Proxy(axisProxyURL))
+	r.GET("/proxy/:backend/*uri", handlers.AuthorizeProxy, middleware.RequestID, middleware.Log, handlers.Proxy)
+
+	r.GET("/debug/backends", auth.AuthorizeFor("allow"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, backends.Status())
+	})
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Fatal("unable to bind listener", zap.Error(err))
 	}
 
+	ctx, stop := server.SignalContext()
+	defer stop()
+
 	log.Info("Starting server", zap.String("on", lis.Addr().String()))
-	err = r.RunListener(lis)
-	switch {
-	case errors.Is(err, http.ErrServerClosed):
-	case err != nil:
-		log.Fatal("failed to server", zap.Error(err))
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(handlers.Shutdown),
+	)
+	if err != nil {
+		log.Fatal("failed to serve", zap.Error(err))
 	}
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/keyservice"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type Handlers struct {
+	Keys   *keyservice.Service
+	Logger *zap.Logger
+
+	// Token is required to issue, rotate, and revoke keys. It can also look up a control group's key.
+	Token string
+
+	// ReadToken can look up a control group's key, but not manage keys
+	ReadToken string
+
+	// Insecure lets anyone issue, rotate, revoke, and look up keys, without a token
+	Insecure bool
+}
+
+// Lookup serves the endpoints of the control keys service, which identify control groups as "room controlGroup":
+//
+//	GET /:key/getPreset                   -> {"RoomID": room, "PresetName": controlGroup}
+//	GET /:room controlGroup/getControlKey -> {"ControlKey": key}, if the control group has been issued a key
+//
+// Looking up a control group's key requires h.Token or h.ReadToken, since the key is what lets people control its cameras.
+// It is used as the router's NoRoute handler, because gin can't match a wildcard in the same place as /v1 and /debug.
+func (h *Handlers) Lookup(c *gin.Context) {
+	parts := strings.Split(strings.TrimPrefix(c.Request.URL.Path, "/"), "/")
+	if c.Request.Method != http.MethodGet || len(parts) != 2 || parts[0] == "" {
+		c.String(http.StatusNotFound, "not found")
+		return
+	}
+
+	switch parts[1] {
+	case "getPreset":
+		h.getPreset(c, parts[0])
+	case "getControlKey":
+		if !h.authorized(c, h.Token, h.ReadToken) {
+			c.String(http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		h.getControlKey(c, parts[0])
+	default:
+		c.String(http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handlers) getPreset(c *gin.Context, key string) {
+	room, cg, err := h.Keys.RoomAndControlGroup(c.Request.Context(), key)
+	if err != nil {
+		c.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"RoomID":     room,
+		"PresetName": cg,
+	})
+}
+
+func (h *Handlers) getControlKey(c *gin.Context, preset string) {
+	room, cg := splitPreset(preset)
+	if room == "" || cg == "" {
+		c.String(http.StatusBadRequest, "control group must be \"room controlGroup\"")
+		return
+	}
+
+	// keys are only issued through the management api, so that they can't be created without the token
+	key, err := h.Keys.ControlKey(c.Request.Context(), room, cg)
+	switch {
+	case errors.Is(err, cameraservices.ErrControlKeyNotFound):
+		c.String(http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		h.Logger.Warn("unable to get control key", zap.String("room", room), zap.String("controlGroup", cg), zap.Error(err))
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ControlKey": key,
+	})
+}
+
+// splitPreset splits a "room controlGroup" preset on its first space.
+func splitPreset(preset string) (string, string) {
+	i := strings.Index(preset, " ")
+	if i < 0 {
+		return "", ""
+	}
+
+	return preset[:i], preset[i+1:]
+}
+
+// RequireToken aborts requests that don't have h.Token as their bearer token, unless h.Insecure is set.
+// Every request is aborted if h.Token is empty.
+func (h *Handlers) RequireToken(c *gin.Context) {
+	if !h.authorized(c, h.Token) {
+		c.String(http.StatusUnauthorized, "invalid token")
+		c.Abort()
+		return
+	}
+}
+
+// authorized returns true if the request's bearer token is one of tokens (ignoring empty ones), or h.Insecure is set.
+func (h *Handlers) authorized(c *gin.Context, tokens ...string) bool {
+	if h.Insecure {
+		return true
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	for _, t := range tokens {
+		if t != "" && subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Handlers) ListKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Keys.ControlKeys())
+}
+
+// IssueKey returns the control group's key, issuing one if it doesn't have one yet.
+func (h *Handlers) IssueKey(c *gin.Context) {
+	key, err := h.Keys.Issue(c.Request.Context(), c.Param("room"), c.Param("controlGroup"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func (h *Handlers) RotateKey(c *gin.Context) {
+	key, err := h.Keys.Rotate(c.Request.Context(), c.Param("room"), c.Param("controlGroup"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func (h *Handlers) RevokeKey(c *gin.Context) {
+	err := h.Keys.Revoke(c.Request.Context(), c.Param("room"), c.Param("controlGroup"))
+	switch {
+	case errors.Is(err, cameraservices.ErrControlKeyNotFound):
+		c.String(http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/keyservice"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestControlKeysContract(t *testing.T) {
+	ks, err := keyservice.New(context.Background(),
+		&keyservice.FileStore{Path: filepath.Join(t.TempDir(), "keys.json")},
+		keyservice.WithRefreshInterval(0),
+	)
+	require.NoError(t, err)
+	defer ks.Close()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	addRoutes(r, &Handlers{Keys: ks, Logger: zap.NewNop(), Token: "secret", ReadToken: "read"})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	// the existing client can use this service
+	client := &keys.ControlKeyService{Address: strings.TrimPrefix(srv.URL, "http://"), Token: "read"}
+	ctx := context.Background()
+
+	// looking up a key doesn't issue one
+	_, err = client.ControlKey(ctx, "ITB-1101", "ITB-1101 Main")
+	require.Error(t, err)
+	require.Empty(t, ks.ControlKeys())
+
+	_, err = ks.Issue(ctx, "ITB-1101", "ITB-1101 Main")
+	require.NoError(t, err)
+
+	key, err := client.ControlKey(ctx, "ITB-1101", "ITB-1101 Main")
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	room, cg, err := client.RoomAndControlGroup(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+	require.Equal(t, "ITB-1101 Main", cg)
+
+	_, _, err = client.RoomAndControlGroup(ctx, "not-a-key")
+	require.True(t, errors.Is(err, keys.ErrInvalidKey))
+
+	// managing keys requires the token
+	do := func(method, path, token string) int {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodDelete, "/v1/keys/ITB-1101/ITB-1101%20Main", ""))
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v1/keys", "wrong"))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/v1/keys", "secret"))
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/keys/ITB-1102/ITB-1102", "secret"))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/keys/ITB-1102/ITB-1102/rotate", "secret"))
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/keys/ITB-1101/ITB-1101%20Main", "secret"))
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v1/keys/ITB-1101/ITB-1101%20Main", "secret"))
+
+	_, _, err = client.RoomAndControlGroup(ctx, key)
+	require.True(t, errors.Is(err, keys.ErrInvalidKey))
+
+	// looking up a key requires the read token or the management token
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/ITB-1102%20ITB-1102/getControlKey", ""))
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/ITB-1102%20ITB-1102/getControlKey", "wrong"))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/ITB-1102%20ITB-1102/getControlKey", "read"))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/ITB-1102%20ITB-1102/getControlKey", "secret"))
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v1/keys", "read"))
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/ITB-1101/getControlKey", "read"))
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/ITB-1103%20ITB-1103/getControlKey", "read"))
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/1234/somethingElse", ""))
+
+	// without a token, keys can only be managed if it's explicitly allowed
+	r = gin.New()
+	h := &Handlers{Keys: ks, Logger: zap.NewNop()}
+	addRoutes(r, h)
+
+	get := func() int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v1/keys", nil)
+		r.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get())
+
+	h.Insecure = true
+	require.Equal(t, http.StatusOK, get())
+}
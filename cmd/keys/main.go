@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/keyservice"
+	"github.com/byuoitav/camera-services/server"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func main() {
+	var (
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
+
+		dbAddr       string
+		dbUsername   string
+		dbPassword   string
+		dbInsecure   bool
+		controlKeyDB string
+		keyFile      string
+
+		token           string
+		readToken       string
+		insecure        bool
+		keyLength       int
+		rotateInterval  time.Duration
+		gracePeriod     time.Duration
+		refreshInterval time.Duration
+	)
+
+	pflag.IntVarP(&port, "port", "P", 8080, "port to run the server on")
+	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish when shutting down")
+	pflag.StringVar(&dbAddr, "db-address", "", "database address")
+	pflag.StringVar(&dbUsername, "db-username", "", "database username")
+	pflag.StringVar(&dbPassword, "db-password", "", "database password")
+	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&controlKeyDB, "control-key-db", "control-keys", "database to store control keys in")
+	pflag.StringVar(&keyFile, "key-file", "", "json file to store control keys in instead of the database")
+	pflag.StringVar(&token, "token", "", "bearer token required to issue, rotate, and revoke keys")
+	pflag.StringVar(&readToken, "read-token", "", "bearer token required to look up a control group's key (--token is also accepted)")
+	pflag.BoolVar(&insecure, "insecure", false, "let anyone issue, rotate, revoke, and look up keys without a token")
+	pflag.IntVar(&keyLength, "key-length", 6, "how many digits new keys have")
+	pflag.DurationVar(&rotateInterval, "rotate-interval", 7*24*time.Hour, "how long a key is used before it is replaced. 0 only replaces keys when they are rotated by hand")
+	pflag.DurationVar(&gracePeriod, "grace-period", 8*time.Hour, "how long a replaced key is still accepted for")
+	pflag.DurationVar(&refreshInterval, "refresh-interval", time.Minute, "how often to reload keys from the store and check which need to be rotated")
+	pflag.Parse()
+
+	var level zapcore.Level
+	if err := level.Set(logLevel); err != nil {
+		fmt.Printf("invalid log level: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	config := zap.Config{
+		Level:       zap.NewAtomicLevelAt(level),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding: "json", EncoderConfig: zapcore.EncoderConfig{
+			TimeKey:        "@",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			MessageKey:     "msg",
+			StacktraceKey:  "trace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		},
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+
+	log, err := config.Build()
+	if err != nil {
+		fmt.Printf("unable to build logger: %s", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	// validate flags
+	if token == "" && !insecure {
+		log.Fatal("--token is required (or --insecure to allow managing keys without one). use --help for more details")
+	}
+
+	// context for setup
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// build the key store
+	var store cameraservices.ControlKeyStore
+	if keyFile != "" {
+		log.Info("Storing control keys in file", zap.String("file", keyFile))
+		store = &keyservice.FileStore{
+			Path: keyFile,
+		}
+	} else {
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
+
+		csOpts := []couch.Option{
+			couch.WithControlKeyDB(controlKeyDB),
+		}
+
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
+
+		store, err = couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+	}
+
+	keys, err := keyservice.New(ctx, store,
+		keyservice.WithKeyLength(keyLength),
+		keyservice.WithRotateInterval(rotateInterval),
+		keyservice.WithGracePeriod(gracePeriod),
+		keyservice.WithRefreshInterval(refreshInterval),
+		keyservice.WithLogger(log),
+	)
+	if err != nil {
+		log.Fatal("unable to create key service", zap.Error(err))
+	}
+	defer keys.Close()
+
+	if insecure {
+		log.Warn("--insecure is set; anyone can issue, rotate, revoke, and look up keys")
+	}
+
+	handlers := Handlers{
+		Keys:      keys,
+		Logger:    log,
+		Token:     token,
+		ReadToken: readToken,
+		Insecure:  insecure,
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	debug := r.Group("/debug")
+	debug.GET("/healthz", func(c *gin.Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	debug.GET("/logz", func(c *gin.Context) {
+		c.String(http.StatusOK, config.Level.String())
+	})
+	debug.GET("/logz/:level", func(c *gin.Context) {
+		var level zapcore.Level
+		if err := level.Set(c.Param("level")); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fmt.Printf("***\n\tSetting log level to %s\n***\n", level.String())
+		config.Level.SetLevel(level)
+		c.String(http.StatusOK, config.Level.String())
+	})
+
+	addRoutes(r, &handlers)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatal("unable to bind listener", zap.Error(err))
+	}
+
+	runCtx, stop := server.SignalContext()
+	defer stop()
+
+	log.Info("Starting server", zap.String("on", lis.Addr().String()))
+	err = server.Serve(runCtx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+	)
+	if err != nil {
+		log.Fatal("failed to serve", zap.Error(err))
+	}
+}
+
+// addRoutes adds the key management api, and the control keys service endpoints.
+func addRoutes(r *gin.Engine, h *Handlers) {
+	api := r.Group("/v1/keys", h.RequireToken)
+	api.GET("", h.ListKeys)
+	api.PUT("/:room/:controlGroup", h.IssueKey)
+	api.POST("/:room/:controlGroup/rotate", h.RotateKey)
+	api.DELETE("/:room/:controlGroup", h.RevokeKey)
+
+	r.NoRoute(h.Lookup)
+}
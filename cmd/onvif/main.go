@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/drivers/onvif"
+	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
+	"github.com/byuoitav/camera-services/handlers"
+	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/camera-services/server"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func main() {
+	var (
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
+
+		keyServiceAddr string
+
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
+
+		eventURL string
+		name     string
+		dnsAddr  string
+
+		camUsername   string
+		camPassword   string
+		panTiltSpeed  float64
+		zoomSpeed     float64
+		frameInterval time.Duration
+	)
+
+	// List of flags
+	pflag.IntVarP(&port, "port", "P", 8080, "port to run the server on")
+	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
+	pflag.StringVar(&eventURL, "event-url", "", "url to send events to")
+	pflag.StringVar(&name, "name", "", "the name of this service to include in events generated by it")
+	pflag.StringVar(&dnsAddr, "dns-addr", "", "dns server to use for reverse ip lookups")
+	pflag.StringVar(&camUsername, "cam-username", "", "username used to authenticate with the cameras")
+	pflag.StringVar(&camPassword, "cam-password", "", "password used to authenticate with the cameras")
+	pflag.Float64Var(&panTiltSpeed, "pantilt-speed", 0.5, "speed to pan and tilt at (0-1)")
+	pflag.Float64Var(&zoomSpeed, "zoom-speed", 0.5, "speed to zoom at (0-1)")
+	pflag.DurationVar(&frameInterval, "frame-interval", 125*time.Millisecond, "how often to get a snapshot from the camera while streaming")
+	pflag.StringVar(&keyServiceAddr, "key-service", "control-keys.av.byu.edu", "address of the control keys service")
+	pflag.StringVar(&dbAddr, "db-address", "", "database address")
+	pflag.StringVar(&dbUsername, "db-username", "", "database username")
+	pflag.StringVar(&dbPassword, "db-password", "", "database password")
+	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
+	pflag.Parse()
+
+	var level zapcore.Level
+	if err := level.Set(logLevel); err != nil {
+		fmt.Printf("invalid log level: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// build the logger
+	config := zap.Config{
+		Level:       zap.NewAtomicLevelAt(level),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding: "json", EncoderConfig: zapcore.EncoderConfig{
+			TimeKey:        "@",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			MessageKey:     "msg",
+			StacktraceKey:  "stacktrace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		},
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+	// build logging configuration
+	log, err := config.Build()
+	if err != nil {
+		fmt.Printf("unable to build logger: %s", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	// validate flags
+	if name == "" {
+		log.Fatal("--name is required. use --help for more details")
+	}
+
+	// build the config service
+	var cs cameraservices.ConfigService
+	if configDir != "" {
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = fc
+	} else {
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
+
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
+
+		// context for setup
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		db, err := couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+
+		cs = db
+		if configCache {
+			cache := couch.NewCache(db, couch.WithCacheLogger(log))
+			defer cache.Close()
+
+			cs = cache
+		}
+	}
+
+	resolver := &net.Resolver{}
+	if len(dnsAddr) > 0 {
+		log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
+
+		resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialer := &net.Dialer{}
+			return dialer.DialContext(ctx, "udp", dnsAddr)
+		}
+	}
+
+	cameras := manager.New(manager.WithLogger(log))
+	defer cameras.Close()
+
+	middleware := handlers.Middleware{
+		Logger: log,
+	}
+	handlers := handlers.NewCameraController(cs)
+	handlers.Logger = log
+	newCamera := func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return onvif.New(addr,
+			onvif.WithCredentials(camUsername, camPassword),
+			onvif.WithSpeed(panTiltSpeed, zoomSpeed),
+			onvif.WithFrameInterval(frameInterval),
+		), nil
+	}
+	handlers.EventPublisher = &event.Publisher{
+		GeneratingSystem: name,
+		URL:              eventURL,
+		Resolver:         resolver,
+	}
+
+	keyCache := keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr}, keys.WithCacheLogger(log))
+	handlers.ControlKeyService = keyCache
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(cors.Default())
+
+	debug := r.Group("/debug")
+	debug.GET("/healthz", func(c *gin.Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	debug.GET("/cameras", func(c *gin.Context) {
+		c.JSON(http.StatusOK, cameras.Stats())
+	})
+	debug.GET("/keys", func(c *gin.Context) {
+		c.JSON(http.StatusOK, keyCache.Stats())
+	})
+	debug.GET("/config", func(c *gin.Context) {
+		cache, ok := cs.(*couch.Cache)
+		if !ok {
+			c.String(http.StatusNotFound, "config isn't cached")
+			return
+		}
+
+		c.JSON(http.StatusOK, cache.Stats())
+	})
+	debug.GET("/logz", func(c *gin.Context) {
+		c.String(http.StatusOK, config.Level.String())
+	})
+	debug.GET("/logz/:level", func(c *gin.Context) {
+		var level zapcore.Level
+		if err := level.Set(c.Param("level")); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fmt.Printf("***\n\tSetting log level to %s\n***\n", level.String())
+		config.Level.SetLevel(level)
+		c.String(http.StatusOK, config.Level.String())
+	})
+
+	var drivers cameraservices.Registry
+	err = drivers.Register(cameraservices.Driver{
+		Model:        "ONVIF",
+		NewCamera:    cameras.Cameras(newCamera),
+		Capabilities: cameraservices.CapabilitiesOf(&onvif.Camera{}),
+	})
+	if err != nil {
+		log.Fatal("unable to register driver", zap.Error(err))
+	}
+
+	handlers.AddDriverRoutes(r, middleware, &drivers)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatal("unable to bind listener", zap.Error(err))
+	}
+
+	ctx, stop := server.SignalContext()
+	defer stop()
+
+	log.Info("Starting server", zap.String("on", lis.Addr().String()))
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(handlers.Shutdown),
+	)
+	if err != nil {
+		log.Fatal("failed to serve", zap.Error(err))
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
+	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/schedule"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func main() {
+	var (
+		logLevel string
+
+		dbAddr     string
+		dbUsername string
+		dbPassword string
+		dbInsecure bool
+		configDir  string
+		scheduleDB string
+
+		keyServiceAddr string
+		keyToken       string
+		services       map[string]string
+
+		eventURL string
+		name     string
+		dnsAddr  string
+
+		scheduleDir     string
+		timeZone        string
+		refreshInterval time.Duration
+	)
+
+	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.StringVar(&dbAddr, "db-address", "", "database address")
+	pflag.StringVar(&dbUsername, "db-username", "", "database username")
+	pflag.StringVar(&dbPassword, "db-password", "", "database password")
+	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database. requires --schedule-dir")
+	pflag.StringVar(&scheduleDB, "schedule-db", "camera-schedules", "database to read schedules from")
+	pflag.StringVar(&keyServiceAddr, "key-service", "control-keys.av.byu.edu", "address of the control keys service")
+	pflag.StringVar(&keyToken, "key-service-token", "", "bearer token to look up control keys with")
+	pflag.StringToStringVar(&services, "camera-service", nil, "url of a camera service that cameras with a model, address, and service are controlled through, ie. aver=http://aver.av.byu.edu")
+	pflag.StringVar(&eventURL, "event-url", "", "url to send events to")
+	pflag.StringVar(&name, "name", "", "the name of this service to include in events generated by it")
+	pflag.StringVar(&dnsAddr, "dns-addr", "", "dns server to use for reverse ip lookups")
+	pflag.StringVar(&scheduleDir, "schedule-dir", "", "directory of iCalendar files to read schedules from instead of the database")
+	pflag.StringVar(&timeZone, "time-zone", "", "time zone to use for schedules that don't specify one")
+	pflag.DurationVar(&refreshInterval, "refresh-interval", 5*time.Minute, "how often to reload schedules")
+	pflag.Parse()
+
+	var level zapcore.Level
+	if err := level.Set(logLevel); err != nil {
+		fmt.Printf("invalid log level: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	config := zap.Config{
+		Level:       zap.NewAtomicLevelAt(level),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding: "json", EncoderConfig: zapcore.EncoderConfig{
+			TimeKey:        "@",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			MessageKey:     "msg",
+			StacktraceKey:  "trace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		},
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+
+	log, err := config.Build()
+	if err != nil {
+		fmt.Printf("unable to build logger: %s", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	// validate flags
+	if name == "" {
+		log.Fatal("--name is required. use --help for more details")
+	}
+
+	// context for setup
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// build the config service
+	var cs interface {
+		cameraservices.ConfigService
+		cameraservices.ScheduleService
+	}
+
+	if configDir != "" {
+		if scheduleDir == "" {
+			log.Fatal("--schedule-dir is required with --config-dir. use --help for more details")
+		}
+
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = noSchedules{fc}
+	} else {
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
+
+		csOpts := []couch.Option{
+			couch.WithScheduleDB(scheduleDB),
+		}
+
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
+
+		cs, err = couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+	}
+
+	resolver := &net.Resolver{}
+	if len(dnsAddr) > 0 {
+		log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
+
+		resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialer := &net.Dialer{}
+			return dialer.DialContext(ctx, "udp", dnsAddr)
+		}
+	}
+
+	loc := time.Local
+	if timeZone != "" {
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			log.Fatal("invalid time zone", zap.Error(err))
+		}
+	}
+
+	var source cameraservices.ScheduleService = cs
+	if scheduleDir != "" {
+		log.Info("Reading schedules from directory", zap.String("dir", scheduleDir))
+		source = &schedule.FileSource{
+			Dir: scheduleDir,
+		}
+	}
+
+	scheduler := &schedule.Scheduler{
+		Source: source,
+		Recaller: &schedule.HTTPRecaller{
+			ConfigService: cs,
+			ControlKeyService: &keys.ControlKeyService{
+				Address: keyServiceAddr,
+				Token:   keyToken,
+			},
+			Services: services,
+			Resolver: resolver,
+		},
+		EventPublisher: &event.Publisher{
+			GeneratingSystem: name,
+			URL:              eventURL,
+			Resolver:         resolver,
+		},
+		Logger:          log,
+		RefreshInterval: refreshInterval,
+		Location:        loc,
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("Starting scheduler")
+	err = scheduler.Run(runCtx)
+	switch {
+	case errors.Is(err, context.Canceled):
+	case err != nil:
+		log.Fatal("failed to run scheduler", zap.Error(err))
+	}
+}
+
+// noSchedules is a config service without any schedules, which are read from --schedule-dir instead.
+type noSchedules struct {
+	cameraservices.ConfigService
+}
+
+func (noSchedules) Schedules(ctx context.Context) ([]cameraservices.ScheduleEntry, error) {
+	return nil, nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/drivers/sim"
+	"github.com/byuoitav/camera-services/fileconfig"
+	"github.com/byuoitav/camera-services/handlers"
+	"github.com/byuoitav/camera-services/keys"
+	"github.com/byuoitav/camera-services/manager"
+	"github.com/byuoitav/camera-services/server"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// models are the models the simulated cameras are served as, so that
+// this service can stand in for any of the other camera services
+var models = []string{
+	"Sim",
+	"Pro520",
+	"P5414-E",
+	"V5915",
+	"VISCA",
+	"ONVIF",
+}
+
+func main() {
+	var (
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
+
+		keyServiceAddr string
+
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
+
+		latency       time.Duration
+		jitter        time.Duration
+		failureRate   float64
+		frameInterval time.Duration
+		frameWidth    int
+		frameHeight   int
+		rebootTime    time.Duration
+	)
+
+	// List of flags
+	pflag.IntVarP(&port, "port", "P", 8080, "port to run the server on")
+	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
+	pflag.DurationVar(&latency, "latency", 0, "how long every camera command takes")
+	pflag.DurationVar(&jitter, "jitter", 0, "max random time added to the latency of each command")
+	pflag.Float64Var(&failureRate, "failure-rate", 0, "fraction (0-1) of camera commands that fail")
+	pflag.DurationVar(&frameInterval, "frame-interval", 125*time.Millisecond, "how often to render a frame while streaming")
+	pflag.IntVar(&frameWidth, "frame-width", 640, "width of rendered frames")
+	pflag.IntVar(&frameHeight, "frame-height", 360, "height of rendered frames")
+	pflag.DurationVar(&rebootTime, "reboot-time", 5*time.Second, "how long cameras are unavailable after rebooting")
+	pflag.StringVar(&keyServiceAddr, "key-service", "", "address of the control keys service. any control key is accepted if empty")
+	pflag.StringVar(&dbAddr, "db-address", "", "database address. required if --key-service is set, unless --config-dir is")
+	pflag.StringVar(&dbUsername, "db-username", "", "database username")
+	pflag.StringVar(&dbPassword, "db-password", "", "database password")
+	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
+	pflag.Parse()
+
+	var level zapcore.Level
+	if err := level.Set(logLevel); err != nil {
+		fmt.Printf("invalid log level: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// build the logger
+	config := zap.Config{
+		Level:       zap.NewAtomicLevelAt(level),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding: "json", EncoderConfig: zapcore.EncoderConfig{
+			TimeKey:        "@",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			MessageKey:     "msg",
+			StacktraceKey:  "stacktrace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		},
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+	// build logging configuration
+	log, err := config.Build()
+	if err != nil {
+		fmt.Printf("unable to build logger: %s", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = log.Sync()
+	}()
+
+	if keyServiceAddr == "" {
+		log.Warn("no --key-service set; accepting any control key")
+	}
+
+	// build the config service, if we are checking control keys
+	var cs cameraservices.ConfigService = openAccess{}
+	var ks cameraservices.ControlKeyService
+
+	if keyServiceAddr != "" {
+		if configDir != "" {
+			log.Info("Reading config from directory", zap.String("dir", configDir))
+
+			fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+			if err != nil {
+				log.Fatal("unable to create config service", zap.Error(err))
+			}
+			defer fc.Close()
+
+			cs = fc
+		} else {
+			if dbInsecure {
+				dbAddr = "http://" + dbAddr
+			} else {
+				dbAddr = "https://" + dbAddr
+			}
+
+			var csOpts []couch.Option
+			if dbUsername != "" {
+				csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			db, err := couch.New(ctx, dbAddr, csOpts...)
+			if err != nil {
+				log.Fatal("unable to create config service", zap.Error(err))
+			}
+
+			cs = db
+			if configCache {
+				cache := couch.NewCache(db, couch.WithCacheLogger(log))
+				defer cache.Close()
+
+				cs = cache
+			}
+		}
+
+		ks = keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr}, keys.WithCacheLogger(log))
+	}
+
+	// simulated cameras are never evicted, so that they keep their position
+	cameras := manager.New(manager.WithLogger(log), manager.WithIdleTimeout(0), manager.WithMaxAge(0))
+	defer cameras.Close()
+
+	middleware := handlers.Middleware{
+		Logger: log,
+	}
+	handlers := handlers.NewCameraController(cs)
+	handlers.Logger = log
+	handlers.ControlKeyService = ks
+	handlers.DisableKeyCheck = ks == nil
+	newCamera := cameras.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return sim.New(addr,
+			sim.WithLatency(latency, jitter),
+			sim.WithFailureRate(failureRate),
+			sim.WithFrameInterval(frameInterval),
+			sim.WithFrameSize(frameWidth, frameHeight),
+			sim.WithRebootTime(rebootTime),
+		), nil
+	})
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(cors.Default())
+
+	debug := r.Group("/debug")
+	debug.GET("/healthz", func(c *gin.Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	debug.GET("/cameras", func(c *gin.Context) {
+		c.JSON(http.StatusOK, cameras.Stats())
+	})
+	debug.GET("/keys", func(c *gin.Context) {
+		cache, ok := ks.(*keys.Cache)
+		if !ok {
+			c.String(http.StatusNotFound, "control keys aren't checked")
+			return
+		}
+
+		c.JSON(http.StatusOK, cache.Stats())
+	})
+	debug.GET("/config", func(c *gin.Context) {
+		cache, ok := cs.(*couch.Cache)
+		if !ok {
+			c.String(http.StatusNotFound, "config isn't cached")
+			return
+		}
+
+		c.JSON(http.StatusOK, cache.Stats())
+	})
+	debug.GET("/logz", func(c *gin.Context) {
+		c.String(http.StatusOK, config.Level.String())
+	})
+	debug.GET("/logz/:level", func(c *gin.Context) {
+		var level zapcore.Level
+		if err := level.Set(c.Param("level")); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fmt.Printf("***\n\tSetting log level to %s\n***\n", level.String())
+		config.Level.SetLevel(level)
+		c.String(http.StatusOK, config.Level.String())
+	})
+
+	var drivers cameraservices.Registry
+	for _, model := range models {
+		err := drivers.Register(cameraservices.Driver{
+			Model:        model,
+			NewCamera:    newCamera,
+			Capabilities: cameraservices.CapabilitiesOf(&sim.Camera{}),
+		})
+		if err != nil {
+			log.Fatal("unable to register driver", zap.Error(err))
+		}
+	}
+
+	handlers.AddDriverRoutes(r, middleware, &drivers)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatal("unable to bind listener", zap.Error(err))
+	}
+
+	ctx, stop := server.SignalContext()
+	defer stop()
+
+	log.Info("Starting server", zap.String("on", lis.Addr().String()))
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(handlers.Shutdown),
+	)
+	if err != nil {
+		log.Fatal("failed to serve", zap.Error(err))
+	}
+}
+
+// openAccess is the config service when control keys aren't checked. It has no cameras.
+type openAccess struct{}
+
+func (openAccess) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	return nil, nil
+}
+
+func (openAccess) ControlIP(ctx context.Context, room string) ([]string, error) {
+	return nil, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/byuoitav/aver"
 	"github.com/byuoitav/axis"
 	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/fileconfig"
 	"github.com/byuoitav/central-event-system/hub/base"
 	"github.com/byuoitav/central-event-system/messenger"
 	"github.com/byuoitav/common/v2/events"
@@ -55,6 +56,7 @@ func main() {
 		dbUsername string
 		dbPassword string
 		dbInsecure bool
+		configDir  string
 	)
 
 	d := &data{
@@ -65,6 +67,7 @@ func main() {
 	pflag.StringVar(&dbUsername, "db-username", "", "database username")
 	pflag.StringVar(&dbPassword, "db-password", "", "database password")
 	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
 	pflag.StringVar(&slackToken, "slack-token", "", "slack token")
 	pflag.StringVar(&d.slackChannelID, "channel-id", "", "slack channel id")
 	pflag.StringVar(&d.averUsername, "aver-username", "", "aver camera username")
@@ -80,23 +83,35 @@ func main() {
 	defer cancel()
 
 	// build the config service
-	if dbInsecure {
-		dbAddr = "http://" + dbAddr
+	if configDir != "" {
+		log.Printf("Reading config from %s", configDir)
+
+		fc, err := fileconfig.New(configDir)
+		if err != nil {
+			log.Fatalf("unable to create config service: %s", err)
+		}
+		defer fc.Close()
+
+		d.configService = fc
 	} else {
-		dbAddr = "https://" + dbAddr
-	}
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
 
-	var csOpts []couch.Option
-	if dbUsername != "" {
-		csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
-	}
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
 
-	cs, err := couch.New(sctx, dbAddr, csOpts...)
-	if err != nil {
-		log.Fatalf("unable to create config service: %s", err)
-	}
+		cs, err := couch.New(sctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatalf("unable to create config service: %s", err)
+		}
 
-	d.configService = cs
+		d.configService = cs
+	}
 
 	// get all of the events
 	messenger, nerr := messenger.BuildMessenger(hubAddress, base.Messenger, 4096)
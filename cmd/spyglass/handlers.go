@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/byuoitav/camera-services/health"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,6 +21,54 @@ type Handlers struct {
 	ControlKeyService interface {
 		ControlKey(context.Context, string, string) (string, error)
 	}
+
+	// Poller is optional; the health endpoints respond with a 503 if it isn't set
+	Poller *health.Poller
+}
+
+type healthResponse struct {
+	LastPoll time.Time             `json:"lastPoll"`
+	Up       int                   `json:"up"`
+	Down     int                   `json:"down"`
+	Cameras  []health.CameraHealth `json:"cameras"`
+}
+
+// GetHealth responds with the health of every camera from the poller's last poll.
+// Only cameras in room are included if the room query parameter is set, and
+// only cameras that are down are included if down=true.
+func (h *Handlers) GetHealth(c *gin.Context) {
+	if h.Poller == nil {
+		c.String(http.StatusServiceUnavailable, "camera health polling is disabled")
+		return
+	}
+
+	room := c.Query("room")
+	down := c.Query("down") == "true"
+
+	resp := healthResponse{
+		LastPoll: h.Poller.LastPoll(),
+		Cameras:  []health.CameraHealth{},
+	}
+
+	for _, cam := range h.Poller.Cameras() {
+		if room != "" && cam.Room != room {
+			continue
+		}
+
+		if cam.Up {
+			resp.Up++
+		} else {
+			resp.Down++
+		}
+
+		if down && cam.Up {
+			continue
+		}
+
+		resp.Cameras = append(resp.Cameras, cam)
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Handlers) GetRooms(c *gin.Context) {
@@ -2,10 +2,8 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -14,8 +12,12 @@ import (
 	"github.com/byuoitav/camera-services/auth/session/cookiestore"
 	"github.com/byuoitav/camera-services/auth/wso2"
 	"github.com/byuoitav/camera-services/couch"
+	"github.com/byuoitav/camera-services/event"
+	"github.com/byuoitav/camera-services/fileconfig"
+	"github.com/byuoitav/camera-services/health"
 	"github.com/byuoitav/camera-services/keys"
 	"github.com/byuoitav/camera-services/opa"
+	"github.com/byuoitav/camera-services/server"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	adapter "github.com/gwatts/gin-adapter"
@@ -30,15 +32,19 @@ const (
 
 func main() {
 	var (
-		port     int
-		logLevel string
+		port            int
+		logLevel        string
+		shutdownTimeout time.Duration
 
-		dbAddr     string
-		dbUsername string
-		dbPassword string
-		dbInsecure bool
+		dbAddr      string
+		dbUsername  string
+		dbPassword  string
+		dbInsecure  bool
+		configDir   string
+		configCache bool
 
 		keyServiceAddr string
+		keyToken       string
 
 		callbackURL  string
 		clientID     string
@@ -50,15 +56,25 @@ func main() {
 		disableAuth bool
 
 		controlURLFormat string
+
+		eventURL     string
+		name         string
+		dnsAddr      string
+		pollInterval time.Duration
+		services     map[string]string
 	)
 
 	pflag.CommandLine.IntVarP(&port, "port", "P", 8080, "port to run the server on")
 	pflag.StringVarP(&logLevel, "log-level", "L", "", "level to log at. refer to https://godoc.org/go.uber.org/zap/zapcore#Level for options")
+	pflag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for requests to finish and cameras to stop when shutting down")
 	pflag.StringVar(&dbAddr, "db-address", "", "database address")
 	pflag.StringVar(&dbUsername, "db-username", "", "database username")
 	pflag.StringVar(&dbPassword, "db-password", "", "database password")
 	pflag.BoolVar(&dbInsecure, "db-insecure", false, "don't use SSL in database connection")
+	pflag.StringVar(&configDir, "config-dir", "", "directory of room config documents (yaml or json) to use instead of the database")
+	pflag.BoolVar(&configCache, "config-cache", true, "cache room config in memory, following the database's changes feed")
 	pflag.StringVar(&keyServiceAddr, "key-service", "control-keys.av.byu.edu", "address of the control keys service")
+	pflag.StringVar(&keyToken, "key-service-token", "", "bearer token to look up control keys with")
 	pflag.StringVar(&callbackURL, "callback-url", "http://localhost:8080", "wso2 callback url")
 	pflag.StringVar(&clientID, "client-id", "", "wso2 client ID")
 	pflag.StringVar(&clientSecret, "client-secret", "", "wso2 client secret")
@@ -67,6 +83,11 @@ func main() {
 	pflag.StringVar(&opaToken, "opa-token", "", "The token to use for OPA")
 	pflag.BoolVar(&disableAuth, "disable-auth", false, "Disable all auth z/n checks")
 	pflag.StringVar(&controlURLFormat, "control-url", "https://cameras.av.byu.edu/key-login?key=%s", "The url format string of the camera control service")
+	pflag.StringVar(&eventURL, "event-url", "", "url to send camera up/down events to")
+	pflag.StringVar(&name, "name", "camera-services-spyglass", "the name of this service to include in events generated by it")
+	pflag.StringVar(&dnsAddr, "dns-addr", "", "dns server to use for reverse ip lookups")
+	pflag.DurationVar(&pollInterval, "poll-interval", 5*time.Minute, "how often to check the health of every camera. 0 disables health polling")
+	pflag.StringToStringVar(&services, "camera-service", nil, "url of a camera service that cameras with a model, address, and service are probed through, ie. aver=http://aver.av.byu.edu")
 
 	var level zapcore.Level
 	if err := level.Set(logLevel); err != nil {
@@ -114,28 +135,100 @@ func main() {
 	defer cancel()
 
 	// build the config service
-	if dbInsecure {
-		dbAddr = "http://" + dbAddr
+	var cs health.ConfigService
+	if configDir != "" {
+		log.Info("Reading config from directory", zap.String("dir", configDir))
+
+		fc, err := fileconfig.New(configDir, fileconfig.WithLogger(log))
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+		defer fc.Close()
+
+		cs = fc
 	} else {
-		dbAddr = "https://" + dbAddr
-	}
+		if dbInsecure {
+			dbAddr = "http://" + dbAddr
+		} else {
+			dbAddr = "https://" + dbAddr
+		}
 
-	var csOpts []couch.Option
-	if dbUsername != "" {
-		csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
-	}
+		var csOpts []couch.Option
+		if dbUsername != "" {
+			csOpts = append(csOpts, couch.WithBasicAuth(dbUsername, dbPassword))
+		}
 
-	cs, err := couch.New(ctx, dbAddr, csOpts...)
-	if err != nil {
-		log.Fatal("unable to create config service", zap.Error(err))
+		db, err := couch.New(ctx, dbAddr, csOpts...)
+		if err != nil {
+			log.Fatal("unable to create config service", zap.Error(err))
+		}
+
+		cs = db
+		if configCache {
+			cache := couch.NewCache(db, couch.WithCacheLogger(log))
+			defer cache.Close()
+
+			cs = cache
+		}
 	}
 
+	keyService := keys.NewCache(&keys.ControlKeyService{Address: keyServiceAddr, Token: keyToken}, keys.WithCacheLogger(log))
+
 	handlers := Handlers{
 		CameraControlURLFormat: controlURLFormat,
 		ConfigService:          cs,
-		ControlKeyService: &keys.ControlKeyService{
-			Address: keyServiceAddr,
-		},
+		ControlKeyService:      keyService,
+	}
+
+	ctx, stop := server.SignalContext()
+	defer stop()
+
+	// the poller stops once the service is shutting down
+	polled := make(chan struct{})
+	waitForPoller := func(ctx context.Context) error {
+		select {
+		case <-polled:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if pollInterval > 0 {
+		resolver := &net.Resolver{}
+		if len(dnsAddr) > 0 {
+			log.Info("Using custom DNS resolver for reserve IP lookups", zap.String("addr", dnsAddr))
+
+			resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialer := &net.Dialer{}
+				return dialer.DialContext(ctx, "udp", dnsAddr)
+			}
+		}
+
+		poller := &health.Poller{
+			ConfigService:     cs,
+			ControlKeyService: keyService,
+			Services:          services,
+			Logger:            log.Named("health"),
+			Interval:          pollInterval,
+			Resolver:          resolver,
+		}
+
+		if eventURL != "" {
+			poller.EventPublisher = &event.Publisher{
+				GeneratingSystem: name,
+				URL:              eventURL,
+				Resolver:         resolver,
+			}
+		}
+
+		handlers.Poller = poller
+		go func() {
+			defer close(polled)
+			_ = poller.Run(ctx)
+		}()
+	} else {
+		close(polled)
 	}
 
 	wso2 := wso2.New(clientID, clientSecret, gatewayURL, callbackURL)
@@ -173,6 +266,7 @@ func main() {
 	api.GET("/rooms", handlers.GetRooms)
 	api.GET("/rooms/:room/controlGroups", handlers.GetControlGroups)
 	api.GET("/rooms/:room/controlGroups/:controlGroup", handlers.ControlPage)
+	api.GET("/health", handlers.GetHealth)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
@@ -180,11 +274,13 @@ func main() {
 	}
 
 	log.Info("Starting server", zap.String("on", lis.Addr().String()))
-	err = r.RunListener(lis)
-	switch {
-	case errors.Is(err, http.ErrServerClosed):
-	case err != nil:
-		log.Fatal("failed to server", zap.Error(err))
+	err = server.Serve(ctx, lis, r,
+		server.WithTimeout(shutdownTimeout),
+		server.WithLogger(log),
+		server.WithShutdown(waitForPoller),
+	)
+	if err != nil {
+		log.Fatal("failed to serve", zap.Error(err))
 	}
 
 }
@@ -0,0 +1,411 @@
+// Package configlint checks room config documents, in the shape of the ui-configuration database's documents,
+// for mistakes that silently break a room's cameras: urls that don't parse, models no camera service serves,
+// urls that point at the wrong camera, duplicate presets, and camera hostnames that events can't be attributed from.
+package configlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// Severity is how serious an Issue is.
+type Severity string
+
+const (
+	// SeverityError means the issue breaks part of the room.
+	SeverityError Severity = "error"
+
+	// SeverityWarning means the room works, but something about it (ie. its events) won't.
+	SeverityWarning Severity = "warning"
+)
+
+// The rules each Issue is reported under.
+const (
+	RuleParse           = "parse"
+	RuleURL             = "url"
+	RuleMissing         = "missing"
+	RuleRoute           = "route"
+	RuleModel           = "model"
+	RuleService         = "service"
+	RuleCamera          = "camera"
+	RuleDuplicatePreset = "duplicate-preset"
+	RuleHostname        = "hostname"
+)
+
+// Room is a room's config document.
+type Room struct {
+	ID            string         `json:"_id"`
+	ControlGroups []ControlGroup `json:"presets"`
+}
+
+// ControlGroup is a control group in a room's config document.
+type ControlGroup struct {
+	Name    string                        `json:"name"`
+	Cameras []cameraservices.CameraConfig `json:"cameras"`
+}
+
+// Issue is a problem found in a document.
+type Issue struct {
+	Document     string   `json:"document"`
+	Room         string   `json:"room,omitempty"`
+	ControlGroup string   `json:"controlGroup,omitempty"`
+	Camera       string   `json:"camera,omitempty"`
+	Field        string   `json:"field,omitempty"`
+	Rule         string   `json:"rule"`
+	Severity     Severity `json:"severity"`
+	Message      string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	var where []string
+	for _, s := range []string{i.Room, i.ControlGroup, i.Camera, i.Field} {
+		if s != "" {
+			where = append(where, s)
+		}
+	}
+
+	if len(where) == 0 {
+		return fmt.Sprintf("%s: %s [%s] %s", i.Document, i.Severity, i.Rule, i.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s [%s] %s", i.Document, strings.Join(where, "/"), i.Severity, i.Rule, i.Message)
+}
+
+// Report is the result of linting a set of documents.
+type Report struct {
+	Documents int     `json:"documents"`
+	Errors    int     `json:"errors"`
+	Warnings  int     `json:"warnings"`
+	Issues    []Issue `json:"issues"`
+}
+
+// Add adds the issues found in a document to the report.
+func (r *Report) Add(issues []Issue) {
+	r.Documents++
+
+	for _, issue := range issues {
+		switch issue.Severity {
+		case SeverityError:
+			r.Errors++
+		case SeverityWarning:
+			r.Warnings++
+		}
+
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// _hostname is the BLDG-ROOM-CP# convention the event publisher parses camera hostnames with.
+var _hostname = regexp.MustCompile(`^[A-Za-z0-9]+-[A-Za-z0-9]+-[A-Za-z]+[0-9]+$`)
+
+// _routes are the camera service routes each url field is expected to use, after /v1/:model/:address.
+var _routes = map[string]*regexp.Regexp{
+	"tiltUp":      regexp.MustCompile(`^/pantilt/up$`),
+	"tiltDown":    regexp.MustCompile(`^/pantilt/down$`),
+	"panLeft":     regexp.MustCompile(`^/pantilt/left$`),
+	"panRight":    regexp.MustCompile(`^/pantilt/right$`),
+	"panTiltStop": regexp.MustCompile(`^/pantilt/stop$`),
+	"zoomIn":      regexp.MustCompile(`^/zoom/in$`),
+	"zoomOut":     regexp.MustCompile(`^/zoom/out$`),
+	"zoomStop":    regexp.MustCompile(`^/zoom/stop$`),
+	"stream":      regexp.MustCompile(`^/stream$`),
+	"reboot":      regexp.MustCompile(`^/reboot$`),
+	"setPreset":   regexp.MustCompile(`^/preset/[^/]+$`),
+	"savePreset":  regexp.MustCompile(`^/savePreset/[^/]+$`),
+}
+
+// Linter checks room config documents.
+type Linter struct {
+	models map[string]string
+}
+
+// New creates a Linter.
+func New(opts ...Option) *Linter {
+	options := options{
+		models: _defaultModels,
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Linter{
+		models: options.models,
+	}
+}
+
+// Document lints the json document named name. The document's room defaults to name.
+func (l *Linter) Document(name string, data []byte) []Issue {
+	var room Room
+	if err := json.Unmarshal(data, &room); err != nil {
+		return []Issue{{
+			Document: name,
+			Rule:     RuleParse,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unable to parse document: %s", err),
+		}}
+	}
+
+	if room.ID == "" {
+		room.ID = name
+	}
+
+	issues := l.Room(room)
+	for i := range issues {
+		issues[i].Document = name
+	}
+
+	return issues
+}
+
+// Room lints room's config.
+func (l *Linter) Room(room Room) []Issue {
+	var issues []Issue
+	for _, cg := range room.ControlGroups {
+		for _, cam := range cg.Cameras {
+			c := camera{
+				linter: l,
+				base: Issue{
+					Document:     room.ID,
+					Room:         room.ID,
+					ControlGroup: cg.Name,
+					Camera:       cam.DisplayName,
+				},
+			}
+
+			c.lint(cam)
+			issues = append(issues, c.issues...)
+		}
+	}
+
+	return issues
+}
+
+// camera collects the issues found in a camera's config.
+type camera struct {
+	linter *Linter
+	base   Issue
+	issues []Issue
+
+	// addresses are the addresses used by each of the camera's urls, keyed by field
+	addresses map[string]string
+
+	// address is the camera's configured address, if it has one
+	address string
+}
+
+func (c *camera) add(field, rule string, severity Severity, format string, a ...interface{}) {
+	issue := c.base
+	issue.Field = field
+	issue.Rule = rule
+	issue.Severity = severity
+	issue.Message = fmt.Sprintf(format, a...)
+
+	c.issues = append(c.issues, issue)
+}
+
+func (c *camera) lint(cam cameraservices.CameraConfig) {
+	c.addresses = make(map[string]string)
+
+	if cam.DisplayName == "" {
+		c.add("displayName", RuleMissing, SeverityError, "camera has no displayName")
+	}
+
+	structured := cam.Model != "" || cam.Address != "" || cam.Service != ""
+	switch {
+	case structured:
+		c.structured(cam)
+	case cam.Stream == "":
+		c.add("stream", RuleMissing, SeverityError, "camera has no stream url")
+	}
+
+	for _, f := range []struct {
+		field string
+		url   string
+	}{
+		{"tiltUp", cam.TiltUp},
+		{"tiltDown", cam.TiltDown},
+		{"panLeft", cam.PanLeft},
+		{"panRight", cam.PanRight},
+		{"panTiltStop", cam.PanTiltStop},
+		{"zoomIn", cam.ZoomIn},
+		{"zoomOut", cam.ZoomOut},
+		{"zoomStop", cam.ZoomStop},
+		{"stream", cam.Stream},
+		{"reboot", cam.Reboot},
+	} {
+		c.url(f.field, f.field, f.url)
+	}
+
+	names := make(map[string]bool)
+	for i, preset := range cam.Presets {
+		field := fmt.Sprintf("presets[%d]", i)
+
+		switch {
+		case preset.DisplayName == "":
+			c.add(field, RuleMissing, SeverityError, "preset has no displayName")
+		case names[preset.DisplayName]:
+			c.add(field, RuleDuplicatePreset, SeverityError, "preset %q is defined more than once", preset.DisplayName)
+		}
+
+		names[preset.DisplayName] = true
+
+		switch {
+		case structured && preset.SetPreset == "" && preset.Preset == "":
+			c.add(field+".preset", RuleMissing, SeverityError, "preset has no preset or setPreset url")
+		case !structured && preset.SetPreset == "":
+			c.add(field+".setPreset", RuleMissing, SeverityError, "preset has no setPreset url")
+		}
+
+		c.url(field+".setPreset", "setPreset", preset.SetPreset)
+		c.url(field+".savePreset", "savePreset", preset.SavePreset)
+	}
+
+	c.sameCamera()
+}
+
+// structured lints a camera described by its model, address, and service. The urls of the camera
+// are derived from them, so any urls the camera also has must point at the same address.
+func (c *camera) structured(cam cameraservices.CameraConfig) {
+	for _, f := range []struct {
+		field string
+		value string
+	}{
+		{"model", cam.Model},
+		{"address", cam.Address},
+		{"service", cam.Service},
+	} {
+		if f.value == "" {
+			c.add(f.field, RuleMissing, SeverityError, "camera has no %s, but has a model, address, or service", f.field)
+		}
+	}
+
+	if _, ok := c.linter.models[cam.Model]; cam.Model != "" && !ok {
+		c.add("model", RuleModel, SeverityError, "model %q isn't served by a known camera service", cam.Model)
+	}
+
+	if cam.Address != "" {
+		c.address = cam.Address
+	}
+}
+
+// url lints the url in field, which is expected to use route.
+func (c *camera) url(field, route, u string) {
+	if u == "" {
+		return
+	}
+
+	parsed, err := url.Parse(u)
+	switch {
+	case err != nil:
+		c.add(field, RuleURL, SeverityError, "unable to parse url: %s", err)
+		return
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		c.add(field, RuleURL, SeverityError, "url %q must be http or https", u)
+		return
+	case parsed.Host == "":
+		c.add(field, RuleURL, SeverityError, "url %q has no host", u)
+		return
+	}
+
+	// camera service urls look like /v1/:model/:address/...
+	split := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 4)
+	if len(split) != 4 || split[0] != "v1" || split[1] == "" || split[2] == "" {
+		c.add(field, RuleURL, SeverityError, "url %q isn't a camera service url (/v1/:model/:address/...)", u)
+		return
+	}
+
+	model, address, rest := split[1], split[2], "/"+split[3]
+
+	if !_routes[route].MatchString(rest) {
+		c.add(field, RuleRoute, SeverityError, "url %q doesn't use the %s route", u, route)
+	}
+
+	service, ok := c.linter.models[model]
+	switch {
+	case !ok:
+		c.add(field, RuleModel, SeverityError, "model %q isn't served by a known camera service", model)
+	default:
+		// camera service hosts are named after their service (ie. aver.av.byu.edu)
+		for _, other := range c.linter.models {
+			if other != service && strings.Contains(parsed.Hostname(), other) {
+				c.add(field, RuleService, SeverityError, "model %q is served by %s, but the url's host is %s", model, service, parsed.Host)
+				break
+			}
+		}
+	}
+
+	c.addresses[field] = address
+}
+
+// hostname checks that address follows the convention events are attributed to rooms with.
+func (c *camera) hostname(address string) {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+
+	if net.ParseIP(host) != nil {
+		c.add("", RuleHostname, SeverityWarning, "camera address %q is an ip address, so its events can't be attributed to a room", address)
+		return
+	}
+
+	name := strings.SplitN(host, ".", 2)[0]
+	if !_hostname.MatchString(name) {
+		c.add("", RuleHostname, SeverityWarning, "camera hostname %q doesn't follow the BLDG-ROOM-CP# convention", host)
+	}
+}
+
+// sameCamera checks that each of the camera's urls use the same address as its configured address or its stream,
+// or as most of its urls if it has neither, and that the address is a valid hostname.
+func (c *camera) sameCamera() {
+	if c.address != "" {
+		c.hostname(c.address)
+	}
+
+	if len(c.addresses) == 0 {
+		return
+	}
+
+	expected, ok := c.address, c.address != ""
+	if !ok {
+		expected, ok = c.addresses["stream"]
+	}
+
+	if !ok {
+		counts := make(map[string]int)
+		for _, addr := range c.addresses {
+			counts[strings.ToLower(addr)]++
+		}
+
+		for addr, count := range counts {
+			if count > counts[expected] || (count == counts[expected] && addr < expected) {
+				expected = addr
+			}
+		}
+	}
+
+	if c.address == "" {
+		c.hostname(expected)
+	}
+
+	fields := make([]string, 0, len(c.addresses))
+	for field := range c.addresses {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if addr := c.addresses[field]; !strings.EqualFold(addr, expected) {
+			c.add(field, RuleCamera, SeverityError, "url points at %s, but the camera's other urls point at %s", addr, expected)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package configlint
+
+import (
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+func validCamera() cameraservices.CameraConfig {
+	return cameraservices.CameraConfig{
+		DisplayName: "Front",
+		PanLeft:     "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/left",
+		PanTiltStop: "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/stop",
+		ZoomIn:      "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/zoom/in",
+		Stream:      "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream",
+		Presets: []cameraservices.CameraPreset{
+			{
+				DisplayName: "Podium",
+				SetPreset:   "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/preset/1",
+				SavePreset:  "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/savePreset/1",
+			},
+		},
+	}
+}
+
+func rules(issues []Issue) []string {
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Field+":"+issue.Rule)
+	}
+
+	return rules
+}
+
+func TestLintRoom(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*cameraservices.CameraConfig)
+		rules  []string
+	}{
+		{
+			name:   "Valid",
+			modify: func(cam *cameraservices.CameraConfig) {},
+		},
+		{
+			name: "BadURL",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.ZoomIn = "http://aver.av.byu.edu/v1/Pro520 /%zz"
+				cam.PanLeft = "aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/left"
+				cam.PanTiltStop = "http://aver.av.byu.edu/pantilt/stop"
+			},
+			rules: []string{"panLeft:url", "panTiltStop:url", "zoomIn:url"},
+		},
+		{
+			name: "MissingStream",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.Stream = ""
+			},
+			rules: []string{"stream:missing"},
+		},
+		{
+			name: "WrongRoute",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.PanLeft = "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/right"
+			},
+			rules: []string{"panLeft:route"},
+		},
+		{
+			name: "UnknownModel",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.ZoomIn = "http://aver.av.byu.edu/v1/Pro502/ITB-1101-CAM1.byu.edu/zoom/in"
+			},
+			rules: []string{"zoomIn:model"},
+		},
+		{
+			name: "WrongService",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.ZoomIn = "http://axis.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/zoom/in"
+			},
+			rules: []string{"zoomIn:service"},
+		},
+		{
+			name: "WrongCamera",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.Presets[0].SetPreset = "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM2.byu.edu/preset/1"
+			},
+			rules: []string{"presets[0].setPreset:camera"},
+		},
+		{
+			name: "DuplicatePreset",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.Presets = append(cam.Presets, cam.Presets[0])
+			},
+			rules: []string{"presets[1]:duplicate-preset"},
+		},
+		{
+			name: "Structured",
+			modify: func(cam *cameraservices.CameraConfig) {
+				*cam = cameraservices.CameraConfig{
+					DisplayName: "Front",
+					Model:       "Pro520",
+					Address:     "ITB-1101-CAM1.byu.edu",
+					Service:     "aver",
+					Presets:     []cameraservices.CameraPreset{{DisplayName: "Podium", Preset: "1"}},
+				}
+			},
+		},
+		{
+			name: "StructuredInvalid",
+			modify: func(cam *cameraservices.CameraConfig) {
+				cam.Model = "Pro502"
+				cam.Address = "ITB-1101-CAM2.byu.edu"
+				cam.Presets = append(cam.Presets, cameraservices.CameraPreset{DisplayName: "Wide"})
+			},
+			rules: []string{"service:missing", "model:model", "presets[1].preset:missing", "panLeft:camera", "panTiltStop:camera", "presets[0].savePreset:camera", "presets[0].setPreset:camera", "stream:camera", "zoomIn:camera"},
+		},
+		{
+			name: "Hostname",
+			modify: func(cam *cameraservices.CameraConfig) {
+				*cam = cameraservices.CameraConfig{
+					DisplayName: "Front",
+					Stream:      "http://aver.av.byu.edu/v1/Pro520/10.5.34.12:8080/stream",
+				}
+			},
+			rules: []string{":hostname"},
+		},
+	}
+
+	l := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cam := validCamera()
+			tt.modify(&cam)
+
+			issues := l.Room(Room{
+				ID: "ITB-1101",
+				ControlGroups: []ControlGroup{
+					{Name: "ITB-1101", Cameras: []cameraservices.CameraConfig{cam}},
+					{Name: "Empty"},
+				},
+			})
+
+			require.Equal(t, tt.rules, rules(issues))
+		})
+	}
+}
+
+func TestLintDocument(t *testing.T) {
+	l := New(WithModels(map[string]string{"P5414-E": "axis"}))
+
+	issues := l.Document("bad.json", []byte("{"))
+	require.Equal(t, []string{":parse"}, rules(issues))
+
+	issues = l.Document("JFSB-B104", []byte(`{
+		"presets": [{
+			"name": "JFSB-B104",
+			"cameras": [{
+				"displayName": "Back",
+				"stream": "http://axis.av.byu.edu/v1/Pro520/JFSB-B104-CAM1.byu.edu/stream"
+			}]
+		}]
+	}`))
+	require.Equal(t, []string{"stream:model"}, rules(issues))
+	require.Equal(t, "JFSB-B104", issues[0].Room)
+	require.Equal(t, "Back", issues[0].Camera)
+
+	var report Report
+	report.Add(issues)
+	report.Add(nil)
+	require.Equal(t, 2, report.Documents)
+	require.Equal(t, 1, report.Errors)
+}
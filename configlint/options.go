@@ -0,0 +1,33 @@
+package configlint
+
+// _defaultModels maps each model the camera services serve to the service that serves it.
+var _defaultModels = map[string]string{
+	"Pro520":  "aver",
+	"P5414-E": "axis",
+	"V5915":   "axis",
+	"VISCA":   "visca",
+	"ONVIF":   "onvif",
+	"Sim":     "sim",
+}
+
+type options struct {
+	models map[string]string
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithModels sets the models that are known, mapped to the name of the camera service that serves them.
+// By default, the models served by the aver, axis, visca, onvif, and sim services are known.
+func WithModels(models map[string]string) Option {
+	return optionFunc(func(o *options) {
+		o.models = models
+	})
+}
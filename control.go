@@ -1,6 +1,10 @@
 package cameraservices
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"strings"
+)
 
 type ControlKeyService interface {
 	RoomAndControlGroup(ctx context.Context, key string) (string, string, error)
@@ -14,6 +18,13 @@ type ConfigService interface {
 type CameraConfig struct {
 	DisplayName string `json:"displayName"`
 
+	// Model (ie. Pro520), Address (ie. ITB-1101-CAM1.byu.edu), and Service (the name of the camera service
+	// that controls the camera, ie. aver) describe the camera without pre-built urls. When they are set,
+	// the control service derives the camera's urls from them instead of using the urls below.
+	Model   string `json:"model,omitempty"`
+	Address string `json:"address,omitempty"`
+	Service string `json:"service,omitempty"`
+
 	TiltUp      string `json:"tiltUp"`
 	TiltDown    string `json:"tiltDown"`
 	PanLeft     string `json:"panLeft"`
@@ -30,12 +41,90 @@ type CameraConfig struct {
 
 	// admin items
 	Reboot string `json:"reboot"`
+
+	// Capabilities is filled in from the camera's service, if it is reachable
+	Capabilities Capabilities `json:"capabilities,omitempty"`
 }
 
 type CameraPreset struct {
 	DisplayName string `json:"displayName"`
 	SavePreset  string `json:"savePreset"`
 	SetPreset   string `json:"setPreset"`
+
+	// Preset is the camera's preset, used to derive the preset's urls when the camera has a model and address
+	Preset string `json:"preset,omitempty"`
+}
+
+// Structured returns true if the camera is described by its model, address, and service instead of by urls.
+func (c CameraConfig) Structured() bool {
+	return c.Model != "" && c.Address != "" && c.Service != ""
+}
+
+// Copy returns a copy of the camera that doesn't share its presets or capabilities with c, so that it can be
+// changed without changing c.
+func (c CameraConfig) Copy() CameraConfig {
+	if c.Presets != nil {
+		c.Presets = append([]CameraPreset(nil), c.Presets...)
+	}
+
+	if c.Capabilities != nil {
+		caps := make(Capabilities, len(c.Capabilities))
+		for name, capability := range c.Capabilities {
+			caps[name] = capability
+		}
+
+		c.Capabilities = caps
+	}
+
+	return c
+}
+
+// WithURLs returns the camera with each url derived from its model and address, on the camera service at base
+// (ie. http://aver.av.byu.edu). Presets without a Preset keep their urls.
+func (c CameraConfig) WithURLs(base string) CameraConfig {
+	camera := strings.TrimSuffix(base, "/") + "/v1/" + url.PathEscape(c.Model) + "/" + url.PathEscape(c.Address)
+
+	c.TiltUp = camera + "/pantilt/up"
+	c.TiltDown = camera + "/pantilt/down"
+	c.PanLeft = camera + "/pantilt/left"
+	c.PanRight = camera + "/pantilt/right"
+	c.PanTiltStop = camera + "/pantilt/stop"
+	c.ZoomIn = camera + "/zoom/in"
+	c.ZoomOut = camera + "/zoom/out"
+	c.ZoomStop = camera + "/zoom/stop"
+	c.Stream = camera + "/stream"
+	c.Reboot = camera + "/reboot"
+
+	presets := make([]CameraPreset, len(c.Presets))
+	for i, p := range c.Presets {
+		if p.Preset != "" {
+			p.SetPreset = camera + "/preset/" + url.PathEscape(p.Preset)
+			p.SavePreset = camera + "/savePreset/" + url.PathEscape(p.Preset)
+		}
+
+		presets[i] = p
+	}
+
+	c.Presets = presets
+	return c
+}
+
+// CameraServices maps the name of a camera service (ie. aver) to its url (ie. http://aver.av.byu.edu).
+type CameraServices map[string]string
+
+// WithURLs returns cameras with the urls of each camera described by its model, address, and service
+// derived from them, on its service's url. Cameras whose service isn't in s are left alone.
+func (s CameraServices) WithURLs(cameras []CameraConfig) []CameraConfig {
+	resolved := make([]CameraConfig, len(cameras))
+	for i, cam := range cameras {
+		if base, ok := s[cam.Service]; ok && cam.Structured() {
+			cam = cam.WithURLs(base)
+		}
+
+		resolved[i] = cam
+	}
+
+	return resolved
 }
 
 type ControlInfo struct {
@@ -0,0 +1,30 @@
+package cameraservices
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrControlKeyNotFound is returned by a ControlKeyStore when a control group doesn't have a key.
+var ErrControlKeyNotFound = errors.New("control key not found")
+
+// ControlKeyStore stores the control keys issued for each room's control groups.
+type ControlKeyStore interface {
+	ControlKeys(ctx context.Context) ([]IssuedControlKey, error)
+	SetControlKey(ctx context.Context, key IssuedControlKey) error
+	DeleteControlKey(ctx context.Context, room, controlGroup string) error
+}
+
+// IssuedControlKey is the key that has to be entered to control a room's control group.
+type IssuedControlKey struct {
+	Room         string    `json:"room"`
+	ControlGroup string    `json:"controlGroup"`
+	Key          string    `json:"key"`
+	Issued       time.Time `json:"issued"`
+
+	// Previous is the key this one replaced, which is still accepted until PreviousExpires
+	// so that people already controlling the room aren't cut off when the key is rotated.
+	Previous        string    `json:"previous,omitempty"`
+	PreviousExpires time.Time `json:"previousExpires,omitempty"`
+}
@@ -0,0 +1,318 @@
+package couch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/go-kivik/kivik/v3"
+	"go.uber.org/zap"
+)
+
+// CacheStats are a Cache's metrics.
+type CacheStats struct {
+	// Rooms is how many room documents are currently cached
+	Rooms int `json:"rooms"`
+
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+
+	// Stale is how many times a cached document was used because the database couldn't be reached
+	Stale uint64 `json:"stale"`
+
+	// Invalidations is how many changes to documents have been seen on the changes feed
+	Invalidations uint64 `json:"invalidations"`
+
+	// Watching is true while the cache is following the database's changes feed.
+	// Cached documents are only trusted while it is.
+	Watching bool `json:"watching"`
+}
+
+// Cache is a ConfigService that keeps the room documents from a config service in memory. Documents
+// are invalidated as they change by following the database's changes feed, and the last known
+// version of a document is used if the database can't be reached.
+type Cache struct {
+	*configService
+
+	log           *zap.Logger
+	retryInterval time.Duration
+
+	mu       sync.RWMutex
+	rooms    map[string]*cachedRoom
+	roomList *cachedRooms
+	watching bool
+
+	// gen is incremented each time a document is invalidated, so that
+	// documents fetched before an invalidation aren't trusted
+	gen uint64
+
+	hits          uint64
+	misses        uint64
+	stale         uint64
+	invalidations uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type cachedRoom struct {
+	config cameraservices.RoomConfig
+	valid  bool
+}
+
+type cachedRooms struct {
+	rooms []string
+	valid bool
+}
+
+// NewCache creates a Cache in front of cs, and starts following the changes feed of cs's ui config database.
+// Close must be called to stop following it.
+func NewCache(cs *configService, opts ...CacheOption) *Cache {
+	options := cacheOptions{
+		retryInterval: _defaultRetryInterval,
+		log:           zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	c := &Cache{
+		configService: cs,
+		log:           options.log,
+		retryInterval: options.retryInterval,
+		rooms:         make(map[string]*cachedRoom),
+		stop:          make(chan struct{}),
+	}
+
+	c.wg.Add(1)
+	go c.watch()
+
+	return c
+}
+
+func (c *Cache) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	config, err := c.uiConfig(ctx, info.Room)
+	if err != nil {
+		return []cameraservices.CameraConfig{}, err
+	}
+
+	return config.Cameras(info)
+}
+
+func (c *Cache) ControlGroups(ctx context.Context, room string) ([]string, error) {
+	config, err := c.uiConfig(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.ControlGroupNames(), nil
+}
+
+// Returns a list of the urls for the commands that each contain the IP address or hostname
+func (c *Cache) ControlIP(ctx context.Context, room string) ([]string, error) {
+	config, err := c.uiConfig(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Streams(), nil
+}
+
+func (c *Cache) Rooms(ctx context.Context) ([]string, error) {
+	c.mu.RLock()
+	cached := c.roomList
+	gen := c.gen
+	trusted := cached != nil && cached.valid && c.watching
+	c.mu.RUnlock()
+
+	if trusted {
+		atomic.AddUint64(&c.hits, 1)
+		return cached.rooms, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+
+	rooms, err := c.configService.Rooms(ctx)
+	if err != nil {
+		if cached != nil {
+			atomic.AddUint64(&c.stale, 1)
+			c.log.Warn("unable to get rooms, using cached rooms", zap.Error(err))
+			return cached.rooms, nil
+		}
+
+		return rooms, err
+	}
+
+	c.mu.Lock()
+	c.roomList = &cachedRooms{rooms: rooms, valid: gen == c.gen}
+	c.mu.Unlock()
+
+	return rooms, nil
+}
+
+// uiConfig gets room's ui config document from the cache, or from the database if it isn't cached.
+func (c *Cache) uiConfig(ctx context.Context, room string) (cameraservices.RoomConfig, error) {
+	c.mu.RLock()
+	cached, ok := c.rooms[room]
+	gen := c.gen
+	trusted := ok && cached.valid && c.watching
+	c.mu.RUnlock()
+
+	if trusted {
+		atomic.AddUint64(&c.hits, 1)
+		return cached.config, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+
+	config, err := c.configService.uiConfig(ctx, room)
+	switch {
+	case kivik.StatusCode(err) == http.StatusNotFound:
+		c.mu.Lock()
+		delete(c.rooms, room)
+		c.mu.Unlock()
+
+		return config, err
+	case err != nil:
+		if ok {
+			atomic.AddUint64(&c.stale, 1)
+			c.log.Warn("unable to get ui config, using cached config", zap.String("room", room), zap.Error(err))
+			return cached.config, nil
+		}
+
+		return config, err
+	}
+
+	c.mu.Lock()
+	c.rooms[room] = &cachedRoom{config: config, valid: gen == c.gen}
+	c.mu.Unlock()
+
+	return config, nil
+}
+
+// Stats returns the cache's current metrics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.RLock()
+	rooms := len(c.rooms)
+	watching := c.watching
+	c.mu.RUnlock()
+
+	return CacheStats{
+		Rooms:         rooms,
+		Hits:          atomic.LoadUint64(&c.hits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		Stale:         atomic.LoadUint64(&c.stale),
+		Invalidations: atomic.LoadUint64(&c.invalidations),
+		Watching:      watching,
+	}
+}
+
+// Close stops following the changes feed.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		c.wg.Wait()
+	})
+
+	return nil
+}
+
+// watch follows the changes feed until the cache is closed, reconnecting when it is interrupted.
+func (c *Cache) watch() {
+	defer c.wg.Done()
+
+	for {
+		err := c.follow()
+
+		c.mu.Lock()
+		c.watching = false
+		c.mu.Unlock()
+
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		c.log.Warn("unable to follow config changes, retrying", zap.Duration("in", c.retryInterval), zap.Error(err))
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+// follow invalidates documents as they change, until the feed is interrupted or the cache is closed.
+func (c *Cache) follow() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	db := c.client.DB(ctx, c.uiConfigDB)
+	changes, err := db.Changes(ctx, kivik.Options{
+		"feed":      "continuous",
+		"since":     "now",
+		"heartbeat": 30000,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get changes feed: %w", err)
+	}
+	defer changes.Close()
+
+	// anything could have changed while we weren't watching
+	c.mu.Lock()
+	for _, cached := range c.rooms {
+		cached.valid = false
+	}
+
+	if c.roomList != nil {
+		c.roomList.valid = false
+	}
+
+	c.gen++
+	c.watching = true
+	c.mu.Unlock()
+
+	c.log.Info("Following config changes", zap.String("db", c.uiConfigDB))
+
+	for changes.Next() {
+		atomic.AddUint64(&c.invalidations, 1)
+
+		c.mu.Lock()
+		if cached, ok := c.rooms[changes.ID()]; ok {
+			if changes.Deleted() {
+				delete(c.rooms, changes.ID())
+			} else {
+				cached.valid = false
+			}
+		}
+
+		if c.roomList != nil {
+			c.roomList.valid = false
+		}
+
+		c.gen++
+		c.mu.Unlock()
+	}
+
+	if err := changes.Err(); err != nil {
+		return fmt.Errorf("changes feed interrupted: %w", err)
+	}
+
+	return fmt.Errorf("changes feed ended")
+}
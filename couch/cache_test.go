@@ -0,0 +1,268 @@
+package couch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/handlers"
+	"github.com/byuoitav/camera-services/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testCouch is a couch server with just enough of the api for the cache.
+type testCouch struct {
+	mu      sync.Mutex
+	docs    map[string]string
+	down    bool
+	changes chan string
+
+	gets int32
+}
+
+func (t *testCouch) setDoc(id, doc string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.docs[id] = doc
+}
+
+func (t *testCouch) setDown(down bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.down = down
+}
+
+func (t *testCouch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	down := t.down
+	t.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error":"unavailable","reason":"down"}`)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+_defaultUIConfigDB+"/")
+	switch path {
+	case "_changes":
+		flusher := w.(http.Flusher)
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case id, ok := <-t.changes:
+				if !ok {
+					return
+				}
+
+				fmt.Fprintf(w, `{"seq":"1-a","id":%q,"changes":[{"rev":"2-a"}]}`+"\n", id)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	case "_find":
+		t.mu.Lock()
+		var docs []string
+		for id := range t.docs {
+			docs = append(docs, fmt.Sprintf(`{"_id":%q}`, id))
+		}
+		t.mu.Unlock()
+
+		fmt.Fprintf(w, `{"docs":[%s]}`, strings.Join(docs, ","))
+	default:
+		atomic.AddInt32(&t.gets, 1)
+
+		t.mu.Lock()
+		doc, ok := t.docs[path]
+		t.mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+
+		w.Header().Set("ETag", `"1-a"`)
+		fmt.Fprint(w, doc)
+	}
+}
+
+func roomDoc(id, camera string) string {
+	doc, _ := json.Marshal(map[string]interface{}{
+		"_id": id,
+		"presets": []map[string]interface{}{
+			{
+				"name":    id,
+				"cameras": []map[string]interface{}{{"displayName": camera}},
+			},
+		},
+	})
+
+	return string(doc)
+}
+
+func TestCache(t *testing.T) {
+	couch := &testCouch{
+		docs:    map[string]string{"ITB-1101": roomDoc("ITB-1101", "Front")},
+		changes: make(chan string),
+	}
+
+	srv := httptest.NewServer(couch)
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	cs, err := New(ctx, srv.URL)
+	require.NoError(t, err)
+
+	cache := NewCache(cs, WithRetryInterval(10*time.Millisecond))
+	defer cache.Close()
+
+	require.Eventually(t, func() bool { return cache.Stats().Watching }, time.Second, 10*time.Millisecond)
+
+	info := cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "ITB-1101"}
+	for i := 0; i < 3; i++ {
+		cameras, err := cache.Cameras(ctx, info)
+		require.NoError(t, err)
+		require.Equal(t, "Front", cameras[0].DisplayName)
+	}
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&couch.gets))
+	require.Equal(t, CacheStats{Rooms: 1, Hits: 2, Misses: 1, Watching: true}, cache.Stats())
+
+	// changed documents are fetched again
+	couch.setDoc("ITB-1101", roomDoc("ITB-1101", "Back"))
+	couch.changes <- "ITB-1101"
+
+	require.Eventually(t, func() bool { return cache.Stats().Invalidations == 1 }, time.Second, 10*time.Millisecond)
+
+	cameras, err := cache.Cameras(ctx, info)
+	require.NoError(t, err)
+	require.Equal(t, "Back", cameras[0].DisplayName)
+	require.Equal(t, int32(2), atomic.LoadInt32(&couch.gets))
+
+	// rooms that don't exist aren't cached
+	_, err = cache.ControlGroups(ctx, "Unknown")
+	require.Error(t, err)
+
+	rooms, err := cache.Rooms(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ITB-1101"}, rooms)
+
+	// the cached config is used while the database is down
+	couch.setDown(true)
+	close(couch.changes)
+
+	require.Eventually(t, func() bool { return !cache.Stats().Watching }, time.Second, 10*time.Millisecond)
+
+	cameras, err = cache.Cameras(ctx, info)
+	require.NoError(t, err)
+	require.Equal(t, "Back", cameras[0].DisplayName)
+
+	rooms, err = cache.Rooms(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ITB-1101"}, rooms)
+
+	require.Equal(t, uint64(2), cache.Stats().Stale)
+
+	_, err = cache.ControlIP(ctx, "Unknown")
+	require.Error(t, err)
+}
+
+func TestCacheCopiesCameras(t *testing.T) {
+	stream := "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream"
+	preset := "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/preset/1"
+
+	doc, err := json.Marshal(map[string]interface{}{
+		"_id": "ITB-1101",
+		"presets": []map[string]interface{}{
+			{
+				"name": "ITB-1101",
+				"cameras": []map[string]interface{}{
+					{
+						"displayName": "Front",
+						"stream":      stream,
+						"presets":     []map[string]interface{}{{"displayName": "Podium", "setPreset": preset}},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	couch := &testCouch{
+		docs:    map[string]string{"ITB-1101": string(doc)},
+		changes: make(chan string),
+	}
+
+	srv := httptest.NewServer(couch)
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	cs, err := New(ctx, srv.URL)
+	require.NoError(t, err)
+
+	cache := NewCache(cs, WithRetryInterval(10*time.Millisecond))
+	defer cache.Close()
+
+	require.Eventually(t, func() bool { return cache.Stats().Watching }, time.Second, 10*time.Millisecond)
+
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	defer upstream.Close()
+
+	backends, err := proxy.New(map[string]proxy.Config{
+		"aver": {Upstreams: []string{upstream.URL}, Hosts: []string{"aver.av.byu.edu"}},
+	}, proxy.WithHealthInterval(0))
+	require.NoError(t, err)
+	defer backends.Close()
+
+	me, err := url.Parse("https://cameras.av.byu.edu")
+	require.NoError(t, err)
+
+	h := &handlers.ControlHandlers{
+		ConfigService: cache,
+		Me:            me,
+		Logger:        zap.NewNop(),
+		DisableAuth:   true,
+		Backends:      backends,
+	}
+
+	// the control service rewrites the urls it returns, which mustn't change the cached config
+	gin.SetMode(gin.TestMode)
+	for i := 0; i < 2; i++ {
+		resp := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(resp)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras?room=ITB-1101&controlGroup=ITB-1101", nil)
+
+		h.GetCameras(c)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var cameras []cameraservices.CameraConfig
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cameras))
+		require.Equal(t, "https://cameras.av.byu.edu/proxy/aver/v1/Pro520/ITB-1101-CAM1.byu.edu/stream", cameras[0].Stream)
+	}
+
+	cameras, err := cache.Cameras(ctx, cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "ITB-1101"})
+	require.NoError(t, err)
+	require.Equal(t, stream, cameras[0].Stream)
+	require.Equal(t, preset, cameras[0].Presets[0].SetPreset)
+	require.Equal(t, int32(1), atomic.LoadInt32(&couch.gets))
+}
@@ -0,0 +1,103 @@
+package couch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/go-kivik/kivik/v3"
+)
+
+// ControlKeys returns every control key in the control key database.
+// Each document's _id is the room the keys belong to.
+func (c *configService) ControlKeys(ctx context.Context) ([]cameraservices.IssuedControlKey, error) {
+	var keys []cameraservices.IssuedControlKey
+
+	db := c.client.DB(ctx, c.controlKeyDB)
+	rows, err := db.AllDocs(ctx, kivik.Options{"include_docs": true})
+	if err != nil {
+		return keys, fmt.Errorf("unable to get all docs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if strings.HasPrefix(rows.ID(), "_design/") {
+			continue
+		}
+
+		var doc controlKeyDoc
+		if err := rows.ScanDoc(&doc); err != nil {
+			return keys, fmt.Errorf("unable to scan control keys %q: %w", rows.ID(), err)
+		}
+
+		for cg, key := range doc.ControlGroups {
+			key.Room = doc.ID
+			key.ControlGroup = cg
+			keys = append(keys, key)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return keys, fmt.Errorf("unable to iterate control keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// SetControlKey creates the control group's key, or replaces its existing key.
+func (c *configService) SetControlKey(ctx context.Context, key cameraservices.IssuedControlKey) error {
+	return c.updateControlKeys(ctx, key.Room, func(doc *controlKeyDoc) error {
+		doc.ControlGroups[key.ControlGroup] = key
+		return nil
+	})
+}
+
+func (c *configService) DeleteControlKey(ctx context.Context, room, controlGroup string) error {
+	return c.updateControlKeys(ctx, room, func(doc *controlKeyDoc) error {
+		if _, ok := doc.ControlGroups[controlGroup]; !ok {
+			return cameraservices.ErrControlKeyNotFound
+		}
+
+		delete(doc.ControlGroups, controlGroup)
+		return nil
+	})
+}
+
+// updateControlKeys applies update to the latest version of room's control key document, retrying on revision conflicts.
+// An empty document is used if it doesn't exist yet.
+func (c *configService) updateControlKeys(ctx context.Context, room string, update func(*controlKeyDoc) error) error {
+	db := c.client.DB(ctx, c.controlKeyDB)
+
+	for i := 0; ; i++ {
+		doc := controlKeyDoc{
+			ID: room,
+		}
+
+		err := db.Get(ctx, room).ScanDoc(&doc)
+		switch {
+		case kivik.StatusCode(err) == http.StatusNotFound:
+		case err != nil:
+			return fmt.Errorf("unable to get/scan control keys: %w", err)
+		}
+
+		if doc.ControlGroups == nil {
+			doc.ControlGroups = make(map[string]cameraservices.IssuedControlKey)
+		}
+
+		if err := update(&doc); err != nil {
+			return err
+		}
+
+		_, err = db.Put(ctx, room, doc)
+		switch {
+		case err == nil:
+			return nil
+		case kivik.StatusCode(err) == http.StatusConflict && i < _maxConflictRetries:
+			continue
+		default:
+			return fmt.Errorf("unable to put control keys: %w", err)
+		}
+	}
+}
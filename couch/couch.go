@@ -2,6 +2,7 @@ package couch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -10,8 +11,11 @@ import (
 )
 
 type configService struct {
-	client     *kivik.Client
-	uiConfigDB string
+	client       *kivik.Client
+	uiConfigDB   string
+	scheduleDB   string
+	sceneDB      string
+	controlKeyDB string
 }
 
 // New creates a new ConfigService, created a couchdb client pointed at url.
@@ -27,7 +31,10 @@ func New(ctx context.Context, url string, opts ...Option) (*configService, error
 // NewWithClient creates a new ConfigService using the given client.
 func NewWithClient(ctx context.Context, client *kivik.Client, opts ...Option) (*configService, error) {
 	options := options{
-		uiConfigDB: _defaultUIConfigDB,
+		uiConfigDB:   _defaultUIConfigDB,
+		scheduleDB:   _defaultScheduleDB,
+		sceneDB:      _defaultSceneDB,
+		controlKeyDB: _defaultControlKeyDB,
 	}
 
 	for _, o := range opts {
@@ -41,26 +48,33 @@ func NewWithClient(ctx context.Context, client *kivik.Client, opts ...Option) (*
 	}
 
 	return &configService{
-		client:     client,
-		uiConfigDB: options.uiConfigDB,
+		client:       client,
+		uiConfigDB:   options.uiConfigDB,
+		scheduleDB:   options.scheduleDB,
+		sceneDB:      options.sceneDB,
+		controlKeyDB: options.controlKeyDB,
 	}, nil
 }
 
 func (c *configService) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
-	var config uiConfig
-
-	db := c.client.DB(ctx, c.uiConfigDB)
-	if err := db.Get(ctx, info.Room).ScanDoc(&config); err != nil {
-		return []cameraservices.CameraConfig{}, fmt.Errorf("unable to get/scan ui config: %w", err)
+	config, err := c.uiConfig(ctx, info.Room)
+	if err != nil {
+		return []cameraservices.CameraConfig{}, err
 	}
 
-	for _, cg := range config.ControlGroups {
-		if cg.ID == info.ControlGroup && len(cg.Cameras) > 0 {
-			return cg.Cameras, nil
-		}
+	return config.Cameras(info)
+}
+
+// uiConfig gets room's ui config document.
+func (c *configService) uiConfig(ctx context.Context, room string) (cameraservices.RoomConfig, error) {
+	var config cameraservices.RoomConfig
+
+	db := c.client.DB(ctx, c.uiConfigDB)
+	if err := db.Get(ctx, room).ScanDoc(&config); err != nil {
+		return config, fmt.Errorf("unable to get/scan ui config: %w", err)
 	}
 
-	return []cameraservices.CameraConfig{}, fmt.Errorf("no cameras found in %s/%s", info.Room, info.ControlGroup)
+	return config, nil
 }
 
 func (c *configService) CameraPreset(ctx context.Context, camID, presetID string) (string, error) {
@@ -94,19 +108,13 @@ func (c *configService) CameraPreset(ctx context.Context, camID, presetID string
 		return "", fmt.Errorf("no matching documents found")
 	}
 
-	var config uiConfig
+	var config cameraservices.RoomConfig
 	if err := rows.ScanDoc(&config); err != nil {
 		return "", fmt.Errorf("unable to scan doc: %w", err)
 	}
 
-	for _, cg := range config.ControlGroups {
-		for _, cam := range cg.Cameras {
-			for _, preset := range cam.Presets {
-				if strings.Contains(preset.SetPreset, camID) && strings.HasSuffix(preset.SetPreset, presetID) {
-					return preset.DisplayName, nil
-				}
-			}
-		}
+	if name, ok := config.PresetName(camID, presetID); ok {
+		return name, nil
 	}
 
 	return "", fmt.Errorf("unable to find matching preset")
@@ -140,7 +148,7 @@ func (c *configService) Rooms(ctx context.Context) ([]string, error) {
 	}
 
 	for rows.Next() {
-		var config uiConfig
+		var config cameraservices.RoomConfig
 		if err := rows.ScanDoc(&config); err != nil {
 			continue
 		}
@@ -153,41 +161,90 @@ func (c *configService) Rooms(ctx context.Context) ([]string, error) {
 	return rooms, nil
 }
 
-func (c *configService) ControlGroups(ctx context.Context, room string) ([]string, error) {
-	var config uiConfig
-	var groups []string
+// UIConfigDocuments returns every document in the ui config database as json, keyed by room.
+func (c *configService) UIConfigDocuments(ctx context.Context) (map[string][]byte, error) {
+	docs := make(map[string][]byte)
 
 	db := c.client.DB(ctx, c.uiConfigDB)
-	if err := db.Get(ctx, room).ScanDoc(&config); err != nil {
-		return groups, fmt.Errorf("unable to get/scan ui config: %w", err)
+	rows, err := db.AllDocs(ctx, kivik.Options{"include_docs": true})
+	if err != nil {
+		return docs, fmt.Errorf("unable to get all docs: %w", err)
 	}
+	defer rows.Close()
 
-	for _, cg := range config.ControlGroups {
-		if len(cg.Cameras) > 0 {
-			groups = append(groups, cg.ID)
+	for rows.Next() {
+		if strings.HasPrefix(rows.ID(), "_design/") {
+			continue
 		}
+
+		var doc json.RawMessage
+		if err := rows.ScanDoc(&doc); err != nil {
+			return docs, fmt.Errorf("unable to scan ui config %q: %w", rows.ID(), err)
+		}
+
+		docs[rows.ID()] = doc
+	}
+
+	if err := rows.Err(); err != nil {
+		return docs, fmt.Errorf("unable to iterate ui configs: %w", err)
+	}
+
+	return docs, nil
+}
+
+func (c *configService) ControlGroups(ctx context.Context, room string) ([]string, error) {
+	config, err := c.uiConfig(ctx, room)
+	if err != nil {
+		return nil, err
 	}
 
-	return groups, nil
+	return config.ControlGroupNames(), nil
 }
 
 // Returns a list of the urls for the commands that each contain the IP address or hostname
 func (c *configService) ControlIP(ctx context.Context, room string) ([]string, error) {
-	var config uiConfig
-	var IP []string
+	config, err := c.uiConfig(ctx, room)
+	if err != nil {
+		return nil, err
+	}
 
-	db := c.client.DB(ctx, c.uiConfigDB)
-	if err := db.Get(ctx, room).ScanDoc(&config); err != nil {
-		return IP, fmt.Errorf("unable to get/scan ui config: %w", err)
+	return config.Streams(), nil
+}
+
+// Schedules returns every schedule entry in the schedule database.
+// Each document's _id is the room the entries belong to.
+func (c *configService) Schedules(ctx context.Context) ([]cameraservices.ScheduleEntry, error) {
+	var entries []cameraservices.ScheduleEntry
+
+	db := c.client.DB(ctx, c.scheduleDB)
+	rows, err := db.AllDocs(ctx, kivik.Options{"include_docs": true})
+	if err != nil {
+		return entries, fmt.Errorf("unable to get all docs: %w", err)
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if strings.HasPrefix(rows.ID(), "_design/") {
+			continue
+		}
 
-	for _, cg := range config.ControlGroups {
-		if len(cg.Cameras) > 0 {
-			for _, cam := range cg.Cameras {
-				IP = append(IP, cam.Stream)
+		var doc scheduleDoc
+		if err := rows.ScanDoc(&doc); err != nil {
+			return entries, fmt.Errorf("unable to scan schedule %q: %w", rows.ID(), err)
+		}
+
+		for _, e := range doc.Schedules {
+			if e.Room == "" {
+				e.Room = doc.ID
 			}
+
+			entries = append(entries, e)
 		}
 	}
 
-	return IP, nil
+	if err := rows.Err(); err != nil {
+		return entries, fmt.Errorf("unable to iterate schedules: %w", err)
+	}
+
+	return entries, nil
 }
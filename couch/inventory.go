@@ -0,0 +1,171 @@
+package couch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/go-kivik/kivik/v3"
+)
+
+// The ui config documents have many fields this service doesn't use, so cameras are edited
+// on the raw document so that the rest of the document is written back as it was.
+type (
+	rawDoc    map[string]json.RawMessage
+	rawCamera map[string]json.RawMessage
+)
+
+func (c *configService) AddCamera(ctx context.Context, room, controlGroup string, cam cameraservices.CameraConfig) error {
+	return c.updateCameras(ctx, room, controlGroup, func(cameras []rawCamera) ([]rawCamera, error) {
+		if cameraIndex(cameras, cam.DisplayName) >= 0 {
+			return nil, cameraservices.ErrCameraExists
+		}
+
+		raw, err := mergeCamera(rawCamera{}, cam)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(cameras, raw), nil
+	})
+}
+
+// SetCamera replaces the camera named name. Fields of the camera this service doesn't use are kept.
+func (c *configService) SetCamera(ctx context.Context, room, controlGroup, name string, cam cameraservices.CameraConfig) error {
+	return c.updateCameras(ctx, room, controlGroup, func(cameras []rawCamera) ([]rawCamera, error) {
+		i := cameraIndex(cameras, name)
+		if i < 0 {
+			return nil, cameraservices.ErrCameraNotFound
+		}
+
+		if j := cameraIndex(cameras, cam.DisplayName); j >= 0 && j != i {
+			return nil, cameraservices.ErrCameraExists
+		}
+
+		raw, err := mergeCamera(cameras[i], cam)
+		if err != nil {
+			return nil, err
+		}
+
+		cameras[i] = raw
+		return cameras, nil
+	})
+}
+
+func (c *configService) DeleteCamera(ctx context.Context, room, controlGroup, name string) error {
+	return c.updateCameras(ctx, room, controlGroup, func(cameras []rawCamera) ([]rawCamera, error) {
+		i := cameraIndex(cameras, name)
+		if i < 0 {
+			return nil, cameraservices.ErrCameraNotFound
+		}
+
+		return append(cameras[:i], cameras[i+1:]...), nil
+	})
+}
+
+// updateCameras applies update to the cameras in the latest version of room's control group, retrying on revision conflicts.
+func (c *configService) updateCameras(ctx context.Context, room, controlGroup string, update func([]rawCamera) ([]rawCamera, error)) error {
+	db := c.client.DB(ctx, c.uiConfigDB)
+
+	for i := 0; ; i++ {
+		var doc rawDoc
+		err := db.Get(ctx, room).ScanDoc(&doc)
+		switch {
+		case kivik.StatusCode(err) == http.StatusNotFound:
+			return cameraservices.ErrControlGroupNotFound
+		case err != nil:
+			return fmt.Errorf("unable to get/scan ui config: %w", err)
+		}
+
+		var groups []rawDoc
+		if err := json.Unmarshal(doc["presets"], &groups); err != nil && len(doc["presets"]) > 0 {
+			return fmt.Errorf("unable to parse control groups: %w", err)
+		}
+
+		group := -1
+		for j := range groups {
+			var name string
+			if err := json.Unmarshal(groups[j]["name"], &name); err == nil && name == controlGroup {
+				group = j
+				break
+			}
+		}
+
+		if group < 0 {
+			return cameraservices.ErrControlGroupNotFound
+		}
+
+		var cameras []rawCamera
+		if err := json.Unmarshal(groups[group]["cameras"], &cameras); err != nil && len(groups[group]["cameras"]) > 0 {
+			return fmt.Errorf("unable to parse cameras: %w", err)
+		}
+
+		cameras, err = update(cameras)
+		if err != nil {
+			return err
+		}
+
+		if cameras == nil {
+			cameras = []rawCamera{}
+		}
+
+		if groups[group]["cameras"], err = json.Marshal(cameras); err != nil {
+			return fmt.Errorf("unable to marshal cameras: %w", err)
+		}
+
+		if doc["presets"], err = json.Marshal(groups); err != nil {
+			return fmt.Errorf("unable to marshal control groups: %w", err)
+		}
+
+		_, err = db.Put(ctx, room, doc)
+		switch {
+		case err == nil:
+			return nil
+		case kivik.StatusCode(err) == http.StatusConflict && i < _maxConflictRetries:
+			continue
+		default:
+			return fmt.Errorf("unable to put ui config: %w", err)
+		}
+	}
+}
+
+// cameraIndex returns the index of the camera named name, or -1 if it isn't found.
+func cameraIndex(cameras []rawCamera, name string) int {
+	for i := range cameras {
+		var displayName string
+		if err := json.Unmarshal(cameras[i]["displayName"], &displayName); err == nil && displayName == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// mergeCamera writes cam's fields over raw's fields.
+func mergeCamera(raw rawCamera, cam cameraservices.CameraConfig) (rawCamera, error) {
+	// capabilities are looked up from the camera, not stored
+	cam.Capabilities = nil
+
+	b, err := json.Marshal(cam)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal camera: %w", err)
+	}
+
+	var fields rawCamera
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal camera: %w", err)
+	}
+
+	merged := make(rawCamera, len(raw)+len(fields))
+	for k, v := range raw {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return merged, nil
+}
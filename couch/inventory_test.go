@@ -0,0 +1,151 @@
+package couch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+// testDocs is a couch server that can get and put documents, checking their revisions.
+type testDocs struct {
+	mu   sync.Mutex
+	docs map[string]map[string]interface{}
+	rev  int
+
+	// conflicts is how many puts to reject with a conflict before accepting them
+	conflicts int
+}
+
+func (t *testDocs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	id := strings.TrimPrefix(r.URL.Path, "/"+_defaultUIConfigDB+"/")
+
+	switch r.Method {
+	case http.MethodGet:
+		doc, ok := t.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+
+		w.Header().Set("ETag", fmt.Sprintf("%q", doc["_rev"]))
+		_ = json.NewEncoder(w).Encode(doc)
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if t.conflicts > 0 || doc["_rev"] != t.docs[id]["_rev"] {
+			t.conflicts--
+			t.rev++
+			t.docs[id]["_rev"] = fmt.Sprintf("%d-a", t.rev)
+
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error":"conflict","reason":"Document update conflict."}`)
+			return
+		}
+
+		t.rev++
+		doc["_rev"] = fmt.Sprintf("%d-a", t.rev)
+		t.docs[id] = doc
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":%q}`, id, doc["_rev"])
+	}
+}
+
+func (t *testDocs) cameras(room string) []interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	groups := t.docs[room]["presets"].([]interface{})
+	return groups[0].(map[string]interface{})["cameras"].([]interface{})
+}
+
+func TestInventory(t *testing.T) {
+	couch := &testDocs{
+		docs: map[string]map[string]interface{}{
+			"ITB-1101": {
+				"_id":     "ITB-1101",
+				"_rev":    "1-a",
+				"outputs": []interface{}{"D1"},
+				"presets": []interface{}{
+					map[string]interface{}{
+						"name": "ITB-1101",
+						"icon": "tv",
+						"cameras": []interface{}{
+							map[string]interface{}{"displayName": "Front", "stream": "http://front/stream", "order": 1.0},
+						},
+					},
+				},
+			},
+		},
+		rev:       1,
+		conflicts: 2,
+	}
+
+	srv := httptest.NewServer(couch)
+	defer srv.Close()
+
+	ctx := context.Background()
+	cs, err := New(ctx, srv.URL)
+	require.NoError(t, err)
+
+	// conflicts are retried
+	require.NoError(t, cs.AddCamera(ctx, "ITB-1101", "ITB-1101", cameraservices.CameraConfig{DisplayName: "Back", Stream: "http://back/stream"}))
+
+	cameras := couch.cameras("ITB-1101")
+	require.Len(t, cameras, 2)
+	require.Equal(t, "http://back/stream", cameras[1].(map[string]interface{})["stream"])
+
+	// fields this service doesn't use are kept
+	require.NoError(t, cs.SetCamera(ctx, "ITB-1101", "ITB-1101", "Front", cameraservices.CameraConfig{DisplayName: "Front", Stream: "http://new/stream"}))
+
+	front := couch.cameras("ITB-1101")[0].(map[string]interface{})
+	require.Equal(t, "http://new/stream", front["stream"])
+	require.Equal(t, 1.0, front["order"])
+	require.NotContains(t, front, "capabilities")
+	require.Equal(t, []interface{}{"D1"}, couch.docs["ITB-1101"]["outputs"])
+	require.Equal(t, "tv", couch.docs["ITB-1101"]["presets"].([]interface{})[0].(map[string]interface{})["icon"])
+
+	err = cs.SetCamera(ctx, "ITB-1101", "ITB-1101", "Front", cameraservices.CameraConfig{DisplayName: "Back"})
+	require.True(t, errors.Is(err, cameraservices.ErrCameraExists))
+
+	err = cs.AddCamera(ctx, "ITB-1101", "ITB-1101", cameraservices.CameraConfig{DisplayName: "Back"})
+	require.True(t, errors.Is(err, cameraservices.ErrCameraExists))
+
+	require.NoError(t, cs.DeleteCamera(ctx, "ITB-1101", "ITB-1101", "Front"))
+	require.Len(t, couch.cameras("ITB-1101"), 1)
+
+	err = cs.DeleteCamera(ctx, "ITB-1101", "ITB-1101", "Front")
+	require.True(t, errors.Is(err, cameraservices.ErrCameraNotFound))
+
+	err = cs.AddCamera(ctx, "ITB-1101", "Unknown", cameraservices.CameraConfig{DisplayName: "Side"})
+	require.True(t, errors.Is(err, cameraservices.ErrControlGroupNotFound))
+
+	err = cs.AddCamera(ctx, "ITB-1102", "ITB-1102", cameraservices.CameraConfig{DisplayName: "Side"})
+	require.True(t, errors.Is(err, cameraservices.ErrControlGroupNotFound))
+
+	// too many conflicts fail
+	couch.conflicts = _maxConflictRetries + 1
+	err = cs.DeleteCamera(ctx, "ITB-1101", "ITB-1101", "Back")
+	require.Error(t, err)
+}
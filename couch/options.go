@@ -1,14 +1,25 @@
 package couch
 
-import "github.com/go-kivik/couchdb/v3"
+import (
+	"time"
+
+	"github.com/go-kivik/couchdb/v3"
+	"go.uber.org/zap"
+)
 
 const (
-	_defaultUIConfigDB = "ui-configuration"
+	_defaultUIConfigDB   = "ui-configuration"
+	_defaultScheduleDB   = "camera-schedules"
+	_defaultSceneDB      = "camera-scenes"
+	_defaultControlKeyDB = "control-keys"
 )
 
 type options struct {
-	authFunc   interface{}
-	uiConfigDB string
+	authFunc     interface{}
+	uiConfigDB   string
+	scheduleDB   string
+	sceneDB      string
+	controlKeyDB string
 }
 
 type Option interface {
@@ -26,3 +37,56 @@ func WithBasicAuth(username, password string) Option {
 		o.authFunc = couchdb.BasicAuth(username, password)
 	})
 }
+
+// WithScheduleDB sets the database schedule entries are read from.
+func WithScheduleDB(db string) Option {
+	return optionFunc(func(o *options) {
+		o.scheduleDB = db
+	})
+}
+
+// WithSceneDB sets the database scenes are stored in.
+func WithSceneDB(db string) Option {
+	return optionFunc(func(o *options) {
+		o.sceneDB = db
+	})
+}
+
+// WithControlKeyDB sets the database issued control keys are stored in.
+func WithControlKeyDB(db string) Option {
+	return optionFunc(func(o *options) {
+		o.controlKeyDB = db
+	})
+}
+
+const _defaultRetryInterval = 5 * time.Second
+
+type cacheOptions struct {
+	retryInterval time.Duration
+	log           *zap.Logger
+}
+
+type CacheOption interface {
+	apply(*cacheOptions)
+}
+
+type cacheOptionFunc func(*cacheOptions)
+
+func (f cacheOptionFunc) apply(o *cacheOptions) {
+	f(o)
+}
+
+// WithRetryInterval sets how long a Cache waits to reconnect to the changes feed after it is interrupted.
+// The default is 5 seconds.
+func WithRetryInterval(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.retryInterval = d
+	})
+}
+
+// WithCacheLogger sets the logger a Cache logs to.
+func WithCacheLogger(log *zap.Logger) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.log = log
+	})
+}
@@ -0,0 +1,121 @@
+package couch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/go-kivik/kivik/v3"
+)
+
+// _maxConflictRetries is how many times a scene update is retried when the document was changed underneath us
+const _maxConflictRetries = 3
+
+func (c *configService) Scenes(ctx context.Context, room, controlGroup string) ([]cameraservices.Scene, error) {
+	doc, err := c.sceneDoc(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	scenes := doc.ControlGroups[controlGroup]
+	if scenes == nil {
+		scenes = []cameraservices.Scene{}
+	}
+
+	return scenes, nil
+}
+
+func (c *configService) Scene(ctx context.Context, room, controlGroup, name string) (cameraservices.Scene, error) {
+	scenes, err := c.Scenes(ctx, room, controlGroup)
+	if err != nil {
+		return cameraservices.Scene{}, err
+	}
+
+	for _, scene := range scenes {
+		if scene.Name == name {
+			return scene, nil
+		}
+	}
+
+	return cameraservices.Scene{}, cameraservices.ErrSceneNotFound
+}
+
+// SetScene creates the scene, or replaces the scene with the same name.
+func (c *configService) SetScene(ctx context.Context, room, controlGroup string, scene cameraservices.Scene) error {
+	return c.updateScenes(ctx, room, func(doc *sceneDoc) error {
+		scenes := doc.ControlGroups[controlGroup]
+		for i := range scenes {
+			if scenes[i].Name == scene.Name {
+				scenes[i] = scene
+				return nil
+			}
+		}
+
+		doc.ControlGroups[controlGroup] = append(scenes, scene)
+		return nil
+	})
+}
+
+func (c *configService) DeleteScene(ctx context.Context, room, controlGroup, name string) error {
+	return c.updateScenes(ctx, room, func(doc *sceneDoc) error {
+		scenes := doc.ControlGroups[controlGroup]
+		for i := range scenes {
+			if scenes[i].Name == name {
+				doc.ControlGroups[controlGroup] = append(scenes[:i], scenes[i+1:]...)
+				return nil
+			}
+		}
+
+		return cameraservices.ErrSceneNotFound
+	})
+}
+
+// sceneDoc gets the scene document for room. An empty document is returned if it doesn't exist yet.
+func (c *configService) sceneDoc(ctx context.Context, room string) (sceneDoc, error) {
+	doc := sceneDoc{
+		ID:            room,
+		ControlGroups: make(map[string][]cameraservices.Scene),
+	}
+
+	db := c.client.DB(ctx, c.sceneDB)
+	err := db.Get(ctx, room).ScanDoc(&doc)
+	switch {
+	case kivik.StatusCode(err) == http.StatusNotFound:
+		return doc, nil
+	case err != nil:
+		return doc, fmt.Errorf("unable to get/scan scenes: %w", err)
+	}
+
+	if doc.ControlGroups == nil {
+		doc.ControlGroups = make(map[string][]cameraservices.Scene)
+	}
+
+	return doc, nil
+}
+
+// updateScenes applies update to the latest version of room's scene document, retrying on revision conflicts.
+func (c *configService) updateScenes(ctx context.Context, room string, update func(*sceneDoc) error) error {
+	db := c.client.DB(ctx, c.sceneDB)
+
+	for i := 0; ; i++ {
+		doc, err := c.sceneDoc(ctx, room)
+		if err != nil {
+			return err
+		}
+
+		if err := update(&doc); err != nil {
+			return err
+		}
+
+		_, err = db.Put(ctx, room, doc)
+		switch {
+		case err == nil:
+			return nil
+		case kivik.StatusCode(err) == http.StatusConflict && i < _maxConflictRetries:
+			continue
+		default:
+			return fmt.Errorf("unable to put scenes: %w", err)
+		}
+	}
+}
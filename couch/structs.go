@@ -1,11 +1,24 @@
 package couch
 
-import cameraservices "github.com/byuoitav/camera-services"
-
-type uiConfig struct {
-	ID            string `json:"_id"`
-	ControlGroups []struct {
-		ID      string                        `json:"name"`
-		Cameras []cameraservices.CameraConfig `json:"cameras"`
-	} `json:"presets"`
+import (
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+type scheduleDoc struct {
+	ID        string                         `json:"_id"`
+	Schedules []cameraservices.ScheduleEntry `json:"schedules"`
+}
+
+// sceneDoc holds the scenes for each control group in a room, keyed by control group
+type sceneDoc struct {
+	ID            string                            `json:"_id"`
+	Rev           string                            `json:"_rev,omitempty"`
+	ControlGroups map[string][]cameraservices.Scene `json:"controlGroups"`
+}
+
+// controlKeyDoc holds the control keys issued for each control group in a room, keyed by control group
+type controlKeyDoc struct {
+	ID            string                                     `json:"_id"`
+	Rev           string                                     `json:"_rev,omitempty"`
+	ControlGroups map[string]cameraservices.IssuedControlKey `json:"controlGroups"`
 }
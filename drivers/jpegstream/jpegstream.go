@@ -0,0 +1,130 @@
+// Package jpegstream builds camera streams out of JPEG snapshots.
+package jpegstream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Poll streams frames by calling get every interval until ctx is cancelled.
+// The first frame is fetched before returning, so that an error is returned if get doesn't work at all.
+func Poll(ctx context.Context, interval time.Duration, get func(context.Context) ([]byte, error)) (chan []byte, chan error, error) {
+	first, err := get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jpegs := make(chan []byte)
+	errs := make(chan error)
+
+	go func() {
+		defer close(jpegs)
+		defer close(errs)
+
+		select {
+		case jpegs <- first:
+		case <-ctx.Done():
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				jpeg, err := get(ctx)
+				if err != nil {
+					select {
+					case errs <- err:
+						continue
+					case <-ctx.Done():
+						return
+					}
+				}
+
+				select {
+				case jpegs <- jpeg:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return jpegs, errs, nil
+}
+
+// Decode decodes each frame from jpegs into an image.
+// The returned channels are closed when either jpegs or jpegErrs is closed.
+func Decode(jpegs chan []byte, jpegErrs chan error) (chan image.Image, chan error) {
+	images := make(chan image.Image)
+	errs := make(chan error)
+
+	go func() {
+		defer close(images)
+		defer close(errs)
+
+		for {
+			select {
+			case jpeg, ok := <-jpegs:
+				if !ok {
+					return
+				}
+
+				image, _, err := image.Decode(bytes.NewReader(jpeg))
+				if err != nil {
+					errs <- fmt.Errorf("unable to decode image: %w", err)
+					continue
+				}
+
+				images <- image
+			case err, ok := <-jpegErrs:
+				if !ok {
+					return
+				}
+
+				errs <- err
+			}
+		}
+	}()
+
+	return images, errs
+}
+
+// Get gets a single frame from url. If username is set, it is sent using basic auth.
+func Get(ctx context.Context, client *http.Client, url, username, password string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%d response from camera", resp.StatusCode)
+	}
+
+	jpeg, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+
+	return jpeg, nil
+}
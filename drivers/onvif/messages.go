@@ -0,0 +1,107 @@
+package onvif
+
+import "encoding/xml"
+
+// requests
+
+type getSystemDateAndTime struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl GetSystemDateAndTime"`
+}
+
+type getCapabilities struct {
+	XMLName  xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl GetCapabilities"`
+	Category string   `xml:"Category"`
+}
+
+type systemReboot struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl SystemReboot"`
+}
+
+type getProfiles struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl GetProfiles"`
+}
+
+type getSnapshotURI struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl GetSnapshotUri"`
+	ProfileToken string   `xml:"ProfileToken"`
+}
+
+type continuousMove struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl ContinuousMove"`
+	ProfileToken string   `xml:"ProfileToken"`
+	Velocity     velocity `xml:"Velocity"`
+}
+
+type velocity struct {
+	PanTilt *vector2D `xml:"http://www.onvif.org/ver10/schema PanTilt,omitempty"`
+	Zoom    *vector1D `xml:"http://www.onvif.org/ver10/schema Zoom,omitempty"`
+}
+
+type vector2D struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+type vector1D struct {
+	X float64 `xml:"x,attr"`
+}
+
+type stop struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl Stop"`
+	ProfileToken string   `xml:"ProfileToken"`
+	PanTilt      bool     `xml:"PanTilt"`
+	Zoom         bool     `xml:"Zoom"`
+}
+
+type gotoPreset struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl GotoPreset"`
+	ProfileToken string   `xml:"ProfileToken"`
+	PresetToken  string   `xml:"PresetToken"`
+}
+
+type getPresets struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl GetPresets"`
+	ProfileToken string   `xml:"ProfileToken"`
+}
+
+type setPreset struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl SetPreset"`
+	ProfileToken string   `xml:"ProfileToken"`
+	PresetName   string   `xml:"PresetName,omitempty"`
+	PresetToken  string   `xml:"PresetToken,omitempty"`
+}
+
+// responses only match on local names, since the namespace declarations are on the envelope
+
+type getSystemDateAndTimeResponse struct {
+	UTC struct {
+		Year   int `xml:"Date>Year"`
+		Month  int `xml:"Date>Month"`
+		Day    int `xml:"Date>Day"`
+		Hour   int `xml:"Time>Hour"`
+		Minute int `xml:"Time>Minute"`
+		Second int `xml:"Time>Second"`
+	} `xml:"SystemDateAndTime>UTCDateTime"`
+}
+
+type getCapabilitiesResponse struct {
+	Media string `xml:"Capabilities>Media>XAddr"`
+	PTZ   string `xml:"Capabilities>PTZ>XAddr"`
+}
+
+type getProfilesResponse struct {
+	Profiles []struct {
+		Token string `xml:"token,attr"`
+	} `xml:"Profiles"`
+}
+
+type getSnapshotURIResponse struct {
+	URI string `xml:"MediaUri>Uri"`
+}
+
+type getPresetsResponse struct {
+	Presets []struct {
+		Token string `xml:"token,attr"`
+		Name  string `xml:"Name"`
+	} `xml:"Preset"`
+}
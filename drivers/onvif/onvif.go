@@ -0,0 +1,345 @@
+// Package onvif is a driver for ONVIF Profile S PTZ cameras.
+// Requests are authenticated using a WS-Security UsernameToken digest, and video comes from polling the snapshot uri.
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const (
+	_speedMin = -100
+	_speedMax = 100
+)
+
+// ErrNoPTZ is returned when controlling a camera that doesn't have a PTZ service.
+var ErrNoPTZ = errors.New("camera does not support ptz")
+
+type Camera struct {
+	host      string
+	deviceURL string
+	username  string
+	password  string
+
+	profile       string
+	panTiltSpeed  float64
+	zoomSpeed     float64
+	frameInterval time.Duration
+	client        *http.Client
+
+	// offset is the difference (in nanoseconds) between the camera's clock and ours
+	offset int64
+
+	mu  sync.Mutex
+	svc *services
+}
+
+// services are the camera's service urls, found the first time they are needed.
+type services struct {
+	media       string
+	ptz         string
+	profile     string
+	snapshotURI string
+}
+
+// New creates a camera at addr (host[:port] of the camera's web server).
+func New(addr string, opts ...Option) *Camera {
+	options := options{
+		devicePath:    _defaultDevicePath,
+		panTiltSpeed:  _defaultPanTiltSpeed,
+		zoomSpeed:     _defaultZoomSpeed,
+		frameInterval: _defaultFrameInterval,
+		client:        http.DefaultClient,
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	return &Camera{
+		host:          host,
+		deviceURL:     "http://" + addr + options.devicePath,
+		username:      options.username,
+		password:      options.password,
+		profile:       options.profile,
+		panTiltSpeed:  options.panTiltSpeed,
+		zoomSpeed:     options.zoomSpeed,
+		frameInterval: options.frameInterval,
+		client:        options.client,
+	}
+}
+
+func (c *Camera) RemoteAddr() string {
+	return c.host
+}
+
+// Close closes the idle keep-alive connections to the camera, unless it is using http.DefaultClient
+// (which is shared with every other camera).
+func (c *Camera) Close() error {
+	if c.client != http.DefaultClient {
+		c.client.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+func (c *Camera) TiltUp(ctx context.Context) error {
+	return c.move(ctx, &vector2D{Y: c.panTiltSpeed}, nil)
+}
+
+func (c *Camera) TiltDown(ctx context.Context) error {
+	return c.move(ctx, &vector2D{Y: -c.panTiltSpeed}, nil)
+}
+
+func (c *Camera) PanLeft(ctx context.Context) error {
+	return c.move(ctx, &vector2D{X: -c.panTiltSpeed}, nil)
+}
+
+func (c *Camera) PanRight(ctx context.Context) error {
+	return c.move(ctx, &vector2D{X: c.panTiltSpeed}, nil)
+}
+
+func (c *Camera) PanTiltStop(ctx context.Context) error {
+	return c.stop(ctx, true, false)
+}
+
+func (c *Camera) ZoomIn(ctx context.Context) error {
+	return c.move(ctx, nil, &vector1D{X: c.zoomSpeed})
+}
+
+func (c *Camera) ZoomOut(ctx context.Context) error {
+	return c.move(ctx, nil, &vector1D{X: -c.zoomSpeed})
+}
+
+func (c *Camera) ZoomStop(ctx context.Context) error {
+	return c.stop(ctx, false, true)
+}
+
+// PanTilt moves the camera at the given speeds (-100 to 100), stopping if both are 0.
+func (c *Camera) PanTilt(ctx context.Context, panSpeed, tiltSpeed int) error {
+	if err := checkSpeed(panSpeed); err != nil {
+		return err
+	}
+
+	if err := checkSpeed(tiltSpeed); err != nil {
+		return err
+	}
+
+	if panSpeed == 0 && tiltSpeed == 0 {
+		return c.stop(ctx, true, false)
+	}
+
+	return c.move(ctx, &vector2D{X: normalize(panSpeed), Y: normalize(tiltSpeed)}, nil)
+}
+
+// Zoom zooms the camera at the given speed (-100 to 100), stopping if it is 0.
+func (c *Camera) Zoom(ctx context.Context, speed int) error {
+	if err := checkSpeed(speed); err != nil {
+		return err
+	}
+
+	if speed == 0 {
+		return c.stop(ctx, false, true)
+	}
+
+	return c.move(ctx, nil, &vector1D{X: normalize(speed)})
+}
+
+// GoToPreset moves the camera to the preset whose token or name is preset.
+func (c *Camera) GoToPreset(ctx context.Context, preset string) error {
+	svc, err := c.ptz(ctx)
+	if err != nil {
+		return err
+	}
+
+	token, err := c.presetToken(ctx, svc, preset)
+	switch {
+	case err != nil:
+		return err
+	case token == "":
+		return fmt.Errorf("no preset %q on camera", preset)
+	}
+
+	return c.call(ctx, svc.ptz, gotoPreset{ProfileToken: svc.profile, PresetToken: token}, nil)
+}
+
+// SetPreset saves the current position as preset, overwriting an existing preset with the same token or name.
+func (c *Camera) SetPreset(ctx context.Context, preset string) error {
+	svc, err := c.ptz(ctx)
+	if err != nil {
+		return err
+	}
+
+	token, err := c.presetToken(ctx, svc, preset)
+	if err != nil {
+		return err
+	}
+
+	req := setPreset{
+		ProfileToken: svc.profile,
+		PresetName:   preset,
+		PresetToken:  token,
+	}
+
+	return c.call(ctx, svc.ptz, req, nil)
+}
+
+func (c *Camera) Reboot(ctx context.Context) error {
+	return c.call(ctx, c.deviceURL, systemReboot{}, nil)
+}
+
+func (c *Camera) Limits() map[string]map[string]cameraservices.Limits {
+	return map[string]map[string]cameraservices.Limits{
+		cameraservices.CapabilitySpeed: {
+			"pan":  {Min: _speedMin, Max: _speedMax},
+			"tilt": {Min: _speedMin, Max: _speedMax},
+			"zoom": {Min: _speedMin, Max: _speedMax},
+		},
+	}
+}
+
+func (c *Camera) move(ctx context.Context, panTilt *vector2D, zoom *vector1D) error {
+	svc, err := c.ptz(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := continuousMove{
+		ProfileToken: svc.profile,
+		Velocity: velocity{
+			PanTilt: panTilt,
+			Zoom:    zoom,
+		},
+	}
+
+	return c.call(ctx, svc.ptz, req, nil)
+}
+
+func (c *Camera) stop(ctx context.Context, panTilt, zoom bool) error {
+	svc, err := c.ptz(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.call(ctx, svc.ptz, stop{ProfileToken: svc.profile, PanTilt: panTilt, Zoom: zoom}, nil)
+}
+
+// presetToken returns the token of the preset whose token or name is preset, or "" if there isn't one.
+func (c *Camera) presetToken(ctx context.Context, svc *services, preset string) (string, error) {
+	var resp getPresetsResponse
+	if err := c.call(ctx, svc.ptz, getPresets{ProfileToken: svc.profile}, &resp); err != nil {
+		return "", fmt.Errorf("unable to get presets: %w", err)
+	}
+
+	for _, p := range resp.Presets {
+		if p.Token == preset {
+			return p.Token, nil
+		}
+	}
+
+	for _, p := range resp.Presets {
+		if p.Name == preset {
+			return p.Token, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (c *Camera) ptz(ctx context.Context) (*services, error) {
+	svc, err := c.services(ctx)
+	switch {
+	case err != nil:
+		return nil, err
+	case svc.ptz == "":
+		return nil, ErrNoPTZ
+	}
+
+	return svc, nil
+}
+
+// services finds the camera's service urls and media profile, syncing with the camera's clock first
+// so that the security tokens aren't rejected. It only talks to the camera until it succeeds once.
+func (c *Camera) services(ctx context.Context) (*services, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.svc != nil {
+		return c.svc, nil
+	}
+
+	var date getSystemDateAndTimeResponse
+	if err := c.call(ctx, c.deviceURL, getSystemDateAndTime{}, &date); err != nil {
+		return nil, fmt.Errorf("unable to get system date and time: %w", err)
+	}
+
+	if date.UTC.Year != 0 {
+		utc := time.Date(date.UTC.Year, time.Month(date.UTC.Month), date.UTC.Day, date.UTC.Hour, date.UTC.Minute, date.UTC.Second, 0, time.UTC)
+		atomic.StoreInt64(&c.offset, int64(time.Until(utc)))
+	}
+
+	var caps getCapabilitiesResponse
+	if err := c.call(ctx, c.deviceURL, getCapabilities{Category: "All"}, &caps); err != nil {
+		return nil, fmt.Errorf("unable to get capabilities: %w", err)
+	}
+
+	if caps.Media == "" {
+		return nil, errors.New("camera does not have a media service")
+	}
+
+	svc := &services{
+		media:   caps.Media,
+		ptz:     caps.PTZ,
+		profile: c.profile,
+	}
+
+	if svc.profile == "" {
+		var profiles getProfilesResponse
+		if err := c.call(ctx, svc.media, getProfiles{}, &profiles); err != nil {
+			return nil, fmt.Errorf("unable to get profiles: %w", err)
+		}
+
+		if len(profiles.Profiles) == 0 {
+			return nil, errors.New("camera has no media profiles")
+		}
+
+		svc.profile = profiles.Profiles[0].Token
+	}
+
+	var snapshot getSnapshotURIResponse
+	if err := c.call(ctx, svc.media, getSnapshotURI{ProfileToken: svc.profile}, &snapshot); err == nil {
+		svc.snapshotURI = snapshot.URI
+	}
+
+	c.svc = svc
+	return svc, nil
+}
+
+func (c *Camera) clockOffset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+func checkSpeed(speed int) error {
+	if speed < _speedMin || speed > _speedMax {
+		return fmt.Errorf("speed must be between %d and %d", _speedMin, _speedMax)
+	}
+
+	return nil
+}
+
+func normalize(speed int) float64 {
+	return float64(speed) / _speedMax
+}
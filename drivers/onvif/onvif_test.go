@@ -0,0 +1,237 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	_testUsername = "admin"
+	_testPassword = "password"
+)
+
+// stub is a minimal ONVIF device, media, and ptz service.
+type stub struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu    sync.Mutex
+	calls []string
+	body  map[string]string
+}
+
+type stubEnvelope struct {
+	Token struct {
+		Username string `xml:"Username"`
+		Password string `xml:"Password"`
+		Nonce    string `xml:"Nonce"`
+		Created  string `xml:"Created"`
+	} `xml:"Header>Security>UsernameToken"`
+	Body struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+func newStub(t *testing.T) *stub {
+	s := &stub{t: t, body: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *stub) addr() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+func (s *stub) called() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.calls...)
+}
+
+func (s *stub) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/snapshot.jpg" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != _testUsername || pass != _testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		buf := &bytes.Buffer{}
+		_ = jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	require.NoError(s.t, err)
+
+	var env stubEnvelope
+	require.NoError(s.t, xml.Unmarshal(data, &env))
+
+	var op struct {
+		XMLName xml.Name
+	}
+	require.NoError(s.t, xml.Unmarshal(env.Body.Inner, &op))
+
+	name := op.XMLName.Local
+	s.mu.Lock()
+	s.calls = append(s.calls, name)
+	s.body[name] = string(env.Body.Inner)
+	s.mu.Unlock()
+
+	if name != "GetSystemDateAndTime" && !validDigest(env) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, envelopeXML(`<s:Fault><s:Code><s:Value>s:Sender</s:Value></s:Code><s:Reason><s:Text>not authorized</s:Text></s:Reason></s:Fault>`))
+		return
+	}
+
+	var resp string
+	switch name {
+	case "GetSystemDateAndTime":
+		now := time.Now().UTC()
+		resp = fmt.Sprintf(`<tds:GetSystemDateAndTimeResponse><tds:SystemDateAndTime><tt:UTCDateTime><tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time><tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date></tt:UTCDateTime></tds:SystemDateAndTime></tds:GetSystemDateAndTimeResponse>`,
+			now.Hour(), now.Minute(), now.Second(), now.Year(), now.Month(), now.Day())
+	case "GetCapabilities":
+		resp = fmt.Sprintf(`<tds:GetCapabilitiesResponse><tds:Capabilities><tt:Media><tt:XAddr>%[1]s/onvif/media</tt:XAddr></tt:Media><tt:PTZ><tt:XAddr>%[1]s/onvif/ptz</tt:XAddr></tt:PTZ></tds:Capabilities></tds:GetCapabilitiesResponse>`, s.server.URL)
+	case "GetProfiles":
+		resp = `<trt:GetProfilesResponse><trt:Profiles token="profile_1"><tt:Name>main</tt:Name></trt:Profiles><trt:Profiles token="profile_2"><tt:Name>sub</tt:Name></trt:Profiles></trt:GetProfilesResponse>`
+	case "GetSnapshotUri":
+		resp = fmt.Sprintf(`<trt:GetSnapshotUriResponse><trt:MediaUri><tt:Uri>%s/snapshot.jpg</tt:Uri></trt:MediaUri></trt:GetSnapshotUriResponse>`, s.server.URL)
+	case "GetPresets":
+		resp = `<tptz:GetPresetsResponse><tptz:Preset token="7"><tt:Name>1</tt:Name></tptz:Preset></tptz:GetPresetsResponse>`
+	default:
+		resp = fmt.Sprintf(`<x:%[1]sResponse xmlns:x="urn:stub"/>`, name)
+	}
+
+	fmt.Fprint(w, envelopeXML(resp))
+}
+
+func envelopeXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"><s:Body>` + body + `</s:Body></s:Envelope>`
+}
+
+func validDigest(env stubEnvelope) bool {
+	if env.Token.Username != _testUsername {
+		return false
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(env.Token.Nonce)
+	if err != nil {
+		return false
+	}
+
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(env.Token.Created))
+	h.Write([]byte(_testPassword))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)) == env.Token.Password
+}
+
+func TestMove(t *testing.T) {
+	s := newStub(t)
+	defer s.server.Close()
+
+	cam := New(s.addr(), WithCredentials(_testUsername, _testPassword))
+	require.Equal(t, "127.0.0.1", cam.RemoteAddr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, cam.PanLeft(ctx))
+	require.Equal(t, []string{"GetSystemDateAndTime", "GetCapabilities", "GetProfiles", "GetSnapshotUri", "ContinuousMove"}, s.called())
+	require.Contains(t, s.body["ContinuousMove"], "profile_1")
+	require.Contains(t, s.body["ContinuousMove"], `x="-0.5"`)
+
+	require.NoError(t, cam.PanTiltStop(ctx))
+	require.Contains(t, s.body["Stop"], "<PanTilt>true</PanTilt>")
+	require.Contains(t, s.body["Stop"], "<Zoom>false</Zoom>")
+
+	require.NoError(t, cam.Zoom(ctx, 100))
+	require.Contains(t, s.body["ContinuousMove"], `<Zoom xmlns="http://www.onvif.org/ver10/schema" x="1"></Zoom>`)
+	require.Error(t, cam.Zoom(ctx, 101))
+}
+
+func TestPresets(t *testing.T) {
+	s := newStub(t)
+	defer s.server.Close()
+
+	cam := New(s.addr(), WithCredentials(_testUsername, _testPassword), WithProfile("profile_2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, cam.GoToPreset(ctx, "1"))
+	require.NotContains(t, s.called(), "GetProfiles")
+	require.Contains(t, s.body["GotoPreset"], "<PresetToken>7</PresetToken>")
+	require.Contains(t, s.body["GotoPreset"], "profile_2")
+
+	require.Error(t, cam.GoToPreset(ctx, "2"))
+
+	require.NoError(t, cam.SetPreset(ctx, "1"))
+	require.Contains(t, s.body["SetPreset"], "<PresetToken>7</PresetToken>")
+
+	require.NoError(t, cam.SetPreset(ctx, "2"))
+	require.Contains(t, s.body["SetPreset"], "<PresetName>2</PresetName>")
+	require.NotContains(t, s.body["SetPreset"], "PresetToken")
+}
+
+func TestReboot(t *testing.T) {
+	s := newStub(t)
+	defer s.server.Close()
+
+	cam := New(s.addr(), WithCredentials(_testUsername, _testPassword))
+	require.NoError(t, cam.Reboot(context.Background()))
+	require.Equal(t, []string{"SystemReboot"}, s.called())
+}
+
+func TestBadCredentials(t *testing.T) {
+	s := newStub(t)
+	defer s.server.Close()
+
+	cam := New(s.addr(), WithCredentials(_testUsername, "wrong"))
+
+	err := cam.Reboot(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not authorized")
+}
+
+func TestStream(t *testing.T) {
+	s := newStub(t)
+	defer s.server.Close()
+
+	cam := New(s.addr(), WithCredentials(_testUsername, _testPassword), WithFrameInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	images, _, err := cam.Stream(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		img := <-images
+		require.NotNil(t, img)
+		require.Equal(t, 8, img.Bounds().Dx())
+	}
+
+	img, err := cam.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 8, img.Bounds().Dy())
+}
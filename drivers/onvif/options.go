@@ -0,0 +1,78 @@
+package onvif
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	_defaultDevicePath    = "/onvif/device_service"
+	_defaultPanTiltSpeed  = 0.5
+	_defaultZoomSpeed     = 0.5
+	_defaultFrameInterval = 125 * time.Millisecond
+)
+
+type options struct {
+	username      string
+	password      string
+	devicePath    string
+	profile       string
+	panTiltSpeed  float64
+	zoomSpeed     float64
+	frameInterval time.Duration
+	client        *http.Client
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithCredentials sets the username and password used to authenticate with the camera.
+func WithCredentials(username, password string) Option {
+	return optionFunc(func(o *options) {
+		o.username = username
+		o.password = password
+	})
+}
+
+// WithDevicePath sets the path of the camera's device service.
+func WithDevicePath(path string) Option {
+	return optionFunc(func(o *options) {
+		o.devicePath = path
+	})
+}
+
+// WithProfile sets the media profile to control. The camera's first profile is used by default.
+func WithProfile(token string) Option {
+	return optionFunc(func(o *options) {
+		o.profile = token
+	})
+}
+
+// WithSpeed sets the normalized speed (0-1) the camera pans, tilts, and zooms at.
+func WithSpeed(panTilt, zoom float64) Option {
+	return optionFunc(func(o *options) {
+		o.panTiltSpeed = panTilt
+		o.zoomSpeed = zoom
+	})
+}
+
+// WithFrameInterval sets how often the snapshot uri is polled while streaming.
+func WithFrameInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.frameInterval = d
+	})
+}
+
+// WithHTTPClient sets the client used to talk to the camera.
+func WithHTTPClient(client *http.Client) Option {
+	return optionFunc(func(o *options) {
+		o.client = client
+	})
+}
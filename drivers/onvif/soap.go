@@ -0,0 +1,172 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	_passwordDigest = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	_base64Binary   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+)
+
+type envelope struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Header  *header  `xml:"http://www.w3.org/2003/05/soap-envelope Header,omitempty"`
+	Body    body     `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+}
+
+type header struct {
+	Security security `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd Security"`
+}
+
+type body struct {
+	Content interface{}
+}
+
+type security struct {
+	MustUnderstand string        `xml:"http://www.w3.org/2003/05/soap-envelope mustUnderstand,attr"`
+	UsernameToken  usernameToken `xml:"UsernameToken"`
+}
+
+type usernameToken struct {
+	Username string   `xml:"Username"`
+	Password password `xml:"Password"`
+	Nonce    nonce    `xml:"Nonce"`
+	Created  string   `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Created"`
+}
+
+type password struct {
+	Type  string `xml:"Type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type nonce struct {
+	EncodingType string `xml:"EncodingType,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type responseEnvelope struct {
+	Body struct {
+		Fault *fault `xml:"Fault"`
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+type fault struct {
+	Code   string `xml:"Code>Value"`
+	Reason string `xml:"Reason>Text"`
+
+	// soap 1.1
+	String string `xml:"faultstring"`
+}
+
+func (f *fault) Error() string {
+	reason := f.Reason
+	if reason == "" {
+		reason = f.String
+	}
+
+	return fmt.Sprintf("soap fault: %s %s", strings.TrimSpace(f.Code), strings.TrimSpace(reason))
+}
+
+// digest builds a WS-Security UsernameToken with a password digest, created at created.
+func digest(username, pass string, created time.Time) (usernameToken, error) {
+	n := make([]byte, 16)
+	if _, err := rand.Read(n); err != nil {
+		return usernameToken{}, fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	c := created.UTC().Format("2006-01-02T15:04:05.000Z")
+
+	h := sha1.New()
+	h.Write(n)
+	h.Write([]byte(c))
+	h.Write([]byte(pass))
+
+	return usernameToken{
+		Username: username,
+		Password: password{
+			Type:  _passwordDigest,
+			Value: base64.StdEncoding.EncodeToString(h.Sum(nil)),
+		},
+		Nonce: nonce{
+			EncodingType: _base64Binary,
+			Value:        base64.StdEncoding.EncodeToString(n),
+		},
+		Created: c,
+	}, nil
+}
+
+// call sends req to the service at url and decodes the response into resp (if resp isn't nil).
+func (c *Camera) call(ctx context.Context, url string, req, resp interface{}) error {
+	env := envelope{
+		Body: body{Content: req},
+	}
+
+	if c.username != "" {
+		token, err := digest(c.username, c.password, time.Now().Add(c.clockOffset()))
+		if err != nil {
+			return err
+		}
+
+		env.Header = &header{
+			Security: security{
+				MustUnderstand: "1",
+				UsernameToken:  token,
+			},
+		}
+	}
+
+	buf, err := xml.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("unable to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("unable to build request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("unable to make request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response: %w", err)
+	}
+
+	var renv responseEnvelope
+	if err := xml.Unmarshal(data, &renv); err != nil {
+		return fmt.Errorf("unable to parse %d response: %w", httpResp.StatusCode, err)
+	}
+
+	switch {
+	case renv.Body.Fault != nil:
+		return renv.Body.Fault
+	case httpResp.StatusCode/100 != 2:
+		return fmt.Errorf("%d response from camera", httpResp.StatusCode)
+	case resp == nil:
+		return nil
+	}
+
+	if err := xml.Unmarshal(renv.Body.Inner, resp); err != nil {
+		return fmt.Errorf("unable to parse response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/byuoitav/camera-services/drivers/jpegstream"
+)
+
+// ErrNoSnapshot is returned when streaming from a camera that doesn't have a snapshot uri.
+var ErrNoSnapshot = errors.New("camera does not have a snapshot uri")
+
+// StreamJPEG streams frames by polling the camera's snapshot uri.
+func (c *Camera) StreamJPEG(ctx context.Context) (chan []byte, chan error, error) {
+	uri, err := c.snapshotURI(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return jpegstream.Poll(ctx, c.frameInterval, func(ctx context.Context) ([]byte, error) {
+		return jpegstream.Get(ctx, c.client, uri, c.username, c.password)
+	})
+}
+
+func (c *Camera) Stream(ctx context.Context) (chan image.Image, chan error, error) {
+	jpegs, errs, err := c.StreamJPEG(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images, errs := jpegstream.Decode(jpegs, errs)
+	return images, errs, nil
+}
+
+func (c *Camera) Snapshot(ctx context.Context) (image.Image, error) {
+	uri, err := c.snapshotURI(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jpeg, err := jpegstream.Get(ctx, c.client, uri, c.username, c.password)
+	if err != nil {
+		return nil, err
+	}
+
+	image, _, err := image.Decode(bytes.NewReader(jpeg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image: %w", err)
+	}
+
+	return image, nil
+}
+
+func (c *Camera) snapshotURI(ctx context.Context) (string, error) {
+	svc, err := c.services(ctx)
+	switch {
+	case err != nil:
+		return "", err
+	case svc.snapshotURI == "":
+		return "", ErrNoSnapshot
+	}
+
+	return svc.snapshotURI, nil
+}
@@ -0,0 +1,39 @@
+package pro520
+
+import "context"
+
+func (c *Camera) SetFocusMode(ctx context.Context, mode string) error {
+	return c.raw.SetFocusMode(ctx, mode)
+}
+
+func (c *Camera) FocusNear(ctx context.Context) error {
+	return c.raw.FocusNear(ctx)
+}
+
+func (c *Camera) FocusFar(ctx context.Context) error {
+	return c.raw.FocusFar(ctx)
+}
+
+func (c *Camera) FocusStop(ctx context.Context) error {
+	return c.raw.FocusStop(ctx)
+}
+
+func (c *Camera) OnePushFocus(ctx context.Context) error {
+	return c.raw.OnePushFocus(ctx)
+}
+
+func (c *Camera) SetExposureMode(ctx context.Context, mode string) error {
+	return c.raw.SetExposureMode(ctx, mode)
+}
+
+func (c *Camera) SetIris(ctx context.Context, level int) error {
+	return c.raw.SetIris(ctx, level)
+}
+
+func (c *Camera) SetBrightnessCompensation(ctx context.Context, level int) error {
+	return c.raw.SetBrightnessCompensation(ctx, level)
+}
+
+func (c *Camera) SetWhiteBalance(ctx context.Context, mode string) error {
+	return c.raw.SetWhiteBalance(ctx, mode)
+}
@@ -0,0 +1,60 @@
+// Package pro520 adds the optional camera-services capabilities to aver's Pro520 driver.
+package pro520
+
+import (
+	"net"
+	"strings"
+
+	"github.com/byuoitav/aver"
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/viscaip"
+	"github.com/byuoitav/visca"
+)
+
+const (
+	_presetMin = 0x00
+	_presetMax = 0x7f
+)
+
+type Camera struct {
+	*aver.Pro520
+
+	// raw is used for the commands and inquiries aver.Pro520 doesn't support
+	raw *viscaip.Client
+}
+
+// New creates a Pro520 at addr (host:port of the camera's VISCA port).
+// username and password are used for the camera's web API.
+func New(addr, username, password string, opts ...visca.Option) (*Camera, error) {
+	host := addr
+	if strings.Contains(host, ":") {
+		var err error
+		if host, _, err = net.SplitHostPort(host); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Camera{
+		Pro520: &aver.Pro520{
+			Camera:   visca.New(addr, opts...),
+			Address:  host,
+			Username: username,
+			Password: password,
+		},
+		raw: &viscaip.Client{
+			Address: addr,
+		},
+	}, nil
+}
+
+func (c *Camera) Limits() map[string]map[string]cameraservices.Limits {
+	return map[string]map[string]cameraservices.Limits{
+		cameraservices.CapabilityPresets: {
+			"preset": {Min: _presetMin, Max: _presetMax},
+		},
+		cameraservices.CapabilityExposure: {
+			"iris":       {Min: viscaip.IrisMin, Max: viscaip.IrisMax},
+			"brightness": {Min: viscaip.BrightnessMin, Max: viscaip.BrightnessMax},
+		},
+	}
+}
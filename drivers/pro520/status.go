@@ -0,0 +1,16 @@
+package pro520
+
+import (
+	"context"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const _model = "Pro520"
+
+// Status gets the state of the camera using VISCA inquiries.
+func (c *Camera) Status(ctx context.Context) (cameraservices.Status, error) {
+	status, err := c.raw.Status(ctx)
+	status.Model = _model
+	return status, err
+}
@@ -0,0 +1,75 @@
+package sim
+
+import "time"
+
+const (
+	_defaultFrameInterval = 125 * time.Millisecond
+	_defaultFrameWidth    = 640
+	_defaultFrameHeight   = 360
+	_defaultRebootTime    = 5 * time.Second
+)
+
+type options struct {
+	latency       time.Duration
+	jitter        time.Duration
+	failureRate   float64
+	frameInterval time.Duration
+	frameWidth    int
+	frameHeight   int
+	rebootTime    time.Duration
+	seed          int64
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithLatency delays every command by latency, plus a random amount up to jitter.
+func WithLatency(latency, jitter time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.latency = latency
+		o.jitter = jitter
+	})
+}
+
+// WithFailureRate sets the fraction (0-1) of commands that fail with ErrInjectedFailure.
+func WithFailureRate(rate float64) Option {
+	return optionFunc(func(o *options) {
+		o.failureRate = rate
+	})
+}
+
+// WithFrameInterval sets how often a frame is rendered while streaming.
+func WithFrameInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.frameInterval = d
+	})
+}
+
+// WithFrameSize sets the size of the rendered frames.
+func WithFrameSize(width, height int) Option {
+	return optionFunc(func(o *options) {
+		o.frameWidth = width
+		o.frameHeight = height
+	})
+}
+
+// WithRebootTime sets how long the camera is unavailable after being rebooted.
+func WithRebootTime(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.rebootTime = d
+	})
+}
+
+// WithSeed sets the seed used to generate latency jitter and failures, so that they are repeatable.
+func WithSeed(seed int64) Option {
+	return optionFunc(func(o *options) {
+		o.seed = seed
+	})
+}
@@ -0,0 +1,205 @@
+package sim
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// horizontal field of view at 1x zoom, in degrees
+	_fov = 60
+
+	// degrees between grid lines
+	_gridSpacing = 10
+
+	_textScale  = 2
+	_textMargin = 8
+)
+
+// render draws what the camera is pointed at: a grid over the world whose color shows the pan angle,
+// and whose brightness shows the tilt angle, with the position and active preset written on top.
+func (c *Camera) render() *image.RGBA {
+	c.mu.Lock()
+	now := time.Now()
+	rebooting := now.Before(c.rebootUntil)
+	c.advance(now)
+	pos, preset := c.pos, c.preset
+	c.mu.Unlock()
+
+	w, h := c.frameWidth, c.frameHeight
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	if rebooting {
+		draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+		drawText(img, _textMargin, _textMargin, "REBOOTING")
+		return img
+	}
+
+	degPerPx := _fov / pos.Zoom / float64(w)
+
+	for x := 0; x < w; x++ {
+		worldPan := pos.Pan + (float64(x)-float64(w)/2)*degPerPx
+		r, g, b := hsv(math.Mod(worldPan+360, 360)/360, 0.6, 1)
+		vline := crosses(worldPan, degPerPx, _gridSpacing)
+
+		for y := 0; y < h; y++ {
+			worldTilt := pos.Tilt - (float64(y)-float64(h)/2)*degPerPx
+			brightness := clamp(0.4+0.6*(worldTilt+90)/180, 0, 1)
+
+			switch {
+			case crosses(worldTilt, degPerPx, 90):
+				// the horizon
+				brightness = 1.5
+			case vline || crosses(worldTilt, degPerPx, _gridSpacing):
+				brightness *= 0.3
+			}
+
+			i := img.PixOffset(x, y)
+			img.Pix[i+0] = shade(r, brightness)
+			img.Pix[i+1] = shade(g, brightness)
+			img.Pix[i+2] = shade(b, brightness)
+			img.Pix[i+3] = 0xff
+		}
+	}
+
+	// crosshair in the center
+	for i := -6; i <= 6; i++ {
+		img.Set(w/2+i, h/2, color.White)
+		img.Set(w/2, h/2+i, color.White)
+	}
+
+	lines := []string{
+		c.addr,
+		fmt.Sprintf("PAN %.1f TILT %.1f ZOOM %.1fX", pos.Pan, pos.Tilt, pos.Zoom),
+	}
+
+	if preset != "" {
+		lines = append(lines, "PRESET "+preset)
+	}
+
+	for i, line := range lines {
+		drawText(img, _textMargin, _textMargin+i*(_glyphHeight+2)*_textScale, line)
+	}
+
+	return img
+}
+
+// crosses returns true if a multiple of spacing is between v and v+step.
+func crosses(v, step, spacing float64) bool {
+	return math.Floor(v/spacing) != math.Floor((v+step)/spacing)
+}
+
+func shade(v, brightness float64) uint8 {
+	return uint8(clamp(v*brightness*255, 0, 255))
+}
+
+// hsv converts a hue, saturation, and value (all 0-1) to rgb (0-1).
+func hsv(h, s, v float64) (float64, float64, float64) {
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p := v * (1 - s)
+	q := v * (1 - f*s)
+	t := v * (1 - (1-f)*s)
+
+	switch int(i) % 6 {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
+
+// drawText draws white text on a black background with its top left corner at (x, y).
+func drawText(img *image.RGBA, x, y int, text string) {
+	text = strings.ToUpper(text)
+
+	width := len(text) * (_glyphWidth + 1) * _textScale
+	background := image.Rect(x-_textScale, y-_textScale, x+width, y+(_glyphHeight+1)*_textScale)
+	draw.Draw(img, background, image.Black, image.Point{}, draw.Src)
+
+	for _, r := range text {
+		g, ok := _font[r]
+		if !ok {
+			g = _unknownGlyph
+		}
+
+		for row := 0; row < _glyphHeight; row++ {
+			for col := 0; col < _glyphWidth; col++ {
+				if g[row]&(1<<(_glyphWidth-1-col)) == 0 {
+					continue
+				}
+
+				px := image.Rect(x+col*_textScale, y+row*_textScale, x+(col+1)*_textScale, y+(row+1)*_textScale)
+				draw.Draw(img, px, image.White, image.Point{}, draw.Src)
+			}
+		}
+
+		x += (_glyphWidth + 1) * _textScale
+	}
+}
+
+const (
+	_glyphWidth  = 3
+	_glyphHeight = 5
+)
+
+var _unknownGlyph = [_glyphHeight]uint8{0b111, 0b111, 0b111, 0b111, 0b111}
+
+// _font is a tiny 3x5 bitmap font, one row per byte
+var _font = map[rune][_glyphHeight]uint8{
+	' ': {0b000, 0b000, 0b000, 0b000, 0b000},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'_': {0b000, 0b000, 0b000, 0b000, 0b111},
+	':': {0b000, 0b010, 0b000, 0b010, 0b000},
+	'/': {0b001, 0b001, 0b010, 0b100, 0b100},
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b001, 0b001, 0b001},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'A': {0b010, 0b101, 0b111, 0b101, 0b101},
+	'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	'C': {0b011, 0b100, 0b100, 0b100, 0b011},
+	'D': {0b110, 0b101, 0b101, 0b101, 0b110},
+	'E': {0b111, 0b100, 0b110, 0b100, 0b111},
+	'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'G': {0b011, 0b100, 0b101, 0b101, 0b011},
+	'H': {0b101, 0b101, 0b111, 0b101, 0b101},
+	'I': {0b111, 0b010, 0b010, 0b010, 0b111},
+	'J': {0b001, 0b001, 0b001, 0b101, 0b010},
+	'K': {0b101, 0b101, 0b110, 0b101, 0b101},
+	'L': {0b100, 0b100, 0b100, 0b100, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'N': {0b110, 0b101, 0b101, 0b101, 0b101},
+	'O': {0b010, 0b101, 0b101, 0b101, 0b010},
+	'P': {0b110, 0b101, 0b110, 0b100, 0b100},
+	'Q': {0b010, 0b101, 0b101, 0b110, 0b011},
+	'R': {0b110, 0b101, 0b110, 0b101, 0b101},
+	'S': {0b011, 0b100, 0b010, 0b001, 0b110},
+	'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'U': {0b101, 0b101, 0b101, 0b101, 0b111},
+	'V': {0b101, 0b101, 0b101, 0b101, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101},
+	'X': {0b101, 0b101, 0b010, 0b101, 0b101},
+	'Y': {0b101, 0b101, 0b010, 0b010, 0b010},
+	'Z': {0b111, 0b001, 0b010, 0b100, 0b111},
+}
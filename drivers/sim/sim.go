@@ -0,0 +1,392 @@
+// Package sim is an in-process simulated camera, for developing and testing without a real camera.
+// It keeps a virtual pan/tilt/zoom state and renders frames that show where it is pointed.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// The range of the camera's position. Pan and tilt are in degrees, and zoom is the magnification.
+const (
+	PanMin  = -170
+	PanMax  = 170
+	TiltMin = -30
+	TiltMax = 90
+	ZoomMin = 1
+	ZoomMax = 20
+)
+
+const (
+	// max speeds, per second
+	_maxPanSpeed  = 60
+	_maxTiltSpeed = 40
+	_maxZoomSpeed = 5
+
+	_speedMin = -100
+	_speedMax = 100
+
+	// speed (as a fraction of the max speed) the discrete movement commands move at
+	_defaultSpeed = 0.5
+)
+
+var (
+	// ErrInjectedFailure is returned by commands that were chosen to fail.
+	ErrInjectedFailure = errors.New("simulated failure")
+
+	// ErrRebooting is returned by commands sent while the camera is rebooting.
+	ErrRebooting = errors.New("camera is rebooting")
+)
+
+type Camera struct {
+	addr string
+
+	latency       time.Duration
+	jitter        time.Duration
+	failureRate   float64
+	frameInterval time.Duration
+	frameWidth    int
+	frameHeight   int
+	rebootTime    time.Duration
+
+	mu          sync.Mutex
+	rand        *rand.Rand
+	pos         cameraservices.Position
+	vel         cameraservices.Position
+	updated     time.Time
+	presets     map[string]cameraservices.Position
+	preset      string
+	rebootUntil time.Time
+}
+
+// New creates a simulated camera. addr is only used to identify the camera.
+func New(addr string, opts ...Option) *Camera {
+	options := options{
+		frameInterval: _defaultFrameInterval,
+		frameWidth:    _defaultFrameWidth,
+		frameHeight:   _defaultFrameHeight,
+		rebootTime:    _defaultRebootTime,
+		seed:          time.Now().UnixNano(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Camera{
+		addr:          addr,
+		latency:       options.latency,
+		jitter:        options.jitter,
+		failureRate:   options.failureRate,
+		frameInterval: options.frameInterval,
+		frameWidth:    options.frameWidth,
+		frameHeight:   options.frameHeight,
+		rebootTime:    options.rebootTime,
+		rand:          rand.New(rand.NewSource(options.seed)),
+		pos:           home(),
+		updated:       time.Now(),
+		presets:       make(map[string]cameraservices.Position),
+	}
+}
+
+func (c *Camera) RemoteAddr() string {
+	return c.addr
+}
+
+func (c *Camera) TiltUp(ctx context.Context) error {
+	return c.setVelocity(ctx, nil, ptr(_defaultSpeed*_maxTiltSpeed), nil)
+}
+
+func (c *Camera) TiltDown(ctx context.Context) error {
+	return c.setVelocity(ctx, nil, ptr(-_defaultSpeed*_maxTiltSpeed), nil)
+}
+
+func (c *Camera) PanLeft(ctx context.Context) error {
+	return c.setVelocity(ctx, ptr(-_defaultSpeed*_maxPanSpeed), nil, nil)
+}
+
+func (c *Camera) PanRight(ctx context.Context) error {
+	return c.setVelocity(ctx, ptr(_defaultSpeed*_maxPanSpeed), nil, nil)
+}
+
+func (c *Camera) PanTiltStop(ctx context.Context) error {
+	return c.setVelocity(ctx, ptr(0), ptr(0), nil)
+}
+
+func (c *Camera) ZoomIn(ctx context.Context) error {
+	return c.setVelocity(ctx, nil, nil, ptr(_defaultSpeed*_maxZoomSpeed))
+}
+
+func (c *Camera) ZoomOut(ctx context.Context) error {
+	return c.setVelocity(ctx, nil, nil, ptr(-_defaultSpeed*_maxZoomSpeed))
+}
+
+func (c *Camera) ZoomStop(ctx context.Context) error {
+	return c.setVelocity(ctx, nil, nil, ptr(0))
+}
+
+// PanTilt moves the camera at the given speeds (-100 to 100), stopping if both are 0.
+func (c *Camera) PanTilt(ctx context.Context, panSpeed, tiltSpeed int) error {
+	if err := checkSpeed(panSpeed); err != nil {
+		return err
+	}
+
+	if err := checkSpeed(tiltSpeed); err != nil {
+		return err
+	}
+
+	pan := float64(panSpeed) / _speedMax * _maxPanSpeed
+	tilt := float64(tiltSpeed) / _speedMax * _maxTiltSpeed
+	return c.setVelocity(ctx, &pan, &tilt, nil)
+}
+
+// Zoom zooms the camera at the given speed (-100 to 100), stopping if it is 0.
+func (c *Camera) Zoom(ctx context.Context, speed int) error {
+	if err := checkSpeed(speed); err != nil {
+		return err
+	}
+
+	zoom := float64(speed) / _speedMax * _maxZoomSpeed
+	return c.setVelocity(ctx, nil, nil, &zoom)
+}
+
+func (c *Camera) Position(ctx context.Context) (cameraservices.Position, error) {
+	if err := c.command(ctx); err != nil {
+		return cameraservices.Position{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(time.Now())
+	return c.pos, nil
+}
+
+func (c *Camera) SetPosition(ctx context.Context, pos cameraservices.Position) error {
+	switch {
+	case pos.Pan < PanMin || pos.Pan > PanMax:
+		return fmt.Errorf("pan must be between %d and %d", PanMin, PanMax)
+	case pos.Tilt < TiltMin || pos.Tilt > TiltMax:
+		return fmt.Errorf("tilt must be between %d and %d", TiltMin, TiltMax)
+	case pos.Zoom < ZoomMin || pos.Zoom > ZoomMax:
+		return fmt.Errorf("zoom must be between %d and %d", ZoomMin, ZoomMax)
+	}
+
+	if err := c.command(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(time.Now())
+	c.pos = pos
+	c.vel = cameraservices.Position{}
+	c.preset = ""
+	return nil
+}
+
+func (c *Camera) GoToPreset(ctx context.Context, preset string) error {
+	if err := c.command(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pos, ok := c.presets[preset]
+	if !ok {
+		return fmt.Errorf("no preset %q", preset)
+	}
+
+	c.advance(time.Now())
+	c.pos = pos
+	c.vel = cameraservices.Position{}
+	c.preset = preset
+	return nil
+}
+
+func (c *Camera) SetPreset(ctx context.Context, preset string) error {
+	if err := c.command(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(time.Now())
+	c.presets[preset] = c.pos
+	c.preset = preset
+	return nil
+}
+
+// Reboot stops the camera and returns it to its home position.
+// Commands fail with ErrRebooting until the reboot time has passed.
+func (c *Camera) Reboot(ctx context.Context) error {
+	if err := c.command(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.pos = home()
+	c.vel = cameraservices.Position{}
+	c.updated = now
+	c.preset = ""
+	c.rebootUntil = now.Add(c.rebootTime)
+	return nil
+}
+
+func (c *Camera) Status(ctx context.Context) (cameraservices.Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(c.rebootUntil) {
+		return cameraservices.Status{Power: cameraservices.PowerStandby}, nil
+	}
+
+	c.advance(now)
+	pos := c.pos
+	temp := 40.0
+
+	return cameraservices.Status{
+		Power:       cameraservices.PowerOn,
+		Model:       "Simulator",
+		Firmware:    "1.0.0",
+		Serial:      c.addr,
+		Position:    &pos,
+		Temperature: &temp,
+	}, nil
+}
+
+func (c *Camera) Limits() map[string]map[string]cameraservices.Limits {
+	return map[string]map[string]cameraservices.Limits{
+		cameraservices.CapabilitySpeed: {
+			"pan":  {Min: _speedMin, Max: _speedMax},
+			"tilt": {Min: _speedMin, Max: _speedMax},
+			"zoom": {Min: _speedMin, Max: _speedMax},
+		},
+		cameraservices.CapabilityAbsolutePosition: {
+			"pan":  {Min: PanMin, Max: PanMax},
+			"tilt": {Min: TiltMin, Max: TiltMax},
+			"zoom": {Min: ZoomMin, Max: ZoomMax},
+		},
+	}
+}
+
+// setVelocity updates the velocity of each axis that isn't nil.
+func (c *Camera) setVelocity(ctx context.Context, pan, tilt, zoom *float64) error {
+	if err := c.command(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(time.Now())
+
+	if pan != nil {
+		c.vel.Pan = *pan
+	}
+
+	if tilt != nil {
+		c.vel.Tilt = *tilt
+	}
+
+	if zoom != nil {
+		c.vel.Zoom = *zoom
+	}
+
+	if c.vel != (cameraservices.Position{}) {
+		c.preset = ""
+	}
+
+	return nil
+}
+
+// command simulates sending a command to the camera, waiting for the latency
+// and then failing if the camera is rebooting or the command was chosen to fail.
+func (c *Camera) command(ctx context.Context) error {
+	c.mu.Lock()
+	delay := c.latency
+	if c.jitter > 0 {
+		delay += time.Duration(c.rand.Int63n(int64(c.jitter)))
+	}
+
+	fail := c.failureRate > 0 && c.rand.Float64() < c.failureRate
+	c.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	rebooting := time.Now().Before(c.rebootUntil)
+	c.mu.Unlock()
+
+	switch {
+	case rebooting:
+		return ErrRebooting
+	case fail:
+		return ErrInjectedFailure
+	}
+
+	return nil
+}
+
+// advance moves the camera at its current velocity up until now. c.mu must be held.
+func (c *Camera) advance(now time.Time) {
+	dt := now.Sub(c.updated).Seconds()
+	c.updated = now
+
+	if dt <= 0 {
+		return
+	}
+
+	c.pos.Pan = clamp(c.pos.Pan+c.vel.Pan*dt, PanMin, PanMax)
+	c.pos.Tilt = clamp(c.pos.Tilt+c.vel.Tilt*dt, TiltMin, TiltMax)
+	c.pos.Zoom = clamp(c.pos.Zoom+c.vel.Zoom*dt, ZoomMin, ZoomMax)
+}
+
+func home() cameraservices.Position {
+	return cameraservices.Position{Zoom: ZoomMin}
+}
+
+func checkSpeed(speed int) error {
+	if speed < _speedMin || speed > _speedMax {
+		return fmt.Errorf("speed must be between %d and %d", _speedMin, _speedMax)
+	}
+
+	return nil
+}
+
+func clamp(v, min, max float64) float64 {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+
+	return v
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
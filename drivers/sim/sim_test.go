@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	cam := New("sim-1")
+
+	require.NoError(t, cam.PanRight(ctx))
+	require.NoError(t, cam.ZoomIn(ctx))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, cam.PanTiltStop(ctx))
+	require.NoError(t, cam.ZoomStop(ctx))
+
+	pos, err := cam.Position(ctx)
+	require.NoError(t, err)
+	require.Greater(t, pos.Pan, 0.0)
+	require.Equal(t, 0.0, pos.Tilt)
+	require.Greater(t, pos.Zoom, float64(ZoomMin))
+
+	// stopped, so it shouldn't move anymore
+	time.Sleep(10 * time.Millisecond)
+	again, err := cam.Position(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pos, again)
+
+	require.Error(t, cam.PanTilt(ctx, 101, 0))
+	require.Error(t, cam.SetPosition(ctx, cameraservices.Position{Pan: PanMax + 1, Zoom: ZoomMin}))
+}
+
+func TestPresets(t *testing.T) {
+	ctx := context.Background()
+	cam := New("sim-1")
+
+	home := cameraservices.Position{Pan: 10, Tilt: 5, Zoom: 2}
+	require.NoError(t, cam.SetPosition(ctx, home))
+	require.NoError(t, cam.SetPreset(ctx, "1"))
+
+	require.NoError(t, cam.SetPosition(ctx, cameraservices.Position{Pan: -20, Tilt: 0, Zoom: 1}))
+	require.NoError(t, cam.GoToPreset(ctx, "1"))
+
+	pos, err := cam.Position(ctx)
+	require.NoError(t, err)
+	require.Equal(t, home, pos)
+	require.Equal(t, "1", cam.preset)
+
+	require.NoError(t, cam.TiltUp(ctx))
+	require.Equal(t, "", cam.preset)
+
+	require.Error(t, cam.GoToPreset(ctx, "2"))
+}
+
+func TestFailures(t *testing.T) {
+	cam := New("sim-1", WithFailureRate(1))
+	require.Equal(t, ErrInjectedFailure, cam.PanLeft(context.Background()))
+
+	cam = New("sim-1", WithLatency(time.Second, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, context.DeadlineExceeded, cam.PanLeft(ctx))
+}
+
+func TestReboot(t *testing.T) {
+	ctx := context.Background()
+	cam := New("sim-1", WithRebootTime(50*time.Millisecond))
+
+	require.NoError(t, cam.SetPosition(ctx, cameraservices.Position{Pan: 10, Tilt: 5, Zoom: 2}))
+	require.NoError(t, cam.Reboot(ctx))
+	require.Equal(t, ErrRebooting, cam.PanLeft(ctx))
+
+	status, err := cam.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cameraservices.PowerStandby, status.Power)
+
+	time.Sleep(60 * time.Millisecond)
+
+	status, err = cam.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cameraservices.PowerOn, status.Power)
+	require.Equal(t, home(), *status.Position)
+}
+
+func TestStream(t *testing.T) {
+	cam := New("sim-1", WithFrameInterval(time.Millisecond), WithFrameSize(160, 90))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	jpegs, _, err := cam.StreamJPEG(ctx)
+	require.NoError(t, err)
+
+	first := <-jpegs
+	img, err := jpeg.Decode(bytes.NewReader(first))
+	require.NoError(t, err)
+	require.Equal(t, 160, img.Bounds().Dx())
+	require.Equal(t, 90, img.Bounds().Dy())
+
+	// frames should change when the camera moves
+	require.NoError(t, cam.SetPosition(ctx, cameraservices.Position{Pan: 45, Tilt: 10, Zoom: 3}))
+	<-jpegs
+	require.NotEqual(t, first, <-jpegs)
+}
+
+func TestCapabilities(t *testing.T) {
+	caps := cameraservices.CapabilitiesOf(New("sim-1"))
+
+	for _, name := range []string{
+		cameraservices.CapabilityAdmin,
+		cameraservices.CapabilityJPEGStream,
+		cameraservices.CapabilitySnapshot,
+		cameraservices.CapabilitySpeed,
+		cameraservices.CapabilityAbsolutePosition,
+		cameraservices.CapabilityStatus,
+	} {
+		require.True(t, caps[name].Supported, name)
+	}
+
+	require.Equal(t, cameraservices.Limits{Min: TiltMin, Max: TiltMax}, caps[cameraservices.CapabilityAbsolutePosition].Limits["tilt"])
+}
@@ -0,0 +1,39 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	"github.com/byuoitav/camera-services/drivers/jpegstream"
+)
+
+// StreamJPEG renders a frame every frame interval.
+func (c *Camera) StreamJPEG(ctx context.Context) (chan []byte, chan error, error) {
+	return jpegstream.Poll(ctx, c.frameInterval, c.frame)
+}
+
+func (c *Camera) Stream(ctx context.Context) (chan image.Image, chan error, error) {
+	jpegs, errs, err := c.StreamJPEG(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images, errs := jpegstream.Decode(jpegs, errs)
+	return images, errs, nil
+}
+
+func (c *Camera) Snapshot(ctx context.Context) (image.Image, error) {
+	return c.render(), nil
+}
+
+func (c *Camera) frame(ctx context.Context) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, c.render(), nil); err != nil {
+		return nil, fmt.Errorf("unable to encode frame: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
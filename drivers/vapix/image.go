@@ -0,0 +1,167 @@
+package vapix
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const (
+	_focusSpeed = 50
+
+	_irisMin       = 1
+	_irisMax       = 9999
+	_brightnessMin = 1
+	_brightnessMax = 9999
+
+	_paramWhiteBalance = "ImageSource.I0.Sensor.WhiteBalance"
+)
+
+func (c *P5414E) SetFocusMode(ctx context.Context, mode string) error {
+	return setFocusMode(ctx, c.Address, mode)
+}
+
+func (c *P5414E) FocusNear(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", strconv.Itoa(-_focusSpeed))
+}
+
+func (c *P5414E) FocusFar(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", strconv.Itoa(_focusSpeed))
+}
+
+func (c *P5414E) FocusStop(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", "0")
+}
+
+func (c *P5414E) SetExposureMode(ctx context.Context, mode string) error {
+	return setExposureMode(ctx, c.Address, mode)
+}
+
+func (c *P5414E) SetIris(ctx context.Context, level int) error {
+	return setLevel(ctx, c.Address, "iris", level, _irisMin, _irisMax)
+}
+
+func (c *P5414E) SetBrightnessCompensation(ctx context.Context, level int) error {
+	return setLevel(ctx, c.Address, "brightness", level, _brightnessMin, _brightnessMax)
+}
+
+func (c *P5414E) SetWhiteBalance(ctx context.Context, mode string) error {
+	return setWhiteBalance(ctx, c.Address, mode)
+}
+
+func (c *V5915) SetFocusMode(ctx context.Context, mode string) error {
+	return setFocusMode(ctx, c.Address, mode)
+}
+
+func (c *V5915) FocusNear(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", strconv.Itoa(-_focusSpeed))
+}
+
+func (c *V5915) FocusFar(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", strconv.Itoa(_focusSpeed))
+}
+
+func (c *V5915) FocusStop(ctx context.Context) error {
+	return ptz(ctx, c.Address, "continuousfocusmove", "0")
+}
+
+func (c *V5915) SetExposureMode(ctx context.Context, mode string) error {
+	return setExposureMode(ctx, c.Address, mode)
+}
+
+func (c *V5915) SetIris(ctx context.Context, level int) error {
+	return setLevel(ctx, c.Address, "iris", level, _irisMin, _irisMax)
+}
+
+func (c *V5915) SetBrightnessCompensation(ctx context.Context, level int) error {
+	return setLevel(ctx, c.Address, "brightness", level, _brightnessMin, _brightnessMax)
+}
+
+func (c *V5915) SetWhiteBalance(ctx context.Context, mode string) error {
+	return setWhiteBalance(ctx, c.Address, mode)
+}
+
+func setFocusMode(ctx context.Context, addr, mode string) error {
+	switch mode {
+	case cameraservices.ModeAuto:
+		return ptz(ctx, addr, "autofocus", "on")
+	case cameraservices.ModeManual:
+		return ptz(ctx, addr, "autofocus", "off")
+	default:
+		return fmt.Errorf("invalid focus mode %q", mode)
+	}
+}
+
+func setExposureMode(ctx context.Context, addr, mode string) error {
+	switch mode {
+	case cameraservices.ModeAuto:
+		return ptz(ctx, addr, "autoiris", "on")
+	case cameraservices.ModeManual:
+		return ptz(ctx, addr, "autoiris", "off")
+	default:
+		return fmt.Errorf("invalid exposure mode %q", mode)
+	}
+}
+
+func setLevel(ctx context.Context, addr, key string, level, min, max int) error {
+	if level < min || level > max {
+		return fmt.Errorf("%s must be between %d and %d", key, min, max)
+	}
+
+	return ptz(ctx, addr, key, strconv.Itoa(level))
+}
+
+// setWhiteBalance updates the white balance parameter. One push white balance
+// is done by holding the white balance that auto white balance is currently using.
+func setWhiteBalance(ctx context.Context, addr, mode string) error {
+	var value string
+	switch mode {
+	case cameraservices.WhiteBalanceAuto:
+		value = "auto"
+	case cameraservices.WhiteBalanceIndoor:
+		value = "fixed_indoor"
+	case cameraservices.WhiteBalanceOutdoor:
+		value = "fixed_outdoor1"
+	case cameraservices.WhiteBalanceOnePush:
+		value = "hold"
+	default:
+		return fmt.Errorf("invalid white balance mode %q", mode)
+	}
+
+	return do(ctx, addr, _paramEndpoint, url.Values{
+		"action":           {"update"},
+		_paramWhiteBalance: {value},
+	})
+}
+
+func ptz(ctx context.Context, addr, key, value string) error {
+	return do(ctx, addr, _ptzEndpoint, url.Values{
+		key: {value},
+	})
+}
+
+// do makes a VAPIX request that doesn't return anything.
+func do(ctx context.Context, addr, endpoint string, values url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", addr, endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.URL.RawQuery = values.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("request failed: %d response from camera", resp.StatusCode)
+	}
+
+	return nil
+}
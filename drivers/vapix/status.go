@@ -0,0 +1,117 @@
+package vapix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const (
+	_paramEndpoint       = "/axis-cgi/param.cgi"
+	_ptzEndpoint         = "/axis-cgi/com/ptz.cgi"
+	_temperatureEndpoint = "/axis-cgi/temperaturecontrol.cgi"
+
+	_paramModel    = "root.Brand.ProdNbr"
+	_paramFirmware = "root.Properties.Firmware.Version"
+	_paramSerial   = "root.Properties.System.SerialNumber"
+)
+
+func (c *P5414E) Status(ctx context.Context) (cameraservices.Status, error) {
+	return status(ctx, c.Address)
+}
+
+func (c *V5915) Status(ctx context.Context) (cameraservices.Status, error) {
+	return status(ctx, c.Address)
+}
+
+// status gets the state of the camera at addr using VAPIX.
+// Axis cameras have no standby mode, so a camera that responds is always on.
+func status(ctx context.Context, addr string) (cameraservices.Status, error) {
+	var status cameraservices.Status
+
+	params, err := get(ctx, addr, _paramEndpoint, url.Values{
+		"action": {"list"},
+		"group":  {strings.Join([]string{_paramModel, _paramFirmware, _paramSerial}, ",")},
+	})
+	if err != nil {
+		return status, fmt.Errorf("unable to get params: %w", err)
+	}
+
+	status.Power = cameraservices.PowerOn
+	status.Model = params[_paramModel]
+	status.Firmware = params[_paramFirmware]
+	status.Serial = params[_paramSerial]
+
+	pos, err := get(ctx, addr, _ptzEndpoint, url.Values{
+		"query": {"position"},
+	})
+	if err != nil {
+		return status, fmt.Errorf("unable to get position: %w", err)
+	}
+
+	status.Position = &cameraservices.Position{}
+	status.Position.Pan, _ = strconv.ParseFloat(pos["pan"], 64)
+	status.Position.Tilt, _ = strconv.ParseFloat(pos["tilt"], 64)
+	status.Position.Zoom, _ = strconv.ParseFloat(pos["zoom"], 64)
+
+	// not every model has a temperature sensor
+	temps, err := get(ctx, addr, _temperatureEndpoint, url.Values{
+		"action": {"statusall"},
+	})
+	if err == nil {
+		if temp, err := strconv.ParseFloat(temps["Sensor.S0.Celsius"], 64); err == nil {
+			status.Temperature = &temp
+		}
+	}
+
+	return status, nil
+}
+
+// get makes a VAPIX request and parses the key=value lines in the response.
+func get(ctx context.Context, addr, endpoint string, values url.Values) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", addr, endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.URL.RawQuery = values.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("request failed: %d response from camera", resp.StatusCode)
+	}
+
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		split := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+
+		res[split[0]] = split[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+
+	// vapix reports errors (ie. unknown parameters) as a comment with a 200 response
+	if len(res) == 0 {
+		return nil, errors.New("no values in response from camera")
+	}
+
+	return res, nil
+}
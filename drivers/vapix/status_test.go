@@ -0,0 +1,38 @@
+package vapix
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case _paramEndpoint:
+			_, _ = w.Write([]byte("root.Brand.ProdNbr=P5414-E\nroot.Properties.Firmware.Version=6.50.1\nroot.Properties.System.SerialNumber=ACCC8E000000\n"))
+		case _ptzEndpoint:
+			_, _ = w.Write([]byte("pan=-12.5\ntilt=3\nzoom=100\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cam := NewP5414E(strings.TrimPrefix(server.URL, "http://"), "")
+
+	status, err := cam.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, cameraservices.Status{
+		Power:    cameraservices.PowerOn,
+		Model:    "P5414-E",
+		Firmware: "6.50.1",
+		Serial:   "ACCC8E000000",
+		Position: &cameraservices.Position{Pan: -12.5, Tilt: 3, Zoom: 100},
+	}, status)
+}
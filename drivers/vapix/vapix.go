@@ -0,0 +1,62 @@
+// Package vapix adds the optional camera-services capabilities to the Axis (VAPIX) camera drivers.
+package vapix
+
+import (
+	"github.com/byuoitav/axis"
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const (
+	_speedMin = -100
+	_speedMax = 100
+)
+
+type P5414E struct {
+	*axis.P5414E
+}
+
+type V5915 struct {
+	*axis.V5915
+}
+
+// NewP5414E creates a P5414-E at addr, streaming with the given stream profile.
+func NewP5414E(addr, streamProfile string) *P5414E {
+	return &P5414E{
+		P5414E: &axis.P5414E{
+			Address:       addr,
+			StreamProfile: streamProfile,
+		},
+	}
+}
+
+// NewV5915 creates a V5915 at addr, streaming with the given stream profile.
+func NewV5915(addr, streamProfile string) *V5915 {
+	return &V5915{
+		V5915: &axis.V5915{
+			Address:       addr,
+			StreamProfile: streamProfile,
+		},
+	}
+}
+
+func (c *P5414E) Limits() map[string]map[string]cameraservices.Limits {
+	return limits()
+}
+
+func (c *V5915) Limits() map[string]map[string]cameraservices.Limits {
+	return limits()
+}
+
+func limits() map[string]map[string]cameraservices.Limits {
+	return map[string]map[string]cameraservices.Limits{
+		cameraservices.CapabilitySpeed: {
+			"pan":  {Min: _speedMin, Max: _speedMax},
+			"tilt": {Min: _speedMin, Max: _speedMax},
+			"zoom": {Min: _speedMin, Max: _speedMax},
+		},
+		cameraservices.CapabilityExposure: {
+			"iris":       {Min: _irisMin, Max: _irisMax},
+			"brightness": {Min: _brightnessMin, Max: _brightnessMax},
+		},
+	}
+}
@@ -0,0 +1,190 @@
+// Package viscacam is a driver for any PTZ camera that speaks VISCA-over-IP (Sony, PTZOptics, Lumens, ...).
+// Video comes from a configurable MJPEG stream or snapshot url.
+package viscacam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/viscaip"
+	"github.com/byuoitav/visca"
+)
+
+const (
+	_presetMin = 0x00
+	_presetMax = 0x7f
+)
+
+// ErrNoVideo is returned when streaming from a camera without a stream or snapshot url.
+var ErrNoVideo = errors.New("no stream or snapshot url configured")
+
+type Camera struct {
+	cam  *visca.Camera
+	raw  *viscaip.Client
+	host string
+
+	streamURL     string
+	snapshotURL   string
+	frameInterval time.Duration
+	panSpeed      byte
+	tiltSpeed     byte
+	powerOnDelay  time.Duration
+	client        *http.Client
+}
+
+// New creates a camera at addr (host:port of the camera's VISCA port).
+func New(addr string, opts ...Option) (*Camera, error) {
+	options := options{
+		frameInterval: _defaultFrameInterval,
+		panSpeed:      _defaultPanSpeed,
+		tiltSpeed:     _defaultTiltSpeed,
+		powerOnDelay:  _defaultPowerOnDelay,
+		client:        http.DefaultClient,
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	host := addr
+	if strings.Contains(host, ":") {
+		var err error
+		if host, _, err = net.SplitHostPort(host); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Camera{
+		cam:           visca.New(addr, options.viscaOpts...),
+		raw:           &viscaip.Client{Address: addr},
+		host:          host,
+		streamURL:     options.streamURL,
+		snapshotURL:   options.snapshotURL,
+		frameInterval: options.frameInterval,
+		panSpeed:      options.panSpeed,
+		tiltSpeed:     options.tiltSpeed,
+		powerOnDelay:  options.powerOnDelay,
+		client:        options.client,
+	}, nil
+}
+
+func (c *Camera) RemoteAddr() string {
+	return c.host
+}
+
+func (c *Camera) TiltUp(ctx context.Context) error {
+	return c.cam.TiltUp(ctx, c.tiltSpeed)
+}
+
+func (c *Camera) TiltDown(ctx context.Context) error {
+	return c.cam.TiltDown(ctx, c.tiltSpeed)
+}
+
+func (c *Camera) PanLeft(ctx context.Context) error {
+	return c.cam.PanLeft(ctx, c.panSpeed)
+}
+
+func (c *Camera) PanRight(ctx context.Context) error {
+	return c.cam.PanRight(ctx, c.panSpeed)
+}
+
+func (c *Camera) PanTiltStop(ctx context.Context) error {
+	return c.cam.PanTiltStop(ctx)
+}
+
+func (c *Camera) ZoomIn(ctx context.Context) error {
+	return c.cam.ZoomTele(ctx)
+}
+
+func (c *Camera) ZoomOut(ctx context.Context) error {
+	return c.cam.ZoomWide(ctx)
+}
+
+func (c *Camera) ZoomStop(ctx context.Context) error {
+	return c.cam.ZoomStop(ctx)
+}
+
+func (c *Camera) GoToPreset(ctx context.Context, preset string) error {
+	channel, err := presetChannel(preset)
+	if err != nil {
+		return err
+	}
+
+	return c.cam.MemoryRecall(ctx, channel)
+}
+
+func (c *Camera) SetPreset(ctx context.Context, preset string) error {
+	channel, err := presetChannel(preset)
+	if err != nil {
+		return err
+	}
+
+	return c.cam.MemorySet(ctx, channel)
+}
+
+// Reboot turns the camera off, and then back on after the power on delay.
+// VISCA has no reboot command, so it returns once the camera has acknowledged turning off.
+func (c *Camera) Reboot(ctx context.Context) error {
+	if err := c.raw.Command(ctx, 0x01, 0x04, 0x00, 0x03); err != nil {
+		return fmt.Errorf("unable to turn off camera: %w", err)
+	}
+
+	go func() {
+		time.Sleep(c.powerOnDelay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = c.raw.Command(ctx, 0x01, 0x04, 0x00, 0x02)
+	}()
+
+	return nil
+}
+
+// Status gets the state of the camera using VISCA inquiries.
+// Model is the camera's vendor and model id, because VISCA doesn't report a model name.
+func (c *Camera) Status(ctx context.Context) (cameraservices.Status, error) {
+	status, err := c.raw.Status(ctx)
+	if err != nil || status.Power != cameraservices.PowerOn {
+		return status, err
+	}
+
+	version, err := c.raw.Version(ctx)
+	if err != nil {
+		return status, fmt.Errorf("unable to get version: %w", err)
+	}
+
+	status.Model = fmt.Sprintf("%04x:%04x", version.Vendor, version.Model)
+	return status, nil
+}
+
+func (c *Camera) Limits() map[string]map[string]cameraservices.Limits {
+	return map[string]map[string]cameraservices.Limits{
+		cameraservices.CapabilityPresets: {
+			"preset": {Min: _presetMin, Max: _presetMax},
+		},
+		cameraservices.CapabilityExposure: {
+			"iris":       {Min: viscaip.IrisMin, Max: viscaip.IrisMax},
+			"brightness": {Min: viscaip.BrightnessMin, Max: viscaip.BrightnessMax},
+		},
+	}
+}
+
+func presetChannel(preset string) (byte, error) {
+	channel, err := strconv.Atoi(preset)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("unable to convert preset to channel: %w", err)
+	case channel < _presetMin || channel > _presetMax:
+		return 0, fmt.Errorf("preset must be between %d and %d", _presetMin, _presetMax)
+	}
+
+	return byte(channel), nil
+}
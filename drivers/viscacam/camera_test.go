@@ -0,0 +1,99 @@
+package viscacam
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testJPEG(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil))
+	return buf.Bytes()
+}
+
+func TestSnapshotStream(t *testing.T) {
+	frame := testJPEG(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(frame)
+	}))
+	defer server.Close()
+
+	cam, err := New("127.0.0.1:52381", WithSnapshotURL(server.URL), WithFrameInterval(time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", cam.RemoteAddr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	jpegs, _, err := cam.StreamJPEG(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, frame, <-jpegs)
+	}
+}
+
+func TestMJPEGSnapshot(t *testing.T) {
+	frame := testJPEG(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+m.Boundary())
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "image/jpeg")
+
+		for {
+			part, err := m.CreatePart(header)
+			if err != nil {
+				return
+			}
+
+			if _, err := part.Write(frame); err != nil {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	cam, err := New("127.0.0.1:52381", WithStreamURL(server.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	img, err := cam.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 8, img.Bounds().Dx())
+}
+
+func TestNoVideo(t *testing.T) {
+	cam, err := New("127.0.0.1:52381")
+	require.NoError(t, err)
+
+	_, _, err = cam.StreamJPEG(context.Background())
+	require.Equal(t, ErrNoVideo, err)
+}
+
+func TestPresetRange(t *testing.T) {
+	cam, err := New("127.0.0.1:52381")
+	require.NoError(t, err)
+
+	require.Error(t, cam.GoToPreset(context.Background(), "128"))
+	require.Error(t, cam.SetPreset(context.Background(), "wide"))
+}
@@ -0,0 +1,87 @@
+package viscacam
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/byuoitav/visca"
+)
+
+const (
+	_defaultPanSpeed      = 0x0b
+	_defaultTiltSpeed     = 0x0e
+	_defaultFrameInterval = 125 * time.Millisecond
+	_defaultPowerOnDelay  = 10 * time.Second
+)
+
+type options struct {
+	streamURL     string
+	snapshotURL   string
+	frameInterval time.Duration
+	panSpeed      byte
+	tiltSpeed     byte
+	powerOnDelay  time.Duration
+	client        *http.Client
+	viscaOpts     []visca.Option
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithStreamURL sets the url of the camera's MJPEG stream.
+func WithStreamURL(url string) Option {
+	return optionFunc(func(o *options) {
+		o.streamURL = url
+	})
+}
+
+// WithSnapshotURL sets the url of a single JPEG frame from the camera.
+// It is polled every frame interval to stream if no stream url is set.
+func WithSnapshotURL(url string) Option {
+	return optionFunc(func(o *options) {
+		o.snapshotURL = url
+	})
+}
+
+// WithFrameInterval sets how often the snapshot url is polled while streaming.
+func WithFrameInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.frameInterval = d
+	})
+}
+
+// WithPanTiltSpeed sets the speed (0x01-0x18) the camera pans and tilts at.
+func WithPanTiltSpeed(pan, tilt byte) Option {
+	return optionFunc(func(o *options) {
+		o.panSpeed = pan
+		o.tiltSpeed = tilt
+	})
+}
+
+// WithPowerOnDelay sets how long to wait between turning the camera off and back on when rebooting.
+func WithPowerOnDelay(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.powerOnDelay = d
+	})
+}
+
+// WithHTTPClient sets the client used to get the stream and snapshots.
+func WithHTTPClient(client *http.Client) Option {
+	return optionFunc(func(o *options) {
+		o.client = client
+	})
+}
+
+// WithViscaOptions sets the options used to create the underlying visca camera.
+func WithViscaOptions(opts ...visca.Option) Option {
+	return optionFunc(func(o *options) {
+		o.viscaOpts = append(o.viscaOpts, opts...)
+	})
+}
@@ -0,0 +1,155 @@
+package viscacam
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/byuoitav/camera-services/drivers/jpegstream"
+)
+
+// StreamJPEG streams frames from the stream url, or by polling the snapshot url if there is no stream url.
+func (c *Camera) StreamJPEG(ctx context.Context) (chan []byte, chan error, error) {
+	switch {
+	case c.streamURL != "":
+		return c.streamMJPEG(ctx)
+	case c.snapshotURL != "":
+		return c.pollSnapshots(ctx)
+	default:
+		return nil, nil, ErrNoVideo
+	}
+}
+
+func (c *Camera) Stream(ctx context.Context) (chan image.Image, chan error, error) {
+	jpegs, errs, err := c.StreamJPEG(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images, errs := jpegstream.Decode(jpegs, errs)
+	return images, errs, nil
+}
+
+// Snapshot gets a single frame from the snapshot url, or the first frame of the stream if there is no snapshot url.
+func (c *Camera) Snapshot(ctx context.Context) (image.Image, error) {
+	var jpeg []byte
+	var err error
+
+	switch {
+	case c.snapshotURL != "":
+		jpeg, err = c.snapshot(ctx)
+	case c.streamURL != "":
+		jpeg, err = c.firstFrame(ctx)
+	default:
+		return nil, ErrNoVideo
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	image, _, err := image.Decode(bytes.NewReader(jpeg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image: %w", err)
+	}
+
+	return image, nil
+}
+
+func (c *Camera) firstFrame(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jpegs, errs, err := c.streamMJPEG(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case jpeg := <-jpegs:
+		return jpeg, nil
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Camera) streamMJPEG(ctx context.Context) (chan []byte, chan error, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.streamURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to make request: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("%d response from camera", resp.StatusCode)
+	}
+
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("unable to parse content-type: %w", err)
+	}
+
+	jpegs := make(chan []byte)
+	errs := make(chan error)
+
+	go func() {
+		defer resp.Body.Close()
+		defer close(jpegs)
+		defer close(errs)
+
+		reader := multipart.NewReader(resp.Body, params["boundary"])
+
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				// the stream is over, so there won't be any more frames
+				select {
+				case errs <- fmt.Errorf("unable to read next frame: %w", err):
+				case <-ctx.Done():
+				}
+
+				return
+			}
+
+			jpeg, err := ioutil.ReadAll(part)
+			if err != nil {
+				select {
+				case errs <- fmt.Errorf("unable to read frame: %w", err):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case jpegs <- jpeg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return jpegs, errs, nil
+}
+
+func (c *Camera) pollSnapshots(ctx context.Context) (chan []byte, chan error, error) {
+	return jpegstream.Poll(ctx, c.frameInterval, c.snapshot)
+}
+
+func (c *Camera) snapshot(ctx context.Context) ([]byte, error) {
+	return jpegstream.Get(ctx, c.client, c.snapshotURL, "", "")
+}
@@ -0,0 +1,90 @@
+package viscaip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+const _defaultTimeout = 3 * time.Second
+
+// Client sends VISCA messages to a camera at Address (host:port) over UDP.
+type Client struct {
+	Address string
+	Dialer  net.Dialer
+
+	seq uint32
+}
+
+// Command sends a command to the camera and waits for it to be acknowledged.
+// msg is the VISCA message without the address byte or terminator (ie. 0x01, 0x04, 0x00, 0x02 for power on).
+func (c *Client) Command(ctx context.Context, msg ...byte) error {
+	_, err := c.send(ctx, PayloadTypeCommand, msg, replyAck)
+	return err
+}
+
+// Inquire sends an inquiry to the camera and returns the data in its completion reply.
+// msg is the VISCA message without the address byte or terminator (ie. 0x09, 0x04, 0x00 for power inquiry).
+func (c *Client) Inquire(ctx context.Context, msg ...byte) ([]byte, error) {
+	return c.send(ctx, PayloadTypeInquiry, msg, replyCompletion)
+}
+
+// send sends msg and reads replies until one of kind until (or a completion or error) is received.
+func (c *Client) send(ctx context.Context, typ PayloadType, msg []byte, until replyKind) ([]byte, error) {
+	p := Packet{
+		Type:     typ,
+		Sequence: atomic.AddUint32(&c.seq, 1),
+		Message:  append(append([]byte{0x81}, msg...), _terminator),
+	}
+
+	buf, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.Dialer.DialContext(ctx, "udp", c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to dial: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(_defaultTimeout)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("unable to set deadline: %w", err)
+	}
+
+	if _, err := conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("unable to write packet: %w", err)
+	}
+
+	read := make([]byte, 1024)
+	for {
+		n, err := conn.Read(read)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read reply: %w", err)
+		}
+
+		var reply Packet
+		if err := reply.UnmarshalBinary(read[:n]); err != nil {
+			return nil, err
+		}
+
+		if reply.Type != PayloadTypeReply {
+			continue
+		}
+
+		kind, data, err := parseReply(reply.Message)
+		switch {
+		case err != nil:
+			return nil, err
+		case kind == until || kind == replyCompletion:
+			return data, nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package viscaip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCamera starts a udp server that replies to each message with replies[message].
+func newTestCamera(t *testing.T, replies map[string][][]byte) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var p Packet
+			if err := p.UnmarshalBinary(buf[:n]); err != nil {
+				continue
+			}
+
+			for _, msg := range replies[string(p.Message)] {
+				reply, _ := Packet{Type: PayloadTypeReply, Sequence: p.Sequence, Message: msg}.MarshalBinary()
+				_, _ = conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestInquiries(t *testing.T) {
+	addr := newTestCamera(t, map[string][][]byte{
+		string([]byte{0x81, 0x09, 0x04, 0x00, 0xff}): {{0x90, 0x50, 0x02, 0xff}},
+		string([]byte{0x81, 0x09, 0x00, 0x02, 0xff}): {{0x90, 0x50, 0x00, 0x20, 0x05, 0x20, 0x01, 0x13, 0x02, 0xff}},
+		string([]byte{0x81, 0x09, 0x06, 0x12, 0xff}): {{0x90, 0x50, 0x0f, 0x0f, 0x0f, 0x0e, 0x00, 0x01, 0x00, 0x00, 0xff}},
+		string([]byte{0x81, 0x09, 0x04, 0x47, 0xff}): {{0x90, 0x50, 0x01, 0x00, 0x00, 0x00, 0xff}},
+		string([]byte{0x81, 0x01, 0x04, 0x00, 0x02, 0xff}): {
+			{0x90, 0x41, 0xff},
+			{0x90, 0x51, 0xff},
+		},
+		string([]byte{0x81, 0x01, 0x04, 0x00, 0x05, 0xff}): {{0x90, 0x60, 0x02, 0xff}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c := &Client{Address: addr}
+
+	on, err := c.Power(ctx)
+	require.NoError(t, err)
+	require.True(t, on)
+
+	version, err := c.Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Version{Vendor: 0x0020, Model: 0x0520, ROM: 0x0113}, version)
+
+	pan, tilt, err := c.PanTiltPosition(ctx)
+	require.NoError(t, err)
+	require.Equal(t, -2, pan)
+	require.Equal(t, 0x100, tilt)
+
+	zoom, err := c.ZoomPosition(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0x1000, zoom)
+
+	require.NoError(t, c.Command(ctx, 0x01, 0x04, 0x00, 0x02))
+	require.True(t, errors.Is(c.Command(ctx, 0x01, 0x04, 0x00, 0x05), ErrSyntax))
+}
+
+func TestUnmarshalBadLength(t *testing.T) {
+	// aver cameras send a length of 1
+	var p Packet
+	require.NoError(t, p.UnmarshalBinary([]byte{0x01, 0x11, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x90, 0x50, 0x02, 0xff}))
+	require.Equal(t, PayloadTypeReply, p.Type)
+	require.True(t, bytes.Equal([]byte{0x90, 0x50, 0x02, 0xff}, p.Message))
+}
@@ -0,0 +1,94 @@
+package viscaip
+
+import (
+	"context"
+	"fmt"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// The ranges of the iris and brightness compensation levels.
+const (
+	IrisMin       = 0x00
+	IrisMax       = 0x11
+	BrightnessMin = 0x00
+	BrightnessMax = 0x0e
+)
+
+func (c *Client) SetFocusMode(ctx context.Context, mode string) error {
+	switch mode {
+	case cameraservices.ModeAuto:
+		return c.Command(ctx, 0x01, 0x04, 0x38, 0x02)
+	case cameraservices.ModeManual:
+		return c.Command(ctx, 0x01, 0x04, 0x38, 0x03)
+	default:
+		return fmt.Errorf("invalid focus mode %q", mode)
+	}
+}
+
+func (c *Client) FocusNear(ctx context.Context) error {
+	return c.Command(ctx, 0x01, 0x04, 0x08, 0x03)
+}
+
+func (c *Client) FocusFar(ctx context.Context) error {
+	return c.Command(ctx, 0x01, 0x04, 0x08, 0x02)
+}
+
+func (c *Client) FocusStop(ctx context.Context) error {
+	return c.Command(ctx, 0x01, 0x04, 0x08, 0x00)
+}
+
+func (c *Client) OnePushFocus(ctx context.Context) error {
+	return c.Command(ctx, 0x01, 0x04, 0x18, 0x01)
+}
+
+func (c *Client) SetExposureMode(ctx context.Context, mode string) error {
+	switch mode {
+	case cameraservices.ModeAuto:
+		return c.Command(ctx, 0x01, 0x04, 0x39, 0x00)
+	case cameraservices.ModeManual:
+		return c.Command(ctx, 0x01, 0x04, 0x39, 0x03)
+	default:
+		return fmt.Errorf("invalid exposure mode %q", mode)
+	}
+}
+
+func (c *Client) SetIris(ctx context.Context, level int) error {
+	if level < IrisMin || level > IrisMax {
+		return fmt.Errorf("iris must be between %d and %d", IrisMin, IrisMax)
+	}
+
+	return c.Command(ctx, 0x01, 0x04, 0x4b, 0x00, 0x00, byte(level>>4), byte(level&0x0f))
+}
+
+// SetBrightnessCompensation turns on exposure compensation and sets it to level. 7 is no compensation.
+func (c *Client) SetBrightnessCompensation(ctx context.Context, level int) error {
+	if level < BrightnessMin || level > BrightnessMax {
+		return fmt.Errorf("brightness compensation must be between %d and %d", BrightnessMin, BrightnessMax)
+	}
+
+	if err := c.Command(ctx, 0x01, 0x04, 0x3e, 0x02); err != nil {
+		return fmt.Errorf("unable to turn on exposure compensation: %w", err)
+	}
+
+	return c.Command(ctx, 0x01, 0x04, 0x4e, 0x00, 0x00, byte(level>>4), byte(level&0x0f))
+}
+
+func (c *Client) SetWhiteBalance(ctx context.Context, mode string) error {
+	switch mode {
+	case cameraservices.WhiteBalanceAuto:
+		return c.Command(ctx, 0x01, 0x04, 0x35, 0x00)
+	case cameraservices.WhiteBalanceIndoor:
+		return c.Command(ctx, 0x01, 0x04, 0x35, 0x01)
+	case cameraservices.WhiteBalanceOutdoor:
+		return c.Command(ctx, 0x01, 0x04, 0x35, 0x02)
+	case cameraservices.WhiteBalanceOnePush:
+		if err := c.Command(ctx, 0x01, 0x04, 0x35, 0x03); err != nil {
+			return err
+		}
+
+		return c.Command(ctx, 0x01, 0x04, 0x10, 0x05)
+	default:
+		return fmt.Errorf("invalid white balance mode %q", mode)
+	}
+}
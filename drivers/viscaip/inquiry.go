@@ -0,0 +1,91 @@
+package viscaip
+
+import (
+	"context"
+	"fmt"
+)
+
+// Version is the reply to a CAM_VersionInq.
+type Version struct {
+	Vendor uint16
+	Model  uint16
+	ROM    uint16
+}
+
+// Power returns true if the camera is on, and false if it is in standby.
+func (c *Client) Power(ctx context.Context) (bool, error) {
+	data, err := c.Inquire(ctx, 0x09, 0x04, 0x00)
+	switch {
+	case err != nil:
+		return false, err
+	case len(data) != 1:
+		return false, fmt.Errorf("invalid power reply: %# x", data)
+	}
+
+	return data[0] == 0x02, nil
+}
+
+// Version returns the vendor, model, and ROM version of the camera.
+func (c *Client) Version(ctx context.Context) (Version, error) {
+	data, err := c.Inquire(ctx, 0x09, 0x00, 0x02)
+	switch {
+	case err != nil:
+		return Version{}, err
+	case len(data) < 6:
+		return Version{}, fmt.Errorf("invalid version reply: %# x", data)
+	}
+
+	return Version{
+		Vendor: uint16(data[0])<<8 | uint16(data[1]),
+		Model:  uint16(data[2])<<8 | uint16(data[3]),
+		ROM:    uint16(data[4])<<8 | uint16(data[5]),
+	}, nil
+}
+
+// PanTiltPosition returns the absolute pan and tilt position of the camera.
+func (c *Client) PanTiltPosition(ctx context.Context) (int, int, error) {
+	data, err := c.Inquire(ctx, 0x09, 0x06, 0x12)
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case len(data) == 0 || len(data)%2 != 0:
+		return 0, 0, fmt.Errorf("invalid pan/tilt position reply: %# x", data)
+	}
+
+	// cameras with a wider pan range use 5 nibbles for each value instead of 4
+	half := len(data) / 2
+	return signed(nibbles(data[:half]), half), signed(nibbles(data[half:]), half), nil
+}
+
+// ZoomPosition returns the absolute zoom position of the camera.
+func (c *Client) ZoomPosition(ctx context.Context) (int, error) {
+	data, err := c.Inquire(ctx, 0x09, 0x04, 0x47)
+	switch {
+	case err != nil:
+		return 0, err
+	case len(data) != 4:
+		return 0, fmt.Errorf("invalid zoom position reply: %# x", data)
+	}
+
+	return nibbles(data), nil
+}
+
+// nibbles combines the low nibble of each byte in b into a single value.
+func nibbles(b []byte) int {
+	var v int
+	for _, n := range b {
+		v = v<<4 | int(n&0x0f)
+	}
+
+	return v
+}
+
+// signed interprets v as a two's complement number with count nibbles.
+func signed(v, count int) int {
+	bits := uint(count * 4)
+	if v&(1<<(bits-1)) != 0 {
+		v -= 1 << bits
+	}
+
+	return v
+}
@@ -0,0 +1,125 @@
+// Package viscaip is a minimal VISCA-over-IP client, used for the commands and
+// inquiries that github.com/byuoitav/visca doesn't expose.
+package viscaip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PayloadType is the type of a VISCA-over-IP packet.
+type PayloadType [2]byte
+
+var (
+	PayloadTypeCommand = PayloadType{0x01, 0x00}
+	PayloadTypeInquiry = PayloadType{0x01, 0x10}
+	PayloadTypeReply   = PayloadType{0x01, 0x11}
+)
+
+const (
+	_headerLength = 8
+	_terminator   = 0xff
+)
+
+// Errors returned by the camera in reply to a message.
+var (
+	ErrMessageLength = errors.New("message length error")
+	ErrSyntax        = errors.New("syntax error")
+	ErrBufferFull    = errors.New("command buffer full")
+	ErrCanceled      = errors.New("command canceled")
+	ErrNoSocket      = errors.New("no socket")
+	ErrNotExecutable = errors.New("command not executable")
+)
+
+// Packet is a single VISCA-over-IP packet.
+// Message is the whole VISCA message, including the address byte and terminator.
+type Packet struct {
+	Type     PayloadType
+	Sequence uint32
+	Message  []byte
+}
+
+func (p Packet) MarshalBinary() ([]byte, error) {
+	if len(p.Message) > 0xffff {
+		return nil, fmt.Errorf("message too long: %d bytes", len(p.Message))
+	}
+
+	buf := make([]byte, _headerLength, _headerLength+len(p.Message))
+	buf[0] = p.Type[0]
+	buf[1] = p.Type[1]
+	binary.BigEndian.PutUint16(buf[2:4], uint16(len(p.Message)))
+	binary.BigEndian.PutUint32(buf[4:8], p.Sequence)
+
+	return append(buf, p.Message...), nil
+}
+
+func (p *Packet) UnmarshalBinary(data []byte) error {
+	if len(data) < _headerLength+1 {
+		return fmt.Errorf("packet too short: %# x", data)
+	}
+
+	p.Type = PayloadType{data[0], data[1]}
+	p.Sequence = binary.BigEndian.Uint32(data[4:8])
+
+	// some cameras (aver) don't send the correct length,
+	// so the message is read up to the terminator instead
+	msg := data[_headerLength:]
+	for i, b := range msg {
+		if b == _terminator {
+			p.Message = append([]byte(nil), msg[:i+1]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("packet missing terminator: %# x", data)
+}
+
+// replyKind is the kind of reply a VISCA message is.
+type replyKind int
+
+const (
+	replyUnknown replyKind = iota
+	replyAck
+	replyCompletion
+	replyError
+)
+
+// parseReply returns the kind of reply msg is and its data (the bytes between the reply type and the terminator).
+func parseReply(msg []byte) (replyKind, []byte, error) {
+	if len(msg) < 3 || msg[0]&0xf0 != 0x90 {
+		return replyUnknown, nil, fmt.Errorf("invalid reply: %# x", msg)
+	}
+
+	data := msg[2 : len(msg)-1]
+
+	switch msg[1] & 0xf0 {
+	case 0x40:
+		return replyAck, data, nil
+	case 0x50:
+		return replyCompletion, data, nil
+	case 0x60:
+		if len(data) == 0 {
+			return replyError, data, fmt.Errorf("invalid error reply: %# x", msg)
+		}
+
+		switch data[0] {
+		case 0x01:
+			return replyError, data, ErrMessageLength
+		case 0x02:
+			return replyError, data, ErrSyntax
+		case 0x03:
+			return replyError, data, ErrBufferFull
+		case 0x04:
+			return replyError, data, ErrCanceled
+		case 0x05:
+			return replyError, data, ErrNoSocket
+		case 0x41:
+			return replyError, data, ErrNotExecutable
+		default:
+			return replyError, data, fmt.Errorf("unknown error: %# x", data[0])
+		}
+	default:
+		return replyUnknown, nil, fmt.Errorf("unknown reply: %# x", msg)
+	}
+}
@@ -0,0 +1,51 @@
+package viscaip
+
+import (
+	"context"
+	"fmt"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// Status gets the power state, firmware version, and position of the camera.
+// The firmware version and position are only included if the camera is on.
+func (c *Client) Status(ctx context.Context) (cameraservices.Status, error) {
+	var status cameraservices.Status
+
+	on, err := c.Power(ctx)
+	if err != nil {
+		return status, fmt.Errorf("unable to get power: %w", err)
+	}
+
+	status.Power = cameraservices.PowerStandby
+	if !on {
+		return status, nil
+	}
+
+	status.Power = cameraservices.PowerOn
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		return status, fmt.Errorf("unable to get version: %w", err)
+	}
+
+	status.Firmware = fmt.Sprintf("%04x", version.ROM)
+
+	pan, tilt, err := c.PanTiltPosition(ctx)
+	if err != nil {
+		return status, fmt.Errorf("unable to get pan/tilt position: %w", err)
+	}
+
+	zoom, err := c.ZoomPosition(ctx)
+	if err != nil {
+		return status, fmt.Errorf("unable to get zoom position: %w", err)
+	}
+
+	status.Position = &cameraservices.Position{
+		Pan:  float64(pan),
+		Tilt: float64(tilt),
+		Zoom: float64(zoom),
+	}
+
+	return status, nil
+}
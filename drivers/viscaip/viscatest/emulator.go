@@ -0,0 +1,321 @@
+// Package viscatest provides a VISCA-over-IP camera emulator for tests.
+// It accepts the commands and inquiries the VISCA drivers use over UDP and TCP,
+// tracks the camera's state, and can be scripted to reply with errors or not reply at all.
+package viscatest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/byuoitav/camera-services/drivers/viscaip"
+)
+
+// The error codes the emulator can reply with.
+const (
+	ErrorMessageLength = 0x01
+	ErrorSyntax        = 0x02
+	ErrorBufferFull    = 0x03
+	ErrorCanceled      = 0x04
+	ErrorNoSocket      = 0x05
+	ErrorNotExecutable = 0x41
+)
+
+// Fault makes the emulator fail messages instead of handling them.
+type Fault struct {
+	// Match is the start of the messages to fail, without the address byte
+	// (ie. 0x01, 0x06, 0x01 for pan/tilt drive). An empty Match fails every message.
+	Match []byte
+
+	// Error is the error code to reply with. If it is 0, no reply is sent, so the client times out.
+	Error byte
+
+	// Count is how many messages to fail. If it is 0, messages fail until ClearFaults is called.
+	Count int
+}
+
+type Emulator struct {
+	completions bool
+	shortLength bool
+
+	mu       sync.Mutex
+	state    State
+	faults   []Fault
+	messages [][]byte
+
+	closeOnce sync.Once
+	closers   []func() error
+	wg        sync.WaitGroup
+}
+
+// New creates an emulator. It doesn't do anything until ListenUDP or ListenTCP is called.
+func New(opts ...Option) *Emulator {
+	options := options{}
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	e := &Emulator{
+		completions: options.completions,
+		shortLength: options.shortLength,
+		state:       newState(),
+	}
+
+	if options.state != nil {
+		options.state(&e.state)
+	}
+
+	return e
+}
+
+// ListenUDP serves VISCA-over-IP on addr (ie. 127.0.0.1:0), returning the address it is listening on.
+func (e *Emulator) ListenUDP(addr string) (string, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	e.mu.Lock()
+	e.closers = append(e.closers, conn.Close)
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		buf := make([]byte, 1024)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var p viscaip.Packet
+			if err := p.UnmarshalBinary(buf[:n]); err != nil {
+				continue
+			}
+
+			for _, reply := range e.handle(p) {
+				_, _ = conn.WriteTo(reply, from)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), nil
+}
+
+// ListenTCP serves VISCA-over-IP on addr (ie. 127.0.0.1:0), returning the address it is listening on.
+func (e *Emulator) ListenTCP(addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	e.mu.Lock()
+	e.closers = append(e.closers, lis.Close)
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			e.mu.Lock()
+			e.closers = append(e.closers, conn.Close)
+			e.mu.Unlock()
+
+			e.wg.Add(1)
+			go func() {
+				defer e.wg.Done()
+				defer conn.Close()
+
+				e.serveTCP(conn)
+			}()
+		}
+	}()
+
+	return lis.Addr().String(), nil
+}
+
+func (e *Emulator) serveTCP(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return
+		}
+
+		msg, err := r.ReadBytes(0xff)
+		if err != nil {
+			return
+		}
+
+		var p viscaip.Packet
+		if err := p.UnmarshalBinary(append(append([]byte(nil), header...), msg...)); err != nil {
+			continue
+		}
+
+		for _, reply := range e.handle(p) {
+			if _, err := conn.Write(reply); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close stops the emulator and closes every connection.
+func (e *Emulator) Close() error {
+	var err error
+	e.closeOnce.Do(func() {
+		e.mu.Lock()
+		closers := e.closers
+		e.mu.Unlock()
+
+		for _, close := range closers {
+			if cerr := close(); cerr != nil && !errors.Is(cerr, net.ErrClosed) && err == nil {
+				err = cerr
+			}
+		}
+
+		e.wg.Wait()
+	})
+
+	return err
+}
+
+// State returns the current state of the camera.
+func (e *Emulator) State() State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.state.advance(time.Now())
+	return e.state.copy()
+}
+
+// SetState calls f with the camera's state, so that it can be changed.
+func (e *Emulator) SetState(f func(*State)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.state.advance(time.Now())
+	f(&e.state)
+}
+
+// Messages returns every message the emulator has received, including the address byte and terminator.
+func (e *Emulator) Messages() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	msgs := make([][]byte, len(e.messages))
+	copy(msgs, e.messages)
+	return msgs
+}
+
+// Fail adds a fault. Faults are checked in the order they were added.
+func (e *Emulator) Fail(f Fault) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.faults = append(e.faults, f)
+}
+
+// ClearFaults removes every fault.
+func (e *Emulator) ClearFaults() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.faults = nil
+}
+
+// handle handles a single packet, returning the packets to reply with.
+func (e *Emulator) handle(p viscaip.Packet) [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.messages = append(e.messages, p.Message)
+
+	var replies [][]byte
+	for _, msg := range e.reply(p.Message) {
+		buf, err := viscaip.Packet{Type: viscaip.PayloadTypeReply, Sequence: p.Sequence, Message: msg}.MarshalBinary()
+		if err != nil {
+			continue
+		}
+
+		if e.shortLength {
+			binary.BigEndian.PutUint16(buf[2:4], uint16(len(msg)-1))
+		}
+
+		replies = append(replies, buf)
+	}
+
+	return replies
+}
+
+// reply returns the VISCA messages to reply to msg with. e.mu must be held.
+func (e *Emulator) reply(msg []byte) [][]byte {
+	if len(msg) < 3 || msg[0] != 0x81 || msg[len(msg)-1] != 0xff {
+		return [][]byte{errorReply(ErrorSyntax)}
+	}
+
+	body := msg[1 : len(msg)-1]
+
+	for i := range e.faults {
+		f := &e.faults[i]
+		if !bytes.HasPrefix(body, f.Match) {
+			continue
+		}
+
+		code := f.Error
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				e.faults = append(e.faults[:i], e.faults[i+1:]...)
+			}
+		}
+
+		if code == 0 {
+			return nil
+		}
+
+		return [][]byte{errorReply(code)}
+	}
+
+	e.state.advance(time.Now())
+
+	switch body[0] {
+	case 0x01:
+		if code := e.command(body[1:]); code != 0 {
+			return [][]byte{errorReply(code)}
+		}
+
+		if e.completions {
+			return [][]byte{{0x90, 0x41, 0xff}, {0x90, 0x51, 0xff}}
+		}
+
+		return [][]byte{{0x90, 0x41, 0xff}}
+	case 0x09:
+		data, code := e.inquiry(body[1:])
+		if code != 0 {
+			return [][]byte{errorReply(code)}
+		}
+
+		return [][]byte{append(append([]byte{0x90, 0x50}, data...), 0xff)}
+	default:
+		return [][]byte{errorReply(ErrorSyntax)}
+	}
+}
+
+func errorReply(code byte) []byte {
+	return []byte{0x90, 0x60, code, 0xff}
+}
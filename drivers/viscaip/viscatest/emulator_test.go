@@ -0,0 +1,136 @@
+package viscatest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/drivers/viscaip"
+	"github.com/byuoitav/visca"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	emu := New(WithCompletions())
+	defer emu.Close()
+
+	addr, err := emu.ListenUDP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	client := &viscaip.Client{Address: addr}
+
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cameraservices.PowerOn, status.Power)
+	require.Equal(t, "0100", status.Firmware)
+
+	require.NoError(t, client.SetFocusMode(ctx, cameraservices.ModeManual))
+	require.NoError(t, client.SetIris(ctx, 0x0a))
+	require.NoError(t, client.SetWhiteBalance(ctx, cameraservices.WhiteBalanceOnePush))
+
+	state := emu.State()
+	require.False(t, state.FocusAuto)
+	require.Equal(t, 0x0a, state.Iris)
+	require.Equal(t, byte(0x03), state.WhiteBalance)
+	require.Equal(t, 1, state.OnePushTriggers)
+
+	// power off, and then only power commands work
+	require.NoError(t, client.Command(ctx, 0x01, 0x04, 0x00, 0x03))
+	require.True(t, errors.Is(client.FocusNear(ctx), viscaip.ErrNotExecutable))
+
+	on, err := client.Power(ctx)
+	require.NoError(t, err)
+	require.False(t, on)
+}
+
+func TestFaults(t *testing.T) {
+	emu := New()
+	defer emu.Close()
+
+	addr, err := emu.ListenUDP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	client := &viscaip.Client{Address: addr}
+
+	emu.Fail(Fault{Match: []byte{0x01, 0x04, 0x08}, Error: ErrorBufferFull, Count: 1})
+	emu.Fail(Fault{Match: []byte{0x09}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.True(t, errors.Is(client.FocusFar(ctx), viscaip.ErrBufferFull))
+	require.NoError(t, client.FocusFar(ctx))
+
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Power(short)
+	require.Error(t, err)
+
+	emu.ClearFaults()
+
+	_, err = client.Power(ctx)
+	require.NoError(t, err)
+	require.Len(t, emu.Messages(), 4)
+}
+
+func TestViscaLibrary(t *testing.T) {
+	emu := New(WithShortLength())
+	defer emu.Close()
+
+	addr, err := emu.ListenUDP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cam := visca.New(addr, visca.WithDelay(0))
+
+	require.NoError(t, cam.PanRight(ctx, 0x0b))
+	state := emu.State()
+	require.Equal(t, byte(DirectionRight), state.PanDirection)
+	require.Equal(t, byte(0x0b), state.PanSpeed)
+
+	require.NoError(t, cam.PanTiltStop(ctx))
+	require.NoError(t, cam.MemorySet(ctx, 3))
+	require.NoError(t, cam.MemoryRecall(ctx, 3))
+	require.Equal(t, []byte{3}, emu.State().Recalls)
+
+	emu.Fail(Fault{Error: ErrorSyntax, Count: 1})
+	require.Equal(t, visca.ErrSyntaxError, cam.ZoomStop(ctx))
+}
+
+func TestTCP(t *testing.T) {
+	emu := New()
+	defer emu.Close()
+
+	addr, err := emu.ListenTCP("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetDeadline(time.Now().Add(2*time.Second)))
+
+	p, err := viscaip.Packet{Type: viscaip.PayloadTypeInquiry, Sequence: 7, Message: []byte{0x81, 0x09, 0x04, 0x47, 0xff}}.MarshalBinary()
+	require.NoError(t, err)
+
+	_, err = conn.Write(p)
+	require.NoError(t, err)
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0xff)
+	require.NoError(t, err)
+
+	var resp viscaip.Packet
+	require.NoError(t, resp.UnmarshalBinary(reply))
+	require.Equal(t, uint32(7), resp.Sequence)
+	require.Equal(t, []byte{0x90, 0x50, 0x00, 0x00, 0x00, 0x00, 0xff}, resp.Message)
+}
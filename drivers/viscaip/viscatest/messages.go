@@ -0,0 +1,261 @@
+package viscatest
+
+import "bytes"
+
+const (
+	_panTiltSpeedMax = 0x18
+	_presetMax       = 0x7f
+	_irisMax         = 0x11
+	_brightnessMax   = 0x0e
+)
+
+// command runs a command (the message after 0x81 0x01, without the terminator),
+// returning the error code to reply with, or 0 if it succeeded. e.mu must be held.
+func (e *Emulator) command(cmd []byte) byte {
+	s := &e.state
+
+	// power on/off
+	if bytes.HasPrefix(cmd, []byte{0x04, 0x00}) {
+		if len(cmd) != 3 {
+			return ErrorSyntax
+		}
+
+		switch cmd[2] {
+		case 0x02:
+			s.Power = true
+		case 0x03:
+			s.Power = false
+			s.PanDirection, s.TiltDirection = DirectionStop, DirectionStop
+			s.ZoomDirection = DriveStop
+		default:
+			return ErrorSyntax
+		}
+
+		return 0
+	}
+
+	if !s.Power {
+		return ErrorNotExecutable
+	}
+
+	switch {
+	case match(cmd, 0x06, 0x01):
+		// pan/tilt drive
+		if len(cmd) != 6 || cmd[2] > _panTiltSpeedMax || cmd[3] > _panTiltSpeedMax {
+			return ErrorSyntax
+		}
+
+		if !validDirection(cmd[4]) || !validDirection(cmd[5]) {
+			return ErrorSyntax
+		}
+
+		s.PanSpeed, s.TiltSpeed = cmd[2], cmd[3]
+		s.PanDirection, s.TiltDirection = cmd[4], cmd[5]
+	case match(cmd, 0x04, 0x07):
+		// zoom (the low nibble of a variable speed zoom is the speed)
+		dir, ok := drive(cmd)
+		if !ok {
+			return ErrorSyntax
+		}
+
+		s.ZoomDirection = dir
+	case match(cmd, 0x04, 0x3f):
+		// memory
+		if len(cmd) != 4 || cmd[3] > _presetMax {
+			return ErrorSyntax
+		}
+
+		switch cmd[2] {
+		case 0x00:
+			delete(s.Presets, cmd[3])
+		case 0x01:
+			s.Presets[cmd[3]] = Preset{Pan: s.Pan, Tilt: s.Tilt, Zoom: s.Zoom}
+		case 0x02:
+			p := s.Presets[cmd[3]]
+			s.Pan, s.Tilt, s.Zoom = p.Pan, p.Tilt, p.Zoom
+			s.PanDirection, s.TiltDirection = DirectionStop, DirectionStop
+			s.ZoomDirection = DriveStop
+			s.Recalls = append(s.Recalls, cmd[3])
+		default:
+			return ErrorSyntax
+		}
+	case match(cmd, 0x04, 0x38):
+		// focus mode
+		if len(cmd) != 3 {
+			return ErrorSyntax
+		}
+
+		switch cmd[2] {
+		case 0x02:
+			s.FocusAuto = true
+		case 0x03:
+			s.FocusAuto = false
+		case 0x10:
+			s.FocusAuto = !s.FocusAuto
+		default:
+			return ErrorSyntax
+		}
+	case match(cmd, 0x04, 0x08):
+		// focus
+		dir, ok := drive(cmd)
+		if !ok {
+			return ErrorSyntax
+		}
+
+		s.FocusDirection = dir
+	case match(cmd, 0x04, 0x18):
+		// one push af
+		if len(cmd) != 3 || cmd[2] != 0x01 {
+			return ErrorSyntax
+		}
+
+		s.OnePushFocuses++
+	case match(cmd, 0x04, 0x39):
+		// ae mode
+		if len(cmd) != 3 {
+			return ErrorSyntax
+		}
+
+		switch cmd[2] {
+		case 0x00, 0x03, 0x0a, 0x0b, 0x0d:
+			s.ExposureMode = cmd[2]
+		default:
+			return ErrorSyntax
+		}
+	case match(cmd, 0x04, 0x4b):
+		// iris direct
+		level, ok := direct(cmd, _irisMax)
+		if !ok {
+			return ErrorSyntax
+		}
+
+		s.Iris = level
+	case match(cmd, 0x04, 0x3e):
+		// exposure compensation on/off
+		if len(cmd) != 3 || (cmd[2] != 0x02 && cmd[2] != 0x03) {
+			return ErrorSyntax
+		}
+
+		s.ExposureCompensation = cmd[2] == 0x02
+	case match(cmd, 0x04, 0x4e):
+		// exposure compensation direct
+		level, ok := direct(cmd, _brightnessMax)
+		if !ok {
+			return ErrorSyntax
+		}
+
+		s.Brightness = level
+	case match(cmd, 0x04, 0x35):
+		// white balance mode
+		if len(cmd) != 3 || cmd[2] > 0x05 {
+			return ErrorSyntax
+		}
+
+		s.WhiteBalance = cmd[2]
+	case match(cmd, 0x04, 0x10):
+		// one push white balance trigger
+		if len(cmd) != 3 || cmd[2] != 0x05 {
+			return ErrorSyntax
+		}
+
+		s.OnePushTriggers++
+	default:
+		return ErrorSyntax
+	}
+
+	return 0
+}
+
+// inquiry answers an inquiry (the message after 0x81 0x09, without the terminator),
+// returning the data to reply with, or the error code to reply with. e.mu must be held.
+func (e *Emulator) inquiry(inq []byte) ([]byte, byte) {
+	s := &e.state
+
+	if len(inq) != 2 {
+		return nil, ErrorSyntax
+	}
+
+	switch {
+	case match(inq, 0x04, 0x00):
+		if s.Power {
+			return []byte{0x02}, 0
+		}
+
+		return []byte{0x03}, 0
+	case match(inq, 0x00, 0x02):
+		return []byte{
+			byte(s.Vendor >> 8), byte(s.Vendor),
+			byte(s.Model >> 8), byte(s.Model),
+			byte(s.ROM >> 8), byte(s.ROM),
+			0x02,
+		}, 0
+	}
+
+	if !s.Power {
+		return nil, ErrorNotExecutable
+	}
+
+	switch {
+	case match(inq, 0x06, 0x12):
+		return append(nibbles(s.Pan&0xffff), nibbles(s.Tilt&0xffff)...), 0
+	case match(inq, 0x04, 0x47):
+		return nibbles(s.Zoom), 0
+	case match(inq, 0x04, 0x38):
+		if s.FocusAuto {
+			return []byte{0x02}, 0
+		}
+
+		return []byte{0x03}, 0
+	case match(inq, 0x04, 0x39):
+		return []byte{s.ExposureMode}, 0
+	case match(inq, 0x04, 0x4b):
+		return nibbles(s.Iris), 0
+	case match(inq, 0x04, 0x4e):
+		return nibbles(s.Brightness), 0
+	case match(inq, 0x04, 0x35):
+		return []byte{s.WhiteBalance}, 0
+	default:
+		return nil, ErrorSyntax
+	}
+}
+
+func match(msg []byte, category, command byte) bool {
+	return len(msg) >= 2 && msg[0] == category && msg[1] == command
+}
+
+func validDirection(dir byte) bool {
+	return dir >= 0x01 && dir <= 0x03
+}
+
+// drive parses a stop/tele/wide (or far/near) command, including the variable speed forms.
+func drive(cmd []byte) (byte, bool) {
+	if len(cmd) != 3 {
+		return 0, false
+	}
+
+	switch {
+	case cmd[2] == DriveStop:
+		return DriveStop, true
+	case cmd[2] == DriveTele || cmd[2]&0xf0 == 0x20:
+		return DriveTele, true
+	case cmd[2] == DriveWide || cmd[2]&0xf0 == 0x30:
+		return DriveWide, true
+	default:
+		return 0, false
+	}
+}
+
+// direct parses a 0x00 0x00 0x0p 0x0q direct command, with a max value of max.
+func direct(cmd []byte, max int) (int, bool) {
+	if len(cmd) != 6 || cmd[2] != 0 || cmd[3] != 0 || cmd[4] > 0x0f || cmd[5] > 0x0f {
+		return 0, false
+	}
+
+	level := int(cmd[4])<<4 | int(cmd[5])
+	return level, level <= max
+}
+
+// nibbles splits the low 16 bits of v into 4 bytes, one nibble each.
+func nibbles(v int) []byte {
+	return []byte{byte(v>>12) & 0x0f, byte(v>>8) & 0x0f, byte(v>>4) & 0x0f, byte(v) & 0x0f}
+}
@@ -0,0 +1,41 @@
+package viscatest
+
+type options struct {
+	completions bool
+	shortLength bool
+	state       func(*State)
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithCompletions makes the emulator send a completion reply after each command's ack, as a separate packet.
+// By default only the ack is sent, because github.com/byuoitav/visca only reads one reply per command
+// and would read the completion as the reply to its next command.
+func WithCompletions() Option {
+	return optionFunc(func(o *options) {
+		o.completions = true
+	})
+}
+
+// WithShortLength makes the emulator leave the terminator out of the length in each reply's header, like Aver cameras do.
+// github.com/byuoitav/visca only recognizes error replies from cameras that do this.
+func WithShortLength() Option {
+	return optionFunc(func(o *options) {
+		o.shortLength = true
+	})
+}
+
+// WithState calls f with the emulator's initial state, so that it can be changed.
+func WithState(f func(*State)) Option {
+	return optionFunc(func(o *options) {
+		o.state = f
+	})
+}
@@ -0,0 +1,126 @@
+package viscatest
+
+import "time"
+
+// how far the camera moves each second, per unit of speed
+const _unitsPerSpeed = 16
+
+// The directions in a pan/tilt drive command.
+const (
+	DirectionUp    = 0x01
+	DirectionDown  = 0x02
+	DirectionLeft  = 0x01
+	DirectionRight = 0x02
+	DirectionStop  = 0x03
+)
+
+// The directions of a zoom or focus command.
+const (
+	DriveStop = 0x00
+	DriveTele = 0x02 // zoom in, or focus far
+	DriveWide = 0x03 // zoom out, or focus near
+)
+
+// State is the state of an emulated camera.
+type State struct {
+	Power bool
+
+	Pan           int
+	Tilt          int
+	PanSpeed      byte
+	TiltSpeed     byte
+	PanDirection  byte
+	TiltDirection byte
+
+	Zoom          int
+	ZoomDirection byte
+
+	FocusAuto      bool
+	FocusDirection byte
+	OnePushFocuses int
+
+	// ExposureMode is the VISCA AE mode (0x00 is auto, 0x03 is manual)
+	ExposureMode         byte
+	Iris                 int
+	ExposureCompensation bool
+	Brightness           int
+
+	// WhiteBalance is the VISCA white balance mode (0x00 is auto, 0x03 is one push)
+	WhiteBalance    byte
+	OnePushTriggers int
+
+	Presets map[byte]Preset
+	Recalls []byte // every preset channel recalled, in order
+
+	Vendor uint16
+	Model  uint16
+	ROM    uint16
+
+	updated time.Time
+}
+
+// Preset is a saved camera position.
+type Preset struct {
+	Pan  int
+	Tilt int
+	Zoom int
+}
+
+func newState() State {
+	return State{
+		Power:         true,
+		PanDirection:  DirectionStop,
+		TiltDirection: DirectionStop,
+		FocusAuto:     true,
+		Brightness:    0x07,
+		Presets:       make(map[byte]Preset),
+		Vendor:        0x0020,
+		Model:         0x0520,
+		ROM:           0x0100,
+		updated:       time.Now(),
+	}
+}
+
+// advance moves the camera at its current speeds up until now.
+func (s *State) advance(now time.Time) {
+	elapsed := now.Sub(s.updated).Seconds()
+	s.updated = now
+
+	s.Pan = clamp(s.Pan+int(float64(direction(s.PanDirection, DirectionRight, DirectionLeft)*int(s.PanSpeed)*_unitsPerSpeed)*elapsed), -0x7fff, 0x7fff)
+	s.Tilt = clamp(s.Tilt+int(float64(direction(s.TiltDirection, DirectionUp, DirectionDown)*int(s.TiltSpeed)*_unitsPerSpeed)*elapsed), -0x7fff, 0x7fff)
+	s.Zoom = clamp(s.Zoom+int(float64(direction(s.ZoomDirection, DriveTele, DriveWide)*0x400)*elapsed), 0, 0x4000)
+}
+
+// copy returns a copy of s that doesn't share its presets.
+func (s State) copy() State {
+	presets := make(map[byte]Preset, len(s.Presets))
+	for k, v := range s.Presets {
+		presets[k] = v
+	}
+
+	s.Presets = presets
+	s.Recalls = append([]byte(nil), s.Recalls...)
+	return s
+}
+
+func direction(dir, positive, negative byte) int {
+	switch dir {
+	case positive:
+		return 1
+	case negative:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func clamp(v, min, max int) int {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+
+	return v
+}
@@ -0,0 +1,148 @@
+package fileconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"gopkg.in/yaml.v2"
+)
+
+// _extensions are the extensions of the files that are read as documents.
+var _extensions = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// documents returns the files in dir that are read as documents, sorted by name.
+func documents(dir string) ([]os.FileInfo, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory: %w", err)
+	}
+
+	docs := infos[:0]
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") || !_extensions[strings.ToLower(filepath.Ext(info.Name()))] {
+			continue
+		}
+
+		docs = append(docs, info)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Name() < docs[j].Name()
+	})
+
+	return docs, nil
+}
+
+// readRooms reads every document in dir, keyed by room. A document's room defaults to its file name.
+func readRooms(dir string) (map[string]cameraservices.RoomConfig, error) {
+	docs, err := documents(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make(map[string]cameraservices.RoomConfig, len(docs))
+	for _, info := range docs {
+		path := filepath.Join(dir, info.Name())
+
+		r, err := readRoom(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", path, err)
+		}
+
+		if r.ID == "" {
+			r.ID = strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		}
+
+		if _, ok := rooms[r.ID]; ok {
+			return nil, fmt.Errorf("%s: room %q is defined more than once", path, r.ID)
+		}
+
+		rooms[r.ID] = r
+	}
+
+	return rooms, nil
+}
+
+func readRoom(path string) (cameraservices.RoomConfig, error) {
+	var r cameraservices.RoomConfig
+
+	data, err := ReadDocument(path)
+	if err != nil {
+		return r, err
+	}
+
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("unable to parse document: %w", err)
+	}
+
+	return r, nil
+}
+
+// Documents returns the path of each file in dir that is read as a room's document, sorted by name.
+func Documents(dir string) ([]string, error) {
+	docs, err := documents(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(docs))
+	for _, info := range docs {
+		paths = append(paths, filepath.Join(dir, info.Name()))
+	}
+
+	return paths, nil
+}
+
+// ReadDocument reads the document at path as json. YAML documents are converted to json,
+// so that both use the json field names.
+func ReadDocument(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse yaml: %w", err)
+		}
+
+		data, err = json.Marshal(jsonValue(doc))
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert yaml: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// jsonValue converts the maps yaml unmarshals into maps that can be marshaled to json.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = jsonValue(val)
+		}
+
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+
+		return v
+	default:
+		return v
+	}
+}
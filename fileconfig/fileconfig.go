@@ -0,0 +1,222 @@
+// Package fileconfig is a ConfigService that reads each room's configuration from a directory of
+// YAML or JSON documents, for deployments without a database. The documents are in the same shape as
+// the ui-configuration database's documents, and are reloaded when they change.
+package fileconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+type configService struct {
+	dir string
+	log *zap.Logger
+
+	mu      sync.RWMutex
+	rooms   map[string]cameraservices.RoomConfig
+	version string
+
+	// scenes are only kept in memory
+	scenesMu sync.Mutex
+	scenes   map[string]map[string][]cameraservices.Scene
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// New creates a ConfigService that reads the documents in dir, and starts reloading them in the background
+// when they change. Close must be called to stop it.
+func New(dir string, opts ...Option) (*configService, error) {
+	options := options{
+		reloadInterval: _defaultReloadInterval,
+		log:            zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	c := &configService{
+		dir:    dir,
+		log:    options.log,
+		scenes: make(map[string]map[string][]cameraservices.Scene),
+		stop:   make(chan struct{}),
+	}
+
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	if options.reloadInterval > 0 {
+		c.wg.Add(1)
+		go c.watch(options.reloadInterval)
+	}
+
+	return c, nil
+}
+
+// Close stops reloading the documents.
+func (c *configService) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		c.wg.Wait()
+	})
+
+	return nil
+}
+
+func (c *configService) watch(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			reloaded, err := c.reload()
+			switch {
+			case err != nil:
+				c.log.Warn("unable to reload config, keeping the previous config", zap.String("dir", c.dir), zap.Error(err))
+			case reloaded:
+				c.log.Info("Reloaded config", zap.String("dir", c.dir), zap.Int("rooms", c.numRooms()))
+			}
+		}
+	}
+}
+
+// reload reads the documents again if any of them have changed since they were last read.
+func (c *configService) reload() (bool, error) {
+	version, err := c.currentVersion()
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.RLock()
+	unchanged := version == c.version
+	c.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	rooms, err := readRooms(c.dir)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.rooms = rooms
+	c.version = version
+	c.mu.Unlock()
+
+	return true, nil
+}
+
+// currentVersion identifies the current contents of the directory by the name, size and modification time of each document.
+func (c *configService) currentVersion() (string, error) {
+	docs, err := documents(c.dir)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, info := range docs {
+		fmt.Fprintf(&b, "%s:%d:%d\n", info.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return b.String(), nil
+}
+
+func (c *configService) numRooms() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.rooms)
+}
+
+func (c *configService) room(id string) (cameraservices.RoomConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r, ok := c.rooms[id]
+	if !ok {
+		return r, fmt.Errorf("no config found for %s", id)
+	}
+
+	return r, nil
+}
+
+func (c *configService) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	r, err := c.room(info.Room)
+	if err != nil {
+		return []cameraservices.CameraConfig{}, err
+	}
+
+	return r.Cameras(info)
+}
+
+// Returns a list of the urls for the commands that each contain the IP address or hostname
+func (c *configService) ControlIP(ctx context.Context, id string) ([]string, error) {
+	r, err := c.room(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Streams(), nil
+}
+
+// Rooms returns every room that has a camera, sorted.
+func (c *configService) Rooms(ctx context.Context) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var rooms []string
+	for id, r := range c.rooms {
+		if len(r.ControlGroupNames()) > 0 {
+			rooms = append(rooms, id)
+		}
+	}
+
+	sort.Strings(rooms)
+	return rooms, nil
+}
+
+func (c *configService) ControlGroups(ctx context.Context, id string) ([]string, error) {
+	r, err := c.room(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ControlGroupNames(), nil
+}
+
+func (c *configService) CameraPreset(ctx context.Context, camID, presetID string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.rooms))
+	for id := range c.rooms {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if name, ok := c.rooms[id].PresetName(camID, presetID); ok {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find matching preset")
+}
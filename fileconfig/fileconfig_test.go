@@ -0,0 +1,186 @@
+package fileconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+const _yamlRoom = `
+presets:
+  - name: ITB-1101
+    cameras:
+      - displayName: Front
+        panLeft: http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/left
+        stream: http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/stream
+        presets:
+          - displayName: Podium
+            setPreset: http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/preset/1
+  - name: Empty
+    cameras: []
+`
+
+const _jsonRoom = `{
+	"_id": "JFSB-B104",
+	"presets": [{
+		"name": "JFSB-B104",
+		"cameras": [{
+			"displayName": "Back",
+			"stream": "http://axis.av/v1/P5414-E/JFSB-B104-CAM1.byu.edu/stream",
+			"presets": [{"displayName": "Wide", "setPreset": "http://axis.av/v1/P5414-E/JFSB-B104-CAM1.byu.edu/preset/3"}]
+		}]
+	}]
+}`
+
+func writeFile(t *testing.T, dir, name, data string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+}
+
+func newTestService(t *testing.T) (*configService, string) {
+	dir := t.TempDir()
+	writeFile(t, dir, "ITB-1101.yaml", _yamlRoom)
+	writeFile(t, dir, "room.json", _jsonRoom)
+	writeFile(t, dir, "README.md", "not a document")
+
+	c, err := New(dir, WithReloadInterval(0))
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	return c, dir
+}
+
+func TestConfigService(t *testing.T) {
+	c, _ := newTestService(t)
+	ctx := context.Background()
+
+	rooms, err := c.Rooms(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ITB-1101", "JFSB-B104"}, rooms)
+
+	groups, err := c.ControlGroups(ctx, "ITB-1101")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ITB-1101"}, groups)
+
+	cameras, err := c.Cameras(ctx, cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "ITB-1101"})
+	require.NoError(t, err)
+	require.Len(t, cameras, 1)
+	require.Equal(t, "Front", cameras[0].DisplayName)
+	require.Equal(t, "http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/left", cameras[0].PanLeft)
+
+	// changing the cameras that are returned doesn't change the config
+	cameras[0].PanLeft = ""
+	cameras[0].Presets[0].SetPreset = ""
+
+	cameras, err = c.Cameras(ctx, cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "ITB-1101"})
+	require.NoError(t, err)
+	require.Equal(t, "http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/left", cameras[0].PanLeft)
+	require.Equal(t, "http://aver.av/v1/Pro520/ITB-1101-CAM1.byu.edu/preset/1", cameras[0].Presets[0].SetPreset)
+
+	_, err = c.Cameras(ctx, cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "Empty"})
+	require.Error(t, err)
+
+	_, err = c.Cameras(ctx, cameraservices.ControlInfo{Room: "Unknown", ControlGroup: "Unknown"})
+	require.Error(t, err)
+
+	ips, err := c.ControlIP(ctx, "JFSB-B104")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://axis.av/v1/P5414-E/JFSB-B104-CAM1.byu.edu/stream"}, ips)
+
+	preset, err := c.CameraPreset(ctx, "JFSB-B104-CAM1", "3")
+	require.NoError(t, err)
+	require.Equal(t, "Wide", preset)
+
+	_, err = c.CameraPreset(ctx, "JFSB-B104-CAM1", "9")
+	require.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	c, dir := newTestService(t)
+	ctx := context.Background()
+
+	reloaded, err := c.reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// a new room is picked up
+	writeFile(t, dir, "new.yml", "_id: NEW-1\npresets:\n  - name: NEW-1\n    cameras:\n      - displayName: Only\n")
+
+	reloaded, err = c.reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	rooms, err := c.Rooms(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ITB-1101", "JFSB-B104", "NEW-1"}, rooms)
+
+	// an invalid document keeps the previous config
+	writeFile(t, dir, "bad.json", "{")
+
+	_, err = c.reload()
+	require.Error(t, err)
+
+	rooms, err = c.Rooms(ctx)
+	require.NoError(t, err)
+	require.Len(t, rooms, 3)
+
+	// rooms can only be defined once
+	require.NoError(t, os.Remove(filepath.Join(dir, "bad.json")))
+	writeFile(t, dir, "copy.json", _jsonRoom)
+
+	_, err = c.reload()
+	require.Error(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "ITB-1101.yaml", _yamlRoom)
+
+	c, err := New(dir, WithReloadInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer c.Close()
+
+	writeFile(t, dir, "room.json", _jsonRoom)
+
+	require.Eventually(t, func() bool {
+		rooms, _ := c.Rooms(context.Background())
+		return len(rooms) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "bad.yaml", "presets: [")
+
+	_, err = New(dir)
+	require.Error(t, err)
+}
+
+func TestScenes(t *testing.T) {
+	c, _ := newTestService(t)
+	ctx := context.Background()
+
+	scene := cameraservices.Scene{
+		Name:    "Lecture",
+		Presets: []cameraservices.ScheduledPreset{{Camera: "Front", Preset: "Podium"}},
+	}
+
+	require.NoError(t, c.SetScene(ctx, "ITB-1101", "ITB-1101", scene))
+
+	got, err := c.Scene(ctx, "ITB-1101", "ITB-1101", "Lecture")
+	require.NoError(t, err)
+	require.Equal(t, scene, got)
+
+	require.NoError(t, c.DeleteScene(ctx, "ITB-1101", "ITB-1101", "Lecture"))
+
+	_, err = c.Scene(ctx, "ITB-1101", "ITB-1101", "Lecture")
+	require.Equal(t, cameraservices.ErrSceneNotFound, err)
+	require.Equal(t, cameraservices.ErrSceneNotFound, c.DeleteScene(ctx, "ITB-1101", "ITB-1101", "Lecture"))
+}
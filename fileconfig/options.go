@@ -0,0 +1,39 @@
+package fileconfig
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const _defaultReloadInterval = 5 * time.Second
+
+type options struct {
+	reloadInterval time.Duration
+	log            *zap.Logger
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithReloadInterval sets how often the directory is checked for changes.
+// The default is 5 seconds. 0 means the documents are only read once.
+func WithReloadInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.reloadInterval = d
+	})
+}
+
+// WithLogger sets the logger used to log reloads.
+func WithLogger(log *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.log = log
+	})
+}
@@ -0,0 +1,68 @@
+package fileconfig
+
+import (
+	"context"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// Scenes aren't part of the room documents, so they are kept in memory and are lost when the service restarts.
+
+func (c *configService) Scenes(ctx context.Context, room, controlGroup string) ([]cameraservices.Scene, error) {
+	c.scenesMu.Lock()
+	defer c.scenesMu.Unlock()
+
+	scenes := append([]cameraservices.Scene{}, c.scenes[room][controlGroup]...)
+	return scenes, nil
+}
+
+func (c *configService) Scene(ctx context.Context, room, controlGroup, name string) (cameraservices.Scene, error) {
+	scenes, err := c.Scenes(ctx, room, controlGroup)
+	if err != nil {
+		return cameraservices.Scene{}, err
+	}
+
+	for _, scene := range scenes {
+		if scene.Name == name {
+			return scene, nil
+		}
+	}
+
+	return cameraservices.Scene{}, cameraservices.ErrSceneNotFound
+}
+
+// SetScene creates the scene, or replaces the scene with the same name.
+func (c *configService) SetScene(ctx context.Context, room, controlGroup string, scene cameraservices.Scene) error {
+	c.scenesMu.Lock()
+	defer c.scenesMu.Unlock()
+
+	if c.scenes[room] == nil {
+		c.scenes[room] = make(map[string][]cameraservices.Scene)
+	}
+
+	scenes := c.scenes[room][controlGroup]
+	for i := range scenes {
+		if scenes[i].Name == scene.Name {
+			scenes[i] = scene
+			return nil
+		}
+	}
+
+	c.scenes[room][controlGroup] = append(scenes, scene)
+	return nil
+}
+
+func (c *configService) DeleteScene(ctx context.Context, room, controlGroup, name string) error {
+	c.scenesMu.Lock()
+	defer c.scenesMu.Unlock()
+
+	scenes := c.scenes[room][controlGroup]
+	for i := range scenes {
+		if scenes[i].Name == name {
+			c.scenes[room][controlGroup] = append(scenes[:i:i], scenes[i+1:]...)
+			return nil
+		}
+	}
+
+	return cameraservices.ErrSceneNotFound
+}
@@ -17,20 +17,36 @@ import (
 )
 
 type CameraController struct {
-	CreateCamera      cameraservices.NewCameraFunc
-	EventPublisher    cameraservices.EventPublisher
+	CreateCamera   cameraservices.NewCameraFunc
+	EventPublisher cameraservices.EventPublisher
+
+	// ControlKeyService is used to check the control key sent with each request, which must be for a control
+	// group the camera is in (according to DatabaseService). Requests are rejected if it is nil, unless
+	// DisableKeyCheck is set.
 	ControlKeyService cameraservices.ControlKeyService
 	DatabaseService   cameraservices.ConfigService
 	Logger            *zap.Logger
 
+	// DisableKeyCheck accepts requests without checking their control key (ie. for simulated cameras)
+	DisableKeyCheck bool
+
+	// KeyTTL is how long the cameras each control key can control are cached for. Defaults to 1 minute.
+	KeyTTL time.Duration
+
 	streams *sync.Map
+	queues  *sync.Map
 	single  *singleflight.Group
+	drain   *drain
+	keys    *keyAuthorizer
 }
 
 func NewCameraController(cs cameraservices.ConfigService) *CameraController {
 	return &CameraController{
 		streams:         &sync.Map{},
+		queues:          &sync.Map{},
+		drain:           newDrain(),
 		single:          &singleflight.Group{},
+		keys:            &keyAuthorizer{},
 		DatabaseService: cs,
 	}
 }
@@ -71,53 +87,51 @@ func (h *CameraController) getCameraIP(ctx context.Context, addr string) (net.IP
 	return ip, nil
 }
 
-func (h *CameraController) checkControlKey(c *gin.Context, key, address string) bool {
-	if key == "" {
-		return false
+// checkControlKey checks that key is for a control group the camera at address is in. Denied requests are audit logged.
+// If false is returned, a response has already been written.
+func (h *CameraController) checkControlKey(c *gin.Context, log *zap.Logger, address string) bool {
+	if h.DisableKeyCheck {
+		return true
 	}
 
-	room, _, err := h.ControlKeyService.RoomAndControlGroup(c, key)
-	if err != nil {
+	if h.ControlKeyService == nil {
+		log.Error("unable to check control key: no control key service", zap.String("address", address))
+		c.String(http.StatusInternalServerError, "unable to check control key: no control key service")
 		return false
 	}
 
-	IPAddresses, err := h.DatabaseService.ControlIP(c, room)
-	if err != nil {
+	key, err := c.Cookie("control-key")
+	if err != nil || key == "" {
+		log.Warn("Denied camera request", zap.String("address", address), zap.String("from", c.ClientIP()), zap.String("reason", "no control key"))
+		c.String(http.StatusUnauthorized, "no control key")
 		return false
 	}
 
-	// Check if any IPAddresses contain address string
-	for _, IP := range IPAddresses {
-		ipStr := fmt.Sprintf("%v", IP) // Convert IP to string
-		if strings.Contains(ipStr, address) {
-			return true
-		}
-	}
-	return true
-}
-
-func (h *CameraController) CameraMiddleware(c *gin.Context) {
-	ck, err := c.Cookie("control-key")
-	if err != nil {
-		c.String(http.StatusUnauthorized, "no control key")
-		c.Abort()
-		return
-	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
 
-	address := c.Param("address")
-	if address == "" {
-		c.String(http.StatusBadRequest, "must include camera address")
-		c.Abort()
-		return
-	}
+	grant, err := h.keys.authorize(ctx, h.ControlKeyService, h.DatabaseService, h.KeyTTL, key, address)
+	switch {
+	case errors.Is(err, errKeyDenied):
+		log.Warn("Denied camera request",
+			zap.String("address", address),
+			zap.String("from", c.ClientIP()),
+			zap.String("room", grant.room),
+			zap.String("controlGroup", grant.controlGroup),
+			zap.String("reason", err.Error()))
 
-	authorized := h.checkControlKey(c, ck, address)
-	if !authorized {
 		c.String(http.StatusForbidden, "Unauthorized Key")
-		c.Abort()
-		return
+		return false
+	case err != nil:
+		log.Warn("unable to check control key", zap.String("address", address), zap.Error(err))
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to check control key: %s", err))
+		return false
 	}
 
+	return true
+}
+
+func (h *CameraController) CameraMiddleware(c *gin.Context) {
 	addr := c.Param("address")
 	if addr == "" {
 		c.String(http.StatusBadRequest, "must include camera address")
@@ -131,6 +145,11 @@ func (h *CameraController) CameraMiddleware(c *gin.Context) {
 		log = log.With(zap.String("requestID", id))
 	}
 
+	if !h.checkControlKey(c, log, addr) {
+		c.Abort()
+		return
+	}
+
 	log.Debug("Getting camera", zap.String("address", addr))
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
@@ -167,7 +186,7 @@ func (h *CameraController) Reboot(c *gin.Context) {
 
 	log.Info("Rebooting...")
 
-	if err := cam.Reboot(ctx); err != nil {
+	if err := h.queue(ctx, c, "Reboot", cam.Reboot); err != nil {
 		log.Warn("unable to reboot", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -176,3 +195,41 @@ func (h *CameraController) Reboot(c *gin.Context) {
 	log.Info("Rebooted")
 	c.Status(http.StatusOK)
 }
+
+// Capabilities responds with the optional capabilities the camera supports, and their limits.
+func (h *CameraController) Capabilities(c *gin.Context) {
+	cam := c.MustGet(_cCamera).(cameraservices.Camera)
+	c.JSON(http.StatusOK, cameraservices.CapabilitiesOf(cam))
+}
+
+// Status responds with the current state of the camera.
+func (h *CameraController) Status(c *gin.Context) {
+	id := c.GetString(_cRequestID)
+	cam, ok := c.MustGet(_cCamera).(cameraservices.StatusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	log := h.Logger
+	if len(id) > 0 {
+		log = log.With(zap.String("requestID", id))
+	}
+
+	var status cameraservices.Status
+	err := h.queue(ctx, c, "Status", func(ctx context.Context) error {
+		var err error
+		status, err = cam.Status(ctx)
+		return err
+	})
+	if err != nil {
+		log.Warn("unable to get status", zap.Error(err))
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -66,8 +66,9 @@ func TestCameraFail(t *testing.T) {
 	}
 
 	handler := &CameraController{
-		Logger:       log,
-		CreateCamera: create,
+		Logger:          log,
+		CreateCamera:    create,
+		DisableKeyCheck: true,
 	}
 	handler.CameraMiddleware(c)
 
@@ -103,8 +104,9 @@ func TestCameraPass(t *testing.T) {
 	}
 
 	handler := &CameraController{
-		Logger:       log,
-		CreateCamera: create,
+		Logger:          log,
+		CreateCamera:    create,
+		DisableKeyCheck: true,
 	}
 	handler.CameraMiddleware(c)
 
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+// capabilitiesTTL is how long a camera's capabilities are cached for
+const capabilitiesTTL = 10 * time.Minute
+
+// capabilitiesFailureTTL is how long a failure to get a camera's capabilities is cached for, so that
+// every request for an unreachable camera's control group doesn't wait for it to time out again
+const capabilitiesFailureTTL = 30 * time.Second
+
+type cachedCapabilities struct {
+	caps    cameraservices.Capabilities
+	err     error
+	expires time.Time
+}
+
+// fillCapabilities looks up the capabilities of each camera from its camera service, in parallel.
+// Cameras whose capabilities can't be found are left without any.
+func (h *ControlHandlers) fillCapabilities(ctx context.Context, requestID, key string, cameras []cameraservices.CameraConfig) {
+	log := h.Logger
+	if len(requestID) > 0 {
+		log = log.With(zap.String("requestID", requestID))
+	}
+
+	wg := sync.WaitGroup{}
+
+	for i := range cameras {
+		base, err := cameraBaseURL(cameras[i])
+		if err != nil {
+			log.Debug("unable to get camera base url", zap.String("camera", cameras[i].DisplayName), zap.Error(err))
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			caps, err := h.cameraCapabilities(ctx, requestID, key, base)
+			if err != nil {
+				log.Warn("unable to get camera capabilities", zap.String("camera", cameras[i].DisplayName), zap.Error(err))
+				return
+			}
+
+			cameras[i].Capabilities = caps
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// cameraCapabilities returns the capabilities of the camera at base, which are cached. Failures are cached
+// for a shorter time, unless they were because ctx is done.
+func (h *ControlHandlers) cameraCapabilities(ctx context.Context, requestID, key, base string) (cameraservices.Capabilities, error) {
+	if cached, ok := h.capabilities.Load(base); ok {
+		if c := cached.(cachedCapabilities); time.Now().Before(c.expires) {
+			return c.caps, c.err
+		}
+	}
+
+	caps, err := h.lookupCapabilities(ctx, requestID, key, base)
+	switch {
+	case err == nil:
+		h.capabilities.Store(base, cachedCapabilities{
+			caps:    caps,
+			expires: time.Now().Add(capabilitiesTTL),
+		})
+	case ctx.Err() == nil:
+		h.capabilities.Store(base, cachedCapabilities{
+			err:     err,
+			expires: time.Now().Add(capabilitiesFailureTTL),
+		})
+	}
+
+	return caps, err
+}
+
+func (h *ControlHandlers) lookupCapabilities(ctx context.Context, requestID, key, base string) (cameraservices.Capabilities, error) {
+	to, err := h.backendURL(base + "/capabilities")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, to.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.Header.Set(_hRequestID, requestID)
+	req.AddCookie(&http.Cookie{
+		Name:  "control-key",
+		Value: key,
+	})
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%d response from camera service", resp.StatusCode)
+	}
+
+	var caps cameraservices.Capabilities
+	if err := json.NewDecoder(resp.Body).Decode(&caps); err != nil {
+		return nil, fmt.Errorf("unable to decode response: %w", err)
+	}
+
+	return caps, nil
+}
+
+// cameraBaseURL returns the /v1/{model}/{address} url of the camera, using any of its configured urls.
+func cameraBaseURL(cam cameraservices.CameraConfig) (string, error) {
+	urls := []string{cam.PanTiltStop, cam.ZoomStop, cam.Stream, cam.PanLeft, cam.ZoomIn}
+	for _, p := range cam.Presets {
+		urls = append(urls, p.SetPreset)
+	}
+
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(u)
+		if err != nil {
+			continue
+		}
+
+		split := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 4)
+		if len(split) < 3 {
+			continue
+		}
+
+		parsed.Path = "/" + strings.Join(split[:3], "/")
+		parsed.RawPath = ""
+		parsed.RawQuery = ""
+		return parsed.String(), nil
+	}
+
+	return "", errors.New("no camera service urls configured")
+}
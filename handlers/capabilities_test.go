@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCapabilities(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Set(_cCamera, &goodTestCamera{})
+
+	handler := &CameraController{}
+	handler.Capabilities(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var caps cameraservices.Capabilities
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &caps))
+	require.True(t, caps[cameraservices.CapabilityPresets].Supported)
+	require.False(t, caps[cameraservices.CapabilitySnapshot].Supported)
+}
+
+func TestGetCamerasCapabilities(t *testing.T) {
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/Pro520/front/capabilities" {
+			atomic.AddInt32(&failures, 1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		atomic.AddInt32(&requests, 1)
+		_ = json.NewEncoder(w).Encode(cameraservices.Capabilities{
+			cameraservices.CapabilityAdmin: {Supported: true},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	backend, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	me, err := url.Parse("http://camera-services-control.byu.edu")
+	require.NoError(t, err)
+
+	h := &ControlHandlers{
+		ConfigService: &testConfigService{
+			cameras: []cameraservices.CameraConfig{
+				{
+					DisplayName: "Front",
+					PanTiltStop: "http://camera-services-aver.byu.edu/v1/Pro520/front/pantilt/stop",
+				},
+				{
+					DisplayName: "Back",
+					PanTiltStop: "http://camera-services-aver.byu.edu/v1/Pro520/back/pantilt/stop",
+				},
+			},
+		},
+		Me:          me,
+		Logger:      zap.NewNop(),
+		DisableAuth: true,
+		Backends: testBackends(t, map[string]*url.URL{
+			"aver": backend,
+		}),
+	}
+
+	get := func() []cameraservices.CameraConfig {
+		gin.SetMode(gin.TestMode)
+		resp := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(resp)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+
+		h.GetCameras(c)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var cameras []cameraservices.CameraConfig
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cameras))
+		require.Len(t, cameras, 2)
+		return cameras
+	}
+
+	cameras := get()
+	require.True(t, cameras[0].Capabilities[cameraservices.CapabilityAdmin].Supported)
+	require.Nil(t, cameras[1].Capabilities)
+	require.Equal(t, "http://camera-services-control.byu.edu/proxy/aver/v1/Pro520/front/pantilt/stop", cameras[0].PanTiltStop)
+
+	// second request should be cached, including the camera whose capabilities couldn't be found
+	cameras = get()
+	require.True(t, cameras[0].Capabilities[cameraservices.CapabilityAdmin].Supported)
+	require.Nil(t, cameras[1].Capabilities)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	require.Equal(t, int32(1), atomic.LoadInt32(&failures))
+}
+
+func TestCameraBaseURL(t *testing.T) {
+	base, err := cameraBaseURL(cameraservices.CameraConfig{
+		Presets: []cameraservices.CameraPreset{
+			{SetPreset: "http://camera-services-axis.byu.edu/v1/P5414E/10.0.0.1/preset/1?x=y"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "http://camera-services-axis.byu.edu/v1/P5414E/10.0.0.1", base)
+
+	_, err = cameraBaseURL(cameraservices.CameraConfig{})
+	require.Error(t, err)
+}
@@ -7,10 +7,12 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	cameraservices "github.com/byuoitav/camera-services"
 	"github.com/byuoitav/camera-services/auth/session/cookiestore"
+	"github.com/byuoitav/camera-services/proxy"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -35,6 +37,42 @@ type ControlHandlers struct {
 	SessionStore      *cookiestore.Store
 	SessionName       string
 	DisableAuth       bool
+
+	SceneService cameraservices.SceneService
+
+	// InventoryService is optional; the admin camera routes require it
+	InventoryService cameraservices.InventoryService
+
+	// ModelURLs maps a model to the url template its cameras' urls are generated from when they are added,
+	// ie. http://aver.av.byu.edu/v1/Pro520/{address}
+	ModelURLs map[string]string
+
+	// EventPublisher is optional; group commands are published through it when set
+	EventPublisher cameraservices.EventPublisher
+
+	// Backends is the routing table of camera services (aver, axis) that requests are proxied to
+	Backends *proxy.Table
+
+	// Client is used for requests made on behalf of the user. Defaults to http.DefaultClient
+	Client *http.Client
+
+	// CameraTTL is how long the cameras in each control group are cached for when authorizing proxied
+	// requests. Defaults to 1 minute.
+	CameraTTL time.Duration
+
+	// capabilities caches the capabilities of each camera, keyed by the camera's base url
+	capabilities sync.Map
+
+	// groupCameras caches the cameras in each control group that proxied requests are authorized against
+	groupCameras groupCameras
+
+	// events are the group events still being published
+	events pending
+}
+
+// Shutdown waits for the group events still being published to be sent, or for ctx to be done.
+func (h *ControlHandlers) Shutdown(ctx context.Context) error {
+	return h.events.wait(ctx)
 }
 
 func (h *ControlHandlers) GetCameras(c *gin.Context) {
@@ -52,14 +90,17 @@ func (h *ControlHandlers) GetCameras(c *gin.Context) {
 		return
 	}
 
-	cameras, err := h.ConfigService.Cameras(ctx, info)
+	cameras, err := h.cameras(ctx, info)
 	if err != nil {
 		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get cameras: %s", err))
 		return
 	}
 
-	// change urls to go through proxy (me)
-	rewrite := func(u string) string {
+	// capabilities have to be looked up before the urls are rewritten
+	h.fillCapabilities(c.Request.Context(), c.GetString(_cRequestID), info.ControlKey, cameras)
+
+	// change urls to go through proxy (me). urls that don't belong to a backend are left alone
+	rewrite := func(u, service string) string {
 		if u == "" {
 			return ""
 		}
@@ -69,40 +110,74 @@ func (h *ControlHandlers) GetCameras(c *gin.Context) {
 			return ""
 		}
 
-		url.Scheme = h.Me.Scheme
-		url.Host = h.Me.Host
+		if service == "" {
+			service = h.Backends.Match(url)
+		}
 
-		switch {
-		case strings.Contains(u, "aver"):
-			url.Path = "/proxy/aver" + url.Path
-		case strings.Contains(u, "axis"):
-			url.Path = "/proxy/axis" + url.Path
+		if service == "" {
+			h.Logger.Warn("no backend for camera url", zap.String("url", u), zap.String("requestID", c.GetString(_cRequestID)))
+			return u
 		}
 
+		url.Path = "/proxy/" + service + url.Path
+		url.Scheme = h.Me.Scheme
+		url.Host = h.Me.Host
+
 		return url.String()
 	}
 
 	for i := range cameras {
-		cameras[i].PanLeft = rewrite(cameras[i].PanLeft)
-		cameras[i].PanRight = rewrite(cameras[i].PanRight)
-		cameras[i].TiltUp = rewrite(cameras[i].TiltUp)
-		cameras[i].TiltDown = rewrite(cameras[i].TiltDown)
-		cameras[i].PanTiltStop = rewrite(cameras[i].PanTiltStop)
-		cameras[i].ZoomIn = rewrite(cameras[i].ZoomIn)
-		cameras[i].ZoomOut = rewrite(cameras[i].ZoomOut)
-		cameras[i].ZoomStop = rewrite(cameras[i].ZoomStop)
-		cameras[i].Stream = rewrite(cameras[i].Stream)
-		cameras[i].Reboot = rewrite(cameras[i].Reboot)
+		// cameras with a service are always proxied to it, even if other backends share its host
+		var service string
+		if cameras[i].Structured() {
+			service = cameras[i].Service
+		}
+
+		cameras[i].PanLeft = rewrite(cameras[i].PanLeft, service)
+		cameras[i].PanRight = rewrite(cameras[i].PanRight, service)
+		cameras[i].TiltUp = rewrite(cameras[i].TiltUp, service)
+		cameras[i].TiltDown = rewrite(cameras[i].TiltDown, service)
+		cameras[i].PanTiltStop = rewrite(cameras[i].PanTiltStop, service)
+		cameras[i].ZoomIn = rewrite(cameras[i].ZoomIn, service)
+		cameras[i].ZoomOut = rewrite(cameras[i].ZoomOut, service)
+		cameras[i].ZoomStop = rewrite(cameras[i].ZoomStop, service)
+		cameras[i].Stream = rewrite(cameras[i].Stream, service)
+		cameras[i].Reboot = rewrite(cameras[i].Reboot, service)
 
 		for j := range cameras[i].Presets {
-			cameras[i].Presets[j].SetPreset = rewrite(cameras[i].Presets[j].SetPreset)
-			cameras[i].Presets[j].SavePreset = rewrite(cameras[i].Presets[j].SavePreset)
+			cameras[i].Presets[j].SetPreset = rewrite(cameras[i].Presets[j].SetPreset, service)
+			cameras[i].Presets[j].SavePreset = rewrite(cameras[i].Presets[j].SavePreset, service)
 		}
 	}
 
 	c.JSON(http.StatusOK, cameras)
 }
 
+// cameras gets the cameras in info's control group. Cameras described by their model, address, and service
+// have their urls derived from them, pointed at their service's backend.
+func (h *ControlHandlers) cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	cameras, err := h.ConfigService.Cameras(ctx, info)
+	if err != nil {
+		return cameras, err
+	}
+
+	for i := range cameras {
+		if !cameras[i].Structured() {
+			continue
+		}
+
+		var base string
+		if backend := h.Backends.Backend(cameras[i].Service); backend != nil {
+			u := backend.URL()
+			base = u.Scheme + "://" + u.Host
+		}
+
+		cameras[i] = cameras[i].WithURLs(base)
+	}
+
+	return cameras, nil
+}
+
 func (h *ControlHandlers) GetControlInfo(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 	defer cancel()
@@ -166,51 +241,63 @@ func (h *ControlHandlers) GetControlInfo(c *gin.Context) {
 	})
 }
 
-func (h *ControlHandlers) Proxy(to *url.URL) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// TODO should i validate that they are allowed to get the info for each specific camera?
-		id := c.GetString(_cRequestID)
+// Proxy forwards requests under /proxy/:backend to one of the backend's healthy upstreams.
+func (h *ControlHandlers) Proxy(c *gin.Context) {
+	id := c.GetString(_cRequestID)
 
-		log := h.Logger
-		if len(id) > 0 {
-			log = log.With(zap.String("requestID", id))
-		}
+	log := h.Logger.With(zap.String("backend", c.Param("backend")))
+	if len(id) > 0 {
+		log = log.With(zap.String("requestID", id))
+	}
 
-		defer func() {
-			if err := recover(); err != nil {
-				if err == http.ErrAbortHandler {
-					return
-				}
+	backend := h.Backends.Backend(c.Param("backend"))
+	if backend == nil {
+		c.String(http.StatusNotFound, fmt.Sprintf("unknown backend %q", c.Param("backend")))
+		return
+	}
 
-				panic(err)
-			}
-		}()
-
-		proxy := httputil.ReverseProxy{
-			Director: func(req *http.Request) {
-				req.URL.Scheme = to.Scheme
-				req.URL.Host = to.Host
-
-				switch {
-				case strings.HasPrefix(req.URL.Path, "/proxy/aver"):
-					req.URL.Path = strings.TrimPrefix(c.Request.URL.Path, "/proxy/aver")
-				case strings.HasPrefix(req.URL.Path, "/proxy/axis"):
-					req.URL.Path = strings.TrimPrefix(c.Request.URL.Path, "/proxy/axis")
-				}
+	to, err := backend.Next()
+	if err != nil {
+		log.Warn("unable to proxy request", zap.Error(err))
+		c.String(http.StatusServiceUnavailable, fmt.Sprintf("unable to proxy request: %s", err))
+		return
+	}
 
-				req.Header.Set(_hRequestID, id)
+	defer func() {
+		if err := recover(); err != nil {
+			if err == http.ErrAbortHandler {
+				return
+			}
 
-				log.Debug("Forwarding request to", zap.String("url", req.URL.String()))
-			},
-			ErrorHandler: func(rw http.ResponseWriter, req *http.Request, err error) {
-				log.Warn("error proxying request", zap.Error(err))
-				rw.WriteHeader(http.StatusBadGateway)
-				_, _ = rw.Write([]byte(fmt.Sprintf("unable to proxy request: %s", err)))
-			},
+			panic(err)
 		}
+	}()
+
+	proxy := httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = to.Scheme
+			req.URL.Host = to.Host
+			req.URL.Path = to.Path + c.Param("uri")
+			req.URL.RawPath = ""
+
+			req.Header.Set(_hRequestID, id)
+
+			log.Debug("Forwarding request to", zap.String("url", req.URL.String()))
+		},
+		Transport: backend.Transport(),
+		ErrorHandler: func(rw http.ResponseWriter, req *http.Request, err error) {
+			// the user going away, or a slow command, isn't a sign that the upstream is down
+			if req.Context().Err() == nil && proxy.Unreachable(err) {
+				backend.Fail(to, err)
+			}
 
-		proxy.ServeHTTP(c.Writer, c.Request)
+			log.Warn("error proxying request", zap.String("upstream", to.String()), zap.Error(err))
+			rw.WriteHeader(http.StatusBadGateway)
+			_, _ = rw.Write([]byte(fmt.Sprintf("unable to proxy request: %s", err)))
+		},
 	}
+
+	proxy.ServeHTTP(c.Writer, c.Request)
 }
 
 func (h *ControlHandlers) AuthorizeProxy(c *gin.Context) {
@@ -222,6 +309,8 @@ func (h *ControlHandlers) AuthorizeProxy(c *gin.Context) {
 		authorized = h.AuthService.IsAuthorizedFor(c.Request.Context(), "restart")
 	case strings.Contains(path, "setPreset"):
 		authorized = h.AuthService.IsAuthorizedFor(c.Request.Context(), "setPreset")
+	case strings.Contains(path, "/focus/"), strings.Contains(path, "/exposure/"), strings.Contains(path, "/whiteBalance/"):
+		authorized = h.AuthService.IsAuthorizedFor(c.Request.Context(), "adjustImage")
 	default:
 		authorized = h.AuthService.IsAuthorizedFor(c.Request.Context(), "allow")
 	}
@@ -232,6 +321,13 @@ func (h *ControlHandlers) AuthorizeProxy(c *gin.Context) {
 		return
 	}
 
+	// users can only reach the cameras in control groups they have entered a control key for
+	if !h.DisableAuth && !h.authorizeCamera(c) {
+		c.String(http.StatusForbidden, "Unauthorized")
+		c.Abort()
+		return
+	}
+
 	c.Next()
 }
 
@@ -280,7 +376,7 @@ func (h *ControlHandlers) authorized(c *gin.Context, room, key, controlGroup str
 					if ts, ok := v.(string); ok {
 						if created, err := time.Parse(time.RFC3339, ts); err == nil {
 							switch {
-							case time.Since(created).Hours() >= 8:
+							case time.Since(created) >= controlGroupTTL:
 								delete(controlGroups, cg)
 							case room == roomID && controlGroup == cg:
 								authorized = true
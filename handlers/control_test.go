@@ -1 +1,187 @@
 package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testBackends builds a routing table with one upstream for each backend. Camera urls on
+// camera-services-<backend>.byu.edu belong to the backend.
+func testBackends(t *testing.T, backends map[string]*url.URL) *proxy.Table {
+	routes := make(map[string]proxy.Config)
+	for name, u := range backends {
+		routes[name] = proxy.Config{
+			Upstreams: []string{u.String()},
+			Hosts:     []string{"camera-services-" + name + ".byu.edu"},
+		}
+	}
+
+	table, err := proxy.New(routes, proxy.WithHealthInterval(0))
+	require.NoError(t, err)
+	return table
+}
+
+func TestGetCamerasURLs(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	backend, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	me, err := url.Parse("https://cameras.av.byu.edu")
+	require.NoError(t, err)
+
+	h := &ControlHandlers{
+		ConfigService: &testConfigService{
+			cameras: []cameraservices.CameraConfig{
+				{
+					// the camera's hostname doesn't decide where it is proxied to
+					DisplayName: "Front",
+					Stream:      "http://camera-services-aver.byu.edu/v1/Pro520/ITB-AXIS-CAM1.byu.edu/stream",
+				},
+				{
+					// urls that don't belong to a backend aren't proxied
+					DisplayName: "Side",
+					Stream:      "http://10.5.5.5/stream",
+				},
+				{
+					DisplayName: "Back",
+					Model:       "P5414-E",
+					Address:     "ITB-1101-CAM2.byu.edu",
+					Service:     "axis",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Wide", Preset: "3"},
+					},
+				},
+			},
+		},
+		Logger:      zap.NewNop(),
+		DisableAuth: true,
+		Me:          me,
+		Backends: testBackends(t, map[string]*url.URL{
+			"aver": backend,
+			"axis": backend,
+		}),
+	}
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+
+	h.GetCameras(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var cameras []cameraservices.CameraConfig
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cameras))
+	require.Len(t, cameras, 3)
+
+	require.Equal(t, "https://cameras.av.byu.edu/proxy/aver/v1/Pro520/ITB-AXIS-CAM1.byu.edu/stream", cameras[0].Stream)
+
+	require.Equal(t, "http://10.5.5.5/stream", cameras[1].Stream)
+
+	require.Equal(t, "https://cameras.av.byu.edu/proxy/axis/v1/P5414-E/ITB-1101-CAM2.byu.edu/stream", cameras[2].Stream)
+	require.Equal(t, "https://cameras.av.byu.edu/proxy/axis/v1/P5414-E/ITB-1101-CAM2.byu.edu/pantilt/left", cameras[2].PanLeft)
+	require.Equal(t, "https://cameras.av.byu.edu/proxy/axis/v1/P5414-E/ITB-1101-CAM2.byu.edu/preset/3", cameras[2].Presets[0].SetPreset)
+	require.Equal(t, "https://cameras.av.byu.edu/proxy/axis/v1/P5414-E/ITB-1101-CAM2.byu.edu/savePreset/3", cameras[2].Presets[0].SavePreset)
+}
+
+func TestProxy(t *testing.T) {
+	var paths []string
+	upstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, name+" "+r.URL.Path)
+		}))
+	}
+
+	aver1, aver2 := upstream("aver1"), upstream("aver2")
+	defer aver1.Close()
+	defer aver2.Close()
+
+	backends, err := proxy.New(map[string]proxy.Config{
+		"aver": {Upstreams: []string{aver1.URL, aver2.URL + "/aver"}},
+		"axis": {Upstreams: []string{"http://127.0.0.1:1"}},
+	}, proxy.WithHealthInterval(0))
+	require.NoError(t, err)
+
+	h := &ControlHandlers{
+		Logger:   zap.NewNop(),
+		Backends: backends,
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/proxy/:backend/*uri", h.Proxy)
+
+	// the proxy needs a real connection to the client
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// requests are balanced across upstreams
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/cam/pantilt/stop"))
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/cam/zoom/stop"))
+	require.Equal(t, []string{"aver1 /v1/Pro520/cam/pantilt/stop", "aver2 /aver/v1/Pro520/cam/zoom/stop"}, paths)
+
+	require.Equal(t, http.StatusNotFound, get("/proxy/visca/v1/VISCA/cam/stream"))
+
+	// failed upstreams are taken out of the rotation
+	require.Equal(t, http.StatusBadGateway, get("/proxy/axis/v1/P5414-E/cam/stream"))
+	require.Equal(t, http.StatusServiceUnavailable, get("/proxy/axis/v1/P5414-E/cam/stream"))
+}
+
+func TestProxySlowUpstream(t *testing.T) {
+	var slow int32 = 1
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.CompareAndSwapInt32(&slow, 1, 0) {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer upstream.Close()
+
+	backends, err := proxy.New(map[string]proxy.Config{
+		"aver": {Upstreams: []string{upstream.URL}, Timeout: "50ms"},
+	}, proxy.WithHealthInterval(0))
+	require.NoError(t, err)
+
+	h := &ControlHandlers{
+		Logger:   zap.NewNop(),
+		Backends: backends,
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/proxy/:backend/*uri", h.Proxy)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// a request that times out doesn't take a healthy upstream out of the rotation
+	require.Equal(t, http.StatusBadGateway, get("/proxy/aver/v1/Pro520/cam/preset/1"))
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/cam/preset/1"))
+	require.True(t, backends.Status()["aver"][0].Healthy)
+}
@@ -28,6 +28,14 @@ func (w responseSaver) WriteString(s string) (int, error) {
 
 func (h *CameraController) Publish(action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// the event is flushed before the service exits
+		events := &pending{}
+		if h.drain != nil {
+			events = &h.drain.events
+		}
+
+		events.add()
+
 		info := cameraservices.RequestInfo{
 			Action:    action + c.Param("channel"),
 			Timestamp: time.Now(),
@@ -59,14 +67,27 @@ func (h *CameraController) Publish(action string) gin.HandlerFunc {
 		// run the rest of the handlers
 		c.Next()
 
-		go func(status int) {
+		// add the queue's figures, if the request went through it
+		if depth, ok := c.Get(_cQueueDepth); ok {
+			info.Data[_cQueueDepth] = depth
+			info.Data[_cCoalesced] = c.GetBool(_cCoalesced)
+		}
+
+		if wait, ok := c.Get(_cQueueWait); ok {
+			info.Data[_cQueueWait] = wait.(time.Duration).String()
+			info.Data[_cCommandLatency] = c.GetDuration(_cCommandLatency).String()
+		}
+
+		go func(address string, status int) {
+			defer events.done()
+
 			info.Duration = time.Since(info.Timestamp)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
 
 			var err error
-			info.CameraIP, err = h.getCameraIP(ctx, c.Param("address"))
+			info.CameraIP, err = h.getCameraIP(ctx, address)
 			if err != nil {
 				log.Warn("unable to get camera ip", zap.Error(err))
 			}
@@ -83,6 +104,6 @@ func (h *CameraController) Publish(action string) gin.HandlerFunc {
 			if err != nil {
 				log.Warn("unable to publish event", zap.Error(err))
 			}
-		}(c.Writer.Status())
+		}(c.Param("address"), c.Writer.Status())
 	}
 }
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CameraResult is the outcome of sending a command to a single camera in a control group.
+type CameraResult struct {
+	Camera     string `json:"camera"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// cameraCommand is the list of camera service urls to request (in order) for a camera.
+type cameraCommand struct {
+	camera string
+	urls   []string
+}
+
+// GroupPreset sends every camera in the control group to the preset with the given display name.
+// Cameras without a matching preset are skipped.
+func (h *ControlHandlers) GroupPreset(c *gin.Context) {
+	preset := c.Param("preset")
+
+	h.group(c, "GroupGoToPreset", perCamera(func(cam cameraservices.CameraConfig) ([]string, error) {
+		for _, p := range cam.Presets {
+			if p.DisplayName == preset {
+				return []string{p.SetPreset}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no preset named %q", preset)
+	}))
+}
+
+// GroupStop stops pan/tilt and zoom on every camera in the control group.
+func (h *ControlHandlers) GroupStop(c *gin.Context) {
+	h.group(c, "GroupStop", perCamera(func(cam cameraservices.CameraConfig) ([]string, error) {
+		return []string{cam.PanTiltStop, cam.ZoomStop}, nil
+	}))
+}
+
+// commandBuilder builds the commands to send to a control group's cameras.
+// cameras that can't be sent a command are returned as results with an error.
+type commandBuilder func(*gin.Context, cameraservices.ControlInfo, []cameraservices.CameraConfig) ([]cameraCommand, []CameraResult, bool)
+
+// perCamera builds a command for each camera in the control group using urls.
+func perCamera(urls func(cameraservices.CameraConfig) ([]string, error)) commandBuilder {
+	return func(c *gin.Context, info cameraservices.ControlInfo, cameras []cameraservices.CameraConfig) ([]cameraCommand, []CameraResult, bool) {
+		var (
+			cmds    []cameraCommand
+			skipped []CameraResult
+		)
+
+		for _, cam := range cameras {
+			u, err := urls(cam)
+			if err != nil {
+				skipped = append(skipped, CameraResult{
+					Camera: cam.DisplayName,
+					Error:  err.Error(),
+				})
+				continue
+			}
+
+			cmds = append(cmds, cameraCommand{
+				camera: cam.DisplayName,
+				urls:   u,
+			})
+		}
+
+		return cmds, skipped, true
+	}
+}
+
+// group sends the commands built by build to a control group's cameras in parallel and
+// responds with the result for each camera. If build returns false, it must have already written a response.
+func (h *ControlHandlers) group(c *gin.Context, action string, build commandBuilder) {
+	id := c.GetString(_cRequestID)
+	log := h.Logger
+	if len(id) > 0 {
+		log = log.With(zap.String("requestID", id))
+	}
+
+	info, ok := h.controlInfo(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	cameras, err := h.cameras(ctx, info)
+	if err != nil {
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get cameras: %s", err))
+		return
+	}
+
+	cmds, skipped, ok := build(c, info, cameras)
+	if !ok {
+		return
+	}
+
+	log.Info("Sending command to control group", zap.String("action", action), zap.String("room", info.Room), zap.String("controlGroup", info.ControlGroup), zap.Int("cameras", len(cmds)))
+
+	start := time.Now()
+	results := append(h.fanOut(c.Request.Context(), id, info.ControlKey, cmds), skipped...)
+
+	h.publishGroup(c, action, info, start, results)
+	c.JSON(http.StatusOK, results)
+}
+
+// fanOut sends each camera's commands in parallel, through the same backends as the proxy.
+// A camera's result has the status of its first failed command, and the errors from all of them.
+func (h *ControlHandlers) fanOut(ctx context.Context, requestID, key string, cmds []cameraCommand) []CameraResult {
+	results := make([]CameraResult, len(cmds))
+	wg := sync.WaitGroup{}
+
+	for i := range cmds {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			// every url is tried even if one fails, so that ie. zoom is still stopped if stopping pan/tilt fails
+			var errs []error
+			results[i].Camera = cmds[i].camera
+
+			for _, u := range cmds[i].urls {
+				status, err := h.sendCommand(ctx, requestID, key, u)
+				if len(errs) == 0 {
+					results[i].StatusCode = status
+				}
+
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+
+			if err := errors.Join(errs...); err != nil {
+				results[i].Error = strings.ReplaceAll(err.Error(), "\n", "; ")
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (h *ControlHandlers) sendCommand(ctx context.Context, requestID, key, u string) (int, error) {
+	to, err := h.backendURL(u)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, to.String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.Header.Set(_hRequestID, requestID)
+	req.AddCookie(&http.Cookie{
+		Name:  "control-key",
+		Value: key,
+	})
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("%d response from camera service: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backendURL rewrites a camera service url from the config so that it is sent to the matching proxy backend.
+func (h *ControlHandlers) backendURL(u string) (*url.URL, error) {
+	if u == "" {
+		return nil, errors.New("no url configured")
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse url: %w", err)
+	}
+
+	backend := h.Backends.Backend(h.Backends.Match(parsed))
+	if backend == nil {
+		return nil, fmt.Errorf("no backend for %s", parsed.Host)
+	}
+
+	to, err := backend.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.Scheme = to.Scheme
+	parsed.Host = to.Host
+	parsed.Path = to.Path + parsed.Path
+	parsed.RawPath = ""
+	return parsed, nil
+}
+
+func (h *ControlHandlers) publishGroup(c *gin.Context, action string, info cameraservices.ControlInfo, start time.Time, results []CameraResult) {
+	if h.EventPublisher == nil {
+		return
+	}
+
+	event := cameraservices.RequestInfo{
+		Action:    action,
+		Timestamp: start,
+		Duration:  time.Since(start),
+		SourceIP:  net.ParseIP(c.ClientIP()),
+		Data: map[string]interface{}{
+			"room":         info.Room,
+			"controlGroup": info.ControlGroup,
+			"results":      results,
+		},
+	}
+
+	for _, p := range c.Params {
+		event.Data[p.Key] = p.Value
+	}
+
+	var failed []string
+	for _, res := range results {
+		if res.Error != "" {
+			failed = append(failed, res.Camera)
+		}
+	}
+
+	id := c.GetString(_cRequestID)
+	log := h.Logger
+	if len(id) > 0 {
+		log = log.With(zap.String("requestID", id))
+	}
+
+	h.events.add()
+	go func() {
+		defer h.events.done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		var err error
+		if len(failed) > 0 {
+			err = h.EventPublisher.Error(ctx, cameraservices.RequestError{
+				RequestInfo: event,
+				Error:       fmt.Sprintf("failed on %d/%d cameras: %s", len(failed), len(results), strings.Join(failed, ", ")),
+			})
+		} else {
+			err = h.EventPublisher.Publish(ctx, event)
+		}
+
+		if err != nil {
+			log.Warn("unable to publish event", zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testConfigService is the config service for the package's tests. It has the cameras in each control group,
+// keyed by "room/controlGroup", or if groups is nil, the same cameras in every control group.
+type testConfigService struct {
+	cameras []cameraservices.CameraConfig
+	groups  map[string][]cameraservices.CameraConfig
+}
+
+func (t *testConfigService) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	cameras := t.cameras
+	if t.groups != nil {
+		var ok bool
+		if cameras, ok = t.groups[info.Room+"/"+info.ControlGroup]; !ok {
+			return nil, errors.New("control group not found")
+		}
+	}
+
+	// like the real config services, callers get their own copy
+	copies := make([]cameraservices.CameraConfig, len(cameras))
+	for i := range cameras {
+		copies[i] = cameras[i].Copy()
+	}
+
+	return copies, nil
+}
+
+func (t *testConfigService) ControlIP(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+type testPublisher struct {
+	published chan cameraservices.RequestInfo
+	errors    chan cameraservices.RequestError
+}
+
+func (t *testPublisher) Publish(ctx context.Context, info cameraservices.RequestInfo) error {
+	t.published <- info
+	return nil
+}
+
+func (t *testPublisher) Error(ctx context.Context, err cameraservices.RequestError) error {
+	t.errors <- err
+	return nil
+}
+
+func newGroupTest(t *testing.T) (*ControlHandlers, *testPublisher, *[]string) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if c, err := r.Cookie("control-key"); err != nil || c.Value != "1234" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/v1/Pro520/back/preset/2" || r.URL.Path == "/v1/Pro520/stuck/pantilt/stop" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("camera offline"))
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	backend, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	publisher := &testPublisher{
+		published: make(chan cameraservices.RequestInfo, 1),
+		errors:    make(chan cameraservices.RequestError, 1),
+	}
+
+	h := &ControlHandlers{
+		ConfigService: &testConfigService{
+			cameras: []cameraservices.CameraConfig{
+				{
+					DisplayName: "Front",
+					PanTiltStop: "http://camera-services-aver.byu.edu/v1/Pro520/front/pantilt/stop",
+					ZoomStop:    "http://camera-services-aver.byu.edu/v1/Pro520/front/zoom/stop",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Wide", SetPreset: "http://camera-services-aver.byu.edu/v1/Pro520/front/preset/1"},
+					},
+				},
+				{
+					DisplayName: "Back",
+					PanTiltStop: "http://camera-services-aver.byu.edu/v1/Pro520/back/pantilt/stop",
+					ZoomStop:    "http://camera-services-aver.byu.edu/v1/Pro520/back/zoom/stop",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Wide", SetPreset: "http://camera-services-aver.byu.edu/v1/Pro520/back/preset/2"},
+					},
+				},
+				{
+					DisplayName: "Side",
+				},
+			},
+		},
+		Logger:         zap.NewNop(),
+		DisableAuth:    true,
+		EventPublisher: publisher,
+		Backends: testBackends(t, map[string]*url.URL{
+			"aver": backend,
+		}),
+	}
+
+	return h, publisher, &paths
+}
+
+func TestGroupStop(t *testing.T) {
+	h, publisher, paths := newGroupTest(t)
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras/stop?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+
+	h.GroupStop(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var results []CameraResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	require.Empty(t, results[0].Error)
+	require.Empty(t, results[1].Error)
+	require.Equal(t, "Side", results[2].Camera)
+	require.NotEmpty(t, results[2].Error)
+
+	require.ElementsMatch(t, []string{
+		"/v1/Pro520/front/pantilt/stop",
+		"/v1/Pro520/front/zoom/stop",
+		"/v1/Pro520/back/pantilt/stop",
+		"/v1/Pro520/back/zoom/stop",
+	}, *paths)
+
+	err := <-publisher.errors
+	require.Equal(t, "GroupStop", err.Action)
+}
+
+func TestGroupStopContinues(t *testing.T) {
+	h, _, paths := newGroupTest(t)
+	h.ConfigService = &testConfigService{
+		cameras: []cameraservices.CameraConfig{
+			{
+				DisplayName: "Stuck",
+				PanTiltStop: "http://camera-services-aver.byu.edu/v1/Pro520/stuck/pantilt/stop",
+				ZoomStop:    "http://camera-services-aver.byu.edu/v1/Pro520/stuck/zoom/stop",
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras/stop?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+
+	h.GroupStop(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// zoom is still stopped when stopping pan/tilt fails
+	require.Equal(t, []string{
+		"/v1/Pro520/stuck/pantilt/stop",
+		"/v1/Pro520/stuck/zoom/stop",
+	}, *paths)
+
+	var results []CameraResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	require.Equal(t, http.StatusInternalServerError, results[0].StatusCode)
+	require.NotEmpty(t, results[0].Error)
+}
+
+func TestGroupPreset(t *testing.T) {
+	h, publisher, _ := newGroupTest(t)
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/cameras/preset/Wide?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+	c.Params = gin.Params{{Key: "preset", Value: "Wide"}}
+
+	h.GroupPreset(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var results []CameraResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+
+	require.Equal(t, CameraResult{Camera: "Front", StatusCode: http.StatusOK}, results[0])
+	require.Equal(t, "Back", results[1].Camera)
+	require.Equal(t, http.StatusInternalServerError, results[1].StatusCode)
+	require.Contains(t, results[1].Error, "camera offline")
+	require.Equal(t, "Side", results[2].Camera)
+
+	err := <-publisher.errors
+	require.Equal(t, "GroupGoToPreset", err.Action)
+	require.Equal(t, "Wide", err.Data["preset"])
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (h *CameraController) FocusMode(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.FocusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	mode := c.Param("mode")
+	h.imageCommand(c, "SetFocusMode", "Setting focus mode", func(ctx context.Context) error {
+		return cam.SetFocusMode(ctx, mode)
+	}, zap.String("mode", mode))
+}
+
+func (h *CameraController) FocusNear(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.FocusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	h.imageCommand(c, "FocusNear", "Focusing near", cam.FocusNear)
+}
+
+func (h *CameraController) FocusFar(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.FocusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	h.imageCommand(c, "FocusFar", "Focusing far", cam.FocusFar)
+}
+
+func (h *CameraController) FocusStop(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.FocusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	h.imageCommand(c, "FocusStop", "Stopping focus", cam.FocusStop)
+}
+
+func (h *CameraController) OnePushFocus(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.OnePushFocusCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	h.imageCommand(c, "OnePushFocus", "Triggering one push focus", cam.OnePushFocus)
+}
+
+func (h *CameraController) ExposureMode(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.ExposureCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	mode := c.Param("mode")
+	h.imageCommand(c, "SetExposureMode", "Setting exposure mode", func(ctx context.Context) error {
+		return cam.SetExposureMode(ctx, mode)
+	}, zap.String("mode", mode))
+}
+
+func (h *CameraController) Iris(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.ExposureCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "level must be a number")
+		return
+	}
+
+	h.imageCommand(c, "SetIris", "Setting iris", func(ctx context.Context) error {
+		return cam.SetIris(ctx, level)
+	}, zap.Int("level", level))
+}
+
+func (h *CameraController) BrightnessCompensation(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.ExposureCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "level must be a number")
+		return
+	}
+
+	h.imageCommand(c, "SetBrightnessCompensation", "Setting brightness compensation", func(ctx context.Context) error {
+		return cam.SetBrightnessCompensation(ctx, level)
+	}, zap.Int("level", level))
+}
+
+func (h *CameraController) WhiteBalance(c *gin.Context) {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.WhiteBalanceCamera)
+	if !ok || cam == nil {
+		c.String(http.StatusBadRequest, "not supported")
+		return
+	}
+
+	mode := c.Param("mode")
+	h.imageCommand(c, "SetWhiteBalance", "Setting white balance", func(ctx context.Context) error {
+		return cam.SetWhiteBalance(ctx, mode)
+	}, zap.String("mode", mode))
+}
+
+// imageCommand runs a focus/exposure/white balance command and writes the response.
+func (h *CameraController) imageCommand(c *gin.Context, action, msg string, cmd func(context.Context) error, fields ...zap.Field) {
+	id := c.GetString(_cRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	log := h.Logger
+	if len(id) > 0 {
+		log = log.With(zap.String("requestID", id))
+	}
+
+	log.Info(msg, fields...)
+
+	if err := h.queue(ctx, c, action, cmd); err != nil {
+		log.Warn("unable to send image command", zap.Error(err))
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Info("Done")
+	c.Status(http.StatusOK)
+}
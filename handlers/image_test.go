@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type imageTestCamera struct {
+	goodTestCamera
+
+	whiteBalance string
+	iris         int
+}
+
+func (t *imageTestCamera) SetExposureMode(ctx context.Context, mode string) error {
+	return nil
+}
+
+func (t *imageTestCamera) SetIris(ctx context.Context, level int) error {
+	t.iris = level
+	return nil
+}
+
+func (t *imageTestCamera) SetBrightnessCompensation(ctx context.Context, level int) error {
+	return nil
+}
+
+func (t *imageTestCamera) SetWhiteBalance(ctx context.Context, mode string) error {
+	t.whiteBalance = mode
+	return nil
+}
+
+func newImageTest(cam interface{}, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "", nil)
+	c.Set(_cCamera, cam)
+	c.Params = params
+	return c, resp
+}
+
+func TestWhiteBalance(t *testing.T) {
+	cam := &imageTestCamera{}
+	c, resp := newImageTest(cam, gin.Params{{Key: "mode", Value: "onePush"}})
+
+	h := &CameraController{Logger: zap.NewNop()}
+	h.WhiteBalance(c)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "onePush", cam.whiteBalance)
+}
+
+func TestIris(t *testing.T) {
+	cam := &imageTestCamera{}
+	h := &CameraController{Logger: zap.NewNop()}
+
+	c, resp := newImageTest(cam, gin.Params{{Key: "level", Value: "ten"}})
+	h.Iris(c)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+
+	c, resp = newImageTest(cam, gin.Params{{Key: "level", Value: "10"}})
+	h.Iris(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, 10, cam.iris)
+}
+
+func TestFocusNotSupported(t *testing.T) {
+	c, resp := newImageTest(&imageTestCamera{}, nil)
+
+	h := &CameraController{Logger: zap.NewNop()}
+	h.FocusNear(c)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Equal(t, "not supported", resp.Body.String())
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CameraRequest is a camera to add to (or replace in) a control group.
+// Every url in the camera's config is generated from its model's url template.
+type CameraRequest struct {
+	DisplayName string `json:"displayName"`
+	Model       string `json:"model"`
+	Address     string `json:"address"`
+
+	// Presets are the presets to add to the camera, by display name and the camera's preset number
+	Presets []PresetRequest `json:"presets"`
+}
+
+// PresetRequest is a preset to add to a camera.
+type PresetRequest struct {
+	DisplayName string `json:"displayName"`
+	Preset      string `json:"preset"`
+}
+
+// cameraConfig generates a camera's config from template, which is the base url of its model's routes
+// with {address} in place of the camera's address (ie. http://aver.av.byu.edu/v1/Pro520/{address}).
+func (r CameraRequest) cameraConfig(template string) (cameraservices.CameraConfig, error) {
+	switch {
+	case r.DisplayName == "":
+		return cameraservices.CameraConfig{}, errors.New("displayName is required")
+	case r.Address == "":
+		return cameraservices.CameraConfig{}, errors.New("address is required")
+	case strings.ContainsAny(r.Address, "/?#"):
+		return cameraservices.CameraConfig{}, fmt.Errorf("invalid address %q", r.Address)
+	}
+
+	base := strings.TrimSuffix(strings.ReplaceAll(template, "{address}", r.Address), "/")
+	if _, err := url.Parse(base); err != nil {
+		return cameraservices.CameraConfig{}, fmt.Errorf("invalid url for %s: %w", r.Address, err)
+	}
+
+	cam := cameraservices.CameraConfig{
+		DisplayName: r.DisplayName,
+		TiltUp:      base + "/pantilt/up",
+		TiltDown:    base + "/pantilt/down",
+		PanLeft:     base + "/pantilt/left",
+		PanRight:    base + "/pantilt/right",
+		PanTiltStop: base + "/pantilt/stop",
+		ZoomIn:      base + "/zoom/in",
+		ZoomOut:     base + "/zoom/out",
+		ZoomStop:    base + "/zoom/stop",
+		Stream:      base + "/stream",
+		Reboot:      base + "/reboot",
+		Presets:     []cameraservices.CameraPreset{},
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range r.Presets {
+		switch {
+		case p.DisplayName == "" || p.Preset == "":
+			return cam, errors.New("presets require a displayName and preset")
+		case seen[p.DisplayName]:
+			return cam, fmt.Errorf("preset %q is defined more than once", p.DisplayName)
+		}
+
+		seen[p.DisplayName] = true
+
+		cam.Presets = append(cam.Presets, cameraservices.CameraPreset{
+			DisplayName: p.DisplayName,
+			SetPreset:   base + "/preset/" + url.PathEscape(p.Preset),
+			SavePreset:  base + "/savePreset/" + url.PathEscape(p.Preset),
+		})
+	}
+
+	return cam, nil
+}
+
+// AddCamera adds a camera to the control group in the url.
+func (h *ControlHandlers) AddCamera(c *gin.Context) {
+	var req CameraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, fmt.Sprintf("invalid camera: %s", err))
+		return
+	}
+
+	cam, ok := h.inventoryCamera(c, req)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.InventoryService.AddCamera(ctx, c.Param("room"), c.Param("controlGroup"), cam)
+	if !h.inventoryResult(c, "AddCamera", req, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, cam)
+}
+
+// SetCamera replaces the camera in the url. The camera can be renamed by sending a different displayName.
+func (h *ControlHandlers) SetCamera(c *gin.Context) {
+	var req CameraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.String(http.StatusBadRequest, fmt.Sprintf("invalid camera: %s", err))
+		return
+	}
+
+	if req.DisplayName == "" {
+		req.DisplayName = c.Param("camera")
+	}
+
+	cam, ok := h.inventoryCamera(c, req)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.InventoryService.SetCamera(ctx, c.Param("room"), c.Param("controlGroup"), c.Param("camera"), cam)
+	if !h.inventoryResult(c, "SetCamera", req, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, cam)
+}
+
+// DeleteCamera removes the camera in the url from its control group.
+func (h *ControlHandlers) DeleteCamera(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.InventoryService.DeleteCamera(ctx, c.Param("room"), c.Param("controlGroup"), c.Param("camera"))
+	if !h.inventoryResult(c, "DeleteCamera", CameraRequest{DisplayName: c.Param("camera")}, err) {
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// inventoryCamera generates the config for req. If false is returned, a response has already been written.
+func (h *ControlHandlers) inventoryCamera(c *gin.Context, req CameraRequest) (cameraservices.CameraConfig, bool) {
+	template, ok := h.ModelURLs[req.Model]
+	if !ok {
+		c.String(http.StatusBadRequest, fmt.Sprintf("unknown model %q", req.Model))
+		return cameraservices.CameraConfig{}, false
+	}
+
+	cam, err := req.cameraConfig(template)
+	if err != nil {
+		c.String(http.StatusBadRequest, fmt.Sprintf("invalid camera: %s", err))
+		return cam, false
+	}
+
+	return cam, true
+}
+
+// inventoryResult writes the error response for err, and audits the change if it was made.
+// If false is returned, a response has already been written.
+func (h *ControlHandlers) inventoryResult(c *gin.Context, action string, req CameraRequest, err error) bool {
+	room, cg := c.Param("room"), c.Param("controlGroup")
+
+	switch {
+	case errors.Is(err, cameraservices.ErrCameraNotFound), errors.Is(err, cameraservices.ErrControlGroupNotFound):
+		c.String(http.StatusNotFound, err.Error())
+		return false
+	case errors.Is(err, cameraservices.ErrCameraExists):
+		c.String(http.StatusConflict, err.Error())
+		return false
+	case err != nil:
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to update cameras: %s", err))
+		return false
+	}
+
+	var user string
+	if v, ok := c.Request.Context().Value("user").(string); ok {
+		user = v
+	}
+
+	h.Logger.Info("Updated camera inventory",
+		zap.String("action", action),
+		zap.String("room", room),
+		zap.String("controlGroup", cg),
+		zap.String("camera", req.DisplayName),
+		zap.String("user", user))
+
+	if h.EventPublisher == nil {
+		return true
+	}
+
+	event := cameraservices.RequestInfo{
+		Action:    action,
+		Timestamp: time.Now(),
+		SourceIP:  net.ParseIP(c.ClientIP()),
+		Data: map[string]interface{}{
+			"room":         room,
+			"controlGroup": cg,
+			"camera":       req.DisplayName,
+			"user":         user,
+		},
+	}
+
+	if name := c.Param("camera"); name != "" && name != req.DisplayName {
+		event.Data["previousCamera"] = name
+	}
+
+	if req.Model != "" {
+		event.Data["model"] = req.Model
+		event.Data["address"] = req.Address
+	}
+
+	h.events.add()
+	go func() {
+		defer h.events.done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		if err := h.EventPublisher.Publish(ctx, event); err != nil {
+			h.Logger.Warn("unable to publish inventory event", zap.String("action", action), zap.Error(err))
+		}
+	}()
+
+	return true
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testInventoryService struct {
+	cameras []cameraservices.CameraConfig
+}
+
+func (t *testInventoryService) index(name string) int {
+	for i := range t.cameras {
+		if t.cameras[i].DisplayName == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (t *testInventoryService) AddCamera(ctx context.Context, room, cg string, cam cameraservices.CameraConfig) error {
+	if room != "ITB-1101" {
+		return cameraservices.ErrControlGroupNotFound
+	}
+
+	if t.index(cam.DisplayName) >= 0 {
+		return cameraservices.ErrCameraExists
+	}
+
+	t.cameras = append(t.cameras, cam)
+	return nil
+}
+
+func (t *testInventoryService) SetCamera(ctx context.Context, room, cg, name string, cam cameraservices.CameraConfig) error {
+	i := t.index(name)
+	if i < 0 {
+		return cameraservices.ErrCameraNotFound
+	}
+
+	t.cameras[i] = cam
+	return nil
+}
+
+func (t *testInventoryService) DeleteCamera(ctx context.Context, room, cg, name string) error {
+	i := t.index(name)
+	if i < 0 {
+		return cameraservices.ErrCameraNotFound
+	}
+
+	t.cameras = append(t.cameras[:i], t.cameras[i+1:]...)
+	return nil
+}
+
+func newInventoryTest() (*gin.Engine, *testInventoryService, *testPublisher) {
+	gin.SetMode(gin.TestMode)
+
+	inventory := &testInventoryService{}
+	publisher := &testPublisher{
+		published: make(chan cameraservices.RequestInfo, 1),
+		errors:    make(chan cameraservices.RequestError, 1),
+	}
+
+	h := &ControlHandlers{
+		InventoryService: inventory,
+		EventPublisher:   publisher,
+		Logger:           zap.NewNop(),
+		ModelURLs: map[string]string{
+			"Pro520": "http://aver.av.byu.edu/v1/Pro520/{address}",
+		},
+	}
+
+	r := gin.New()
+	admin := r.Group("/admin/rooms/:room/controlGroups/:controlGroup/cameras")
+	admin.POST("", h.AddCamera)
+	admin.PUT("/:camera", h.SetCamera)
+	admin.DELETE("/:camera", h.DeleteCamera)
+
+	return r, inventory, publisher
+}
+
+func inventoryRequest(t *testing.T, r *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, path, &buf)
+	require.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestAddCamera(t *testing.T) {
+	r, inventory, publisher := newInventoryTest()
+
+	resp := inventoryRequest(t, r, http.MethodPost, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras", CameraRequest{
+		DisplayName: "Front",
+		Model:       "Pro520",
+		Address:     "ITB-1101-CAM1.byu.edu",
+		Presets:     []PresetRequest{{DisplayName: "Podium", Preset: "1"}},
+	})
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	require.Len(t, inventory.cameras, 1)
+	cam := inventory.cameras[0]
+	require.Equal(t, "Front", cam.DisplayName)
+	require.Equal(t, "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/up", cam.TiltUp)
+	require.Equal(t, "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/zoom/stop", cam.ZoomStop)
+	require.Equal(t, "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream", cam.Stream)
+	require.Equal(t, []cameraservices.CameraPreset{{
+		DisplayName: "Podium",
+		SetPreset:   "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/preset/1",
+		SavePreset:  "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/savePreset/1",
+	}}, cam.Presets)
+
+	event := <-publisher.published
+	require.Equal(t, "AddCamera", event.Action)
+	require.Equal(t, "ITB-1101", event.Data["room"])
+	require.Equal(t, "Front", event.Data["camera"])
+	require.Equal(t, "Pro520", event.Data["model"])
+
+	// the same camera can't be added twice
+	resp = inventoryRequest(t, r, http.MethodPost, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras", CameraRequest{
+		DisplayName: "Front",
+		Model:       "Pro520",
+		Address:     "ITB-1101-CAM2.byu.edu",
+	})
+	require.Equal(t, http.StatusConflict, resp.Code)
+
+	for _, req := range []CameraRequest{
+		{DisplayName: "Back", Model: "Unknown", Address: "ITB-1101-CAM2.byu.edu"},
+		{DisplayName: "Back", Model: "Pro520"},
+		{DisplayName: "Back", Model: "Pro520", Address: "ITB-1101-CAM2.byu.edu/stream"},
+		{Model: "Pro520", Address: "ITB-1101-CAM2.byu.edu"},
+		{DisplayName: "Back", Model: "Pro520", Address: "ITB-1101-CAM2.byu.edu", Presets: []PresetRequest{{DisplayName: "Wide"}}},
+	} {
+		resp = inventoryRequest(t, r, http.MethodPost, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras", req)
+		require.Equal(t, http.StatusBadRequest, resp.Code, "%+v", req)
+	}
+
+	resp = inventoryRequest(t, r, http.MethodPost, "/admin/rooms/ITB-1102/controlGroups/ITB-1102/cameras", CameraRequest{
+		DisplayName: "Back",
+		Model:       "Pro520",
+		Address:     "ITB-1102-CAM1.byu.edu",
+	})
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	require.Len(t, inventory.cameras, 1)
+}
+
+func TestSetAndDeleteCamera(t *testing.T) {
+	r, inventory, publisher := newInventoryTest()
+	inventory.cameras = []cameraservices.CameraConfig{{DisplayName: "Front"}}
+
+	resp := inventoryRequest(t, r, http.MethodPut, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras/Front", CameraRequest{
+		DisplayName: "Back",
+		Model:       "Pro520",
+		Address:     "ITB-1101-CAM2.byu.edu",
+	})
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "Back", inventory.cameras[0].DisplayName)
+	require.Equal(t, "http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM2.byu.edu/reboot", inventory.cameras[0].Reboot)
+
+	event := <-publisher.published
+	require.Equal(t, "SetCamera", event.Action)
+	require.Equal(t, "Front", event.Data["previousCamera"])
+
+	resp = inventoryRequest(t, r, http.MethodPut, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras/Front", CameraRequest{
+		Model:   "Pro520",
+		Address: "ITB-1101-CAM1.byu.edu",
+	})
+	require.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = inventoryRequest(t, r, http.MethodDelete, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras/Back", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Empty(t, inventory.cameras)
+
+	event = <-publisher.published
+	require.Equal(t, "DeleteCamera", event.Action)
+	require.Equal(t, "Back", event.Data["camera"])
+
+	resp = inventoryRequest(t, r, http.MethodDelete, "/admin/rooms/ITB-1101/controlGroups/ITB-1101/cameras/Back", nil)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// _defaultKeyTTL is how long the cameras a control key can control are cached for.
+const _defaultKeyTTL = time.Minute
+
+var errKeyDenied = errors.New("control key denied")
+
+// keyAuthorizer decides which cameras a control key can control: the cameras in the key's control group.
+// The cameras for each key are cached, so that every command doesn't look the key and room up again.
+// A nil keyAuthorizer doesn't cache anything.
+type keyAuthorizer struct {
+	grants ttlCache
+}
+
+// keyGrant is the room and control group a control key is for, and the addresses of the cameras in the control group.
+// Grants are shared by every request using the key, so cameras must not be written to.
+type keyGrant struct {
+	room         string
+	controlGroup string
+	cameras      map[string]bool
+}
+
+// authorize checks that key can control the camera at address. errKeyDenied is returned if it can't.
+func (a *keyAuthorizer) authorize(ctx context.Context, keys cameraservices.ControlKeyService, config cameraservices.ConfigService, ttl time.Duration, key, address string) (keyGrant, error) {
+	grant, err := a.grant(ctx, keys, config, ttl, key)
+	if err != nil {
+		return grant, err
+	}
+
+	if !grant.cameras[normalizeAddress(address)] {
+		return grant, fmt.Errorf("%w: %s isn't in %s/%s", errKeyDenied, address, grant.room, grant.controlGroup)
+	}
+
+	return grant, nil
+}
+
+func (a *keyAuthorizer) grant(ctx context.Context, keys cameraservices.ControlKeyService, config cameraservices.ConfigService, ttl time.Duration, key string) (keyGrant, error) {
+	if ttl <= 0 {
+		ttl = _defaultKeyTTL
+	}
+
+	var grants *ttlCache
+	if a != nil {
+		grants = &a.grants
+	}
+
+	v, err := grants.get(key, ttl, func() (interface{}, error) {
+		return lookupGrant(ctx, keys, config, key)
+	})
+
+	grant, _ := v.(keyGrant)
+	return grant, err
+}
+
+// lookupGrant looks up the room and control group key is for, and the cameras in the control group.
+func lookupGrant(ctx context.Context, keys cameraservices.ControlKeyService, config cameraservices.ConfigService, key string) (keyGrant, error) {
+	room, cg, err := keys.RoomAndControlGroup(ctx, key)
+	if err != nil {
+		return keyGrant{}, fmt.Errorf("%w: %s", errKeyDenied, err)
+	}
+
+	cameras, err := config.Cameras(ctx, cameraservices.ControlInfo{Room: room, ControlGroup: cg})
+	if err != nil {
+		return keyGrant{room: room, controlGroup: cg}, fmt.Errorf("unable to get cameras in %s/%s: %w", room, cg, err)
+	}
+
+	grant := keyGrant{
+		room:         room,
+		controlGroup: cg,
+		cameras:      make(map[string]bool, len(cameras)),
+	}
+
+	for _, cam := range cameras {
+		for _, addr := range configAddresses(cam) {
+			grant.cameras[addr] = true
+		}
+	}
+
+	return grant, nil
+}
+
+// configAddresses returns the address of the camera cam configures, from its address or from each of its urls.
+func configAddresses(cam cameraservices.CameraConfig) []string {
+	if cam.Structured() {
+		return []string{normalizeAddress(cam.Address)}
+	}
+
+	urls := []string{cam.Stream, cam.TiltUp, cam.TiltDown, cam.PanLeft, cam.PanRight, cam.PanTiltStop, cam.ZoomIn, cam.ZoomOut, cam.ZoomStop, cam.Reboot}
+	for _, p := range cam.Presets {
+		urls = append(urls, p.SetPreset, p.SavePreset)
+	}
+
+	var addrs []string
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+
+		if addr := cameraAddress(u); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+// cameraAddress returns the address of the camera a configured url is for. Camera service urls
+// (ie. http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream) have the camera's address in their path.
+// Other urls are directly to the camera, and bare addresses are the camera's address.
+func cameraAddress(u string) string {
+	if !strings.Contains(u, "://") {
+		return normalizeAddress(u)
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	split := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 4)
+	if len(split) >= 3 && split[0] == "v1" && split[2] != "" {
+		return normalizeAddress(split[2])
+	}
+
+	return normalizeAddress(parsed.Host)
+}
+
+// normalizeAddress lowercases the host of a host[:port] address, so that addresses can be compared exactly.
+func normalizeAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.ToLower(strings.Trim(addr, "[]"))
+	}
+
+	return net.JoinHostPort(strings.ToLower(host), port)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testKeyService struct {
+	lookups int32
+}
+
+func (t *testKeyService) RoomAndControlGroup(ctx context.Context, key string) (string, string, error) {
+	atomic.AddInt32(&t.lookups, 1)
+
+	switch key {
+	case "1101":
+		return "ITB-1101", "ITB-1101", nil
+	case "1102":
+		return "ITB-1102", "ITB-1102", nil
+	}
+
+	return "", "", errors.New("Invalid Control Key")
+}
+
+func (t *testKeyService) ControlKey(ctx context.Context, room, cg string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func TestCameraAddress(t *testing.T) {
+	tests := map[string]string{
+		"http://aver.av.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream": "itb-1101-cam1.byu.edu",
+		"http://aver.av.byu.edu/v1/Pro520/10.0.0.1:8080/stream":         "10.0.0.1:8080",
+		"rtsp://10.0.0.2:554/axis-media/media.amp":                      "10.0.0.2:554",
+		"ITB-1101-CAM2.byu.edu":                                         "itb-1101-cam2.byu.edu",
+		"[fe80::1]:52381":                                               "[fe80::1]:52381",
+		"http://%zz":                                                    "",
+	}
+
+	for u, addr := range tests {
+		require.Equal(t, addr, cameraAddress(u), u)
+	}
+}
+
+func TestCheckControlKey(t *testing.T) {
+	keys := &testKeyService{}
+
+	h := NewCameraController(&testConfigService{
+		groups: map[string][]cameraservices.CameraConfig{
+			"ITB-1101/ITB-1101": {
+				{Stream: "http://aver.av.byu.edu/v1/Pro520/10.0.0.1/stream"},
+				{Model: "Pro520", Address: "ITB-1101-CAM2.byu.edu", Service: "aver"},
+			},
+			// a control group in the same room, that key 1101 isn't for
+			"ITB-1101/ITB-1101 Back": {
+				{Stream: "http://aver.av.byu.edu/v1/Pro520/10.0.0.3/stream"},
+			},
+		},
+	})
+	h.ControlKeyService = keys
+	h.Logger = zap.NewNop()
+	h.CreateCamera = func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return &goodTestCamera{}, nil
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/Pro520/:address/pantilt/stop", h.CameraMiddleware, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(address, key string) int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v1/Pro520/"+address+"/pantilt/stop", nil)
+		if key != "" {
+			req.AddCookie(&http.Cookie{Name: "control-key", Value: key})
+		}
+
+		r.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	require.Equal(t, http.StatusOK, get("10.0.0.1", "1101"))
+	require.Equal(t, http.StatusOK, get("itb-1101-cam2.byu.edu", "1101"))
+
+	// addresses have to match exactly
+	require.Equal(t, http.StatusForbidden, get("10.0.0.12", "1101"))
+	require.Equal(t, http.StatusForbidden, get("10.0.0.1:8080", "1101"))
+	require.Equal(t, http.StatusForbidden, get("0.0.1", "1101"))
+
+	// keys can only control the cameras in their control group
+	require.Equal(t, http.StatusForbidden, get("10.0.0.3", "1101"))
+
+	// the key's room is only looked up once
+	require.Equal(t, int32(1), atomic.LoadInt32(&keys.lookups))
+
+	require.Equal(t, http.StatusForbidden, get("10.0.0.1", "9999"))
+	require.Equal(t, http.StatusUnauthorized, get("10.0.0.1", ""))
+
+	// the room's cameras couldn't be found
+	require.Equal(t, http.StatusInternalServerError, get("10.0.0.1", "1102"))
+
+	// requests are rejected without a control key service, unless key checks are disabled
+	h.ControlKeyService = nil
+	require.Equal(t, http.StatusInternalServerError, get("10.0.0.1", "1101"))
+
+	h.DisableKeyCheck = true
+	require.Equal(t, http.StatusOK, get("10.0.0.12", ""))
+}
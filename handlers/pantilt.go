@@ -24,7 +24,7 @@ func (h *CameraController) TiltUp(c *gin.Context) {
 
 	log.Info("Tilting up")
 
-	if err := cam.TiltUp(ctx); err != nil {
+	if err := h.queue(ctx, c, "TiltUp", cam.TiltUp); err != nil {
 		log.Warn("unable to tilt up", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -48,7 +48,7 @@ func (h *CameraController) TiltDown(c *gin.Context) {
 
 	log.Info("Tilting down")
 
-	if err := cam.TiltDown(ctx); err != nil {
+	if err := h.queue(ctx, c, "TiltDown", cam.TiltDown); err != nil {
 		log.Warn("unable to tilt down", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -72,7 +72,7 @@ func (h *CameraController) PanLeft(c *gin.Context) {
 
 	log.Info("Panning left")
 
-	if err := cam.PanLeft(ctx); err != nil {
+	if err := h.queue(ctx, c, "PanLeft", cam.PanLeft); err != nil {
 		log.Warn("unable to pan left", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -96,7 +96,7 @@ func (h *CameraController) PanRight(c *gin.Context) {
 
 	log.Info("Panning right")
 
-	if err := cam.PanRight(ctx); err != nil {
+	if err := h.queue(ctx, c, "PanRight", cam.PanRight); err != nil {
 		log.Warn("unable to pan right", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -120,7 +120,7 @@ func (h *CameraController) PanTiltStop(c *gin.Context) {
 
 	log.Info("Stopping pan/tilt")
 
-	if err := cam.PanTiltStop(ctx); err != nil {
+	if err := h.queue(ctx, c, "PanTiltStop", cam.PanTiltStop); err != nil {
 		log.Warn("unable to stop pan/tilt", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
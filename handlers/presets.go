@@ -25,7 +25,10 @@ func (h *CameraController) GoToPreset(c *gin.Context) {
 	preset := c.Param("preset")
 	log.Info("Going to preset", zap.String("preset", preset))
 
-	if err := cam.GoToPreset(ctx, preset); err != nil {
+	err := h.queue(ctx, c, "GoToPreset", func(ctx context.Context) error {
+		return cam.GoToPreset(ctx, preset)
+	})
+	if err != nil {
 		log.Warn("unable to go to preset", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -54,7 +57,10 @@ func (h *CameraController) SavePreset(c *gin.Context) {
 	preset := c.Param("preset")
 	log.Info("Setting preset", zap.String("preset", preset))
 
-	if err := cam.SetPreset(ctx, preset); err != nil {
+	err := h.queue(ctx, c, "SavePreset", func(ctx context.Context) error {
+		return cam.SetPreset(ctx, preset)
+	})
+	if err != nil {
 		log.Warn("unable to set preset", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// controlGroupTTL is how long a control key entered by a user authorizes them for its control group.
+const controlGroupTTL = 8 * time.Hour
+
+// _defaultCameraTTL is how long the cameras in each control group are cached for when authorizing proxied requests.
+const _defaultCameraTTL = time.Minute
+
+// groupCameras caches the cameras in each control group, so that every proxied request doesn't look
+// up every control group the user is authorized for again.
+type groupCameras struct {
+	cache ttlCache
+}
+
+// cameraKey identifies the camera at path (/v1/{model}/{address}) on backend.
+func cameraKey(backend, path string) string {
+	return backend + " " + strings.ToLower(path)
+}
+
+// get returns the cameras in info's control group, calling lookup if they aren't cached. The map is shared
+// by every request authorized against the control group (including concurrent ones), so it must not be written to.
+func (g *groupCameras) get(ctx context.Context, info cameraservices.ControlInfo, ttl time.Duration, lookup func(context.Context, cameraservices.ControlInfo) (map[string]bool, error)) (map[string]bool, error) {
+	if ttl <= 0 {
+		ttl = _defaultCameraTTL
+	}
+
+	v, err := g.cache.get(info.Room+"/"+info.ControlGroup, ttl, func() (interface{}, error) {
+		return lookup(ctx, info)
+	})
+
+	cameras, _ := v.(map[string]bool)
+	return cameras, err
+}
+
+// sessionControlGroups returns the control groups in a session's rooms claim that haven't expired.
+func sessionControlGroups(claim interface{}) []cameraservices.ControlInfo {
+	rooms, ok := claim.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var groups []cameraservices.ControlInfo
+	for room, cgs := range rooms {
+		controlGroups, ok := cgs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for cg, v := range controlGroups {
+			ts, ok := v.(string)
+			if !ok {
+				continue
+			}
+
+			created, err := time.Parse(time.RFC3339, ts)
+			if err != nil || time.Since(created) >= controlGroupTTL {
+				continue
+			}
+
+			groups = append(groups, cameraservices.ControlInfo{Room: room, ControlGroup: cg})
+		}
+	}
+
+	// so that denied requests are reported consistently
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Room != groups[j].Room {
+			return groups[i].Room < groups[j].Room
+		}
+
+		return groups[i].ControlGroup < groups[j].ControlGroup
+	})
+
+	return groups
+}
+
+// cameraPath returns the /v1/{model}/{address} part of a camera service path.
+func cameraPath(uri string) (string, bool) {
+	split := strings.SplitN(strings.TrimPrefix(uri, "/"), "/", 4)
+	if len(split) < 3 || split[0] == "" || split[1] == "" || split[2] == "" {
+		return "", false
+	}
+
+	return "/" + strings.Join(split[:3], "/"), true
+}
+
+// authorizeCamera checks that the camera a proxied request is for is in one of the control groups
+// the user has entered a control key for. Denied requests are logged and published as security events.
+func (h *ControlHandlers) authorizeCamera(c *gin.Context) bool {
+	backend := c.Param("backend")
+
+	target, ok := cameraPath(c.Param("uri"))
+	if !ok {
+		h.deniedCamera(c, nil, "not a camera url")
+		return false
+	}
+
+	session, err := h.SessionStore.Get(c.Request, h.SessionName)
+	if err != nil {
+		h.deniedCamera(c, nil, "invalid session: "+err.Error())
+		return false
+	}
+
+	groups := sessionControlGroups(session.Values[claimAuthorizedRooms])
+	if len(groups) == 0 {
+		h.deniedCamera(c, nil, "no control groups in session")
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	for _, info := range groups {
+		cameras, err := h.groupCameras.get(ctx, info, h.CameraTTL, h.cameraKeys)
+		if err != nil {
+			h.Logger.Warn("unable to get cameras", zap.String("room", info.Room), zap.String("controlGroup", info.ControlGroup), zap.Error(err))
+			continue
+		}
+
+		if cameras[cameraKey(backend, target)] {
+			return true
+		}
+	}
+
+	h.deniedCamera(c, groups, "camera isn't in an authorized control group")
+	return false
+}
+
+// cameraKeys returns the cameraKey of each camera in info's control group.
+func (h *ControlHandlers) cameraKeys(ctx context.Context, info cameraservices.ControlInfo) (map[string]bool, error) {
+	cameras, err := h.cameras(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(cameras))
+	for _, cam := range cameras {
+		base, err := cameraBaseURL(cam)
+		if err != nil {
+			continue
+		}
+
+		parsed, err := url.Parse(base)
+		if err != nil {
+			continue
+		}
+
+		service := cam.Service
+		if !cam.Structured() {
+			service = h.Backends.Match(parsed)
+		}
+
+		if service != "" {
+			keys[cameraKey(service, parsed.Path)] = true
+		}
+	}
+
+	return keys, nil
+}
+
+// deniedCamera logs and publishes a proxied request for a camera the user isn't authorized for.
+func (h *ControlHandlers) deniedCamera(c *gin.Context, groups []cameraservices.ControlInfo, reason string) {
+	var user string
+	if v, ok := c.Request.Context().Value("user").(string); ok {
+		user = v
+	}
+
+	rooms := make([]string, 0, len(groups))
+	for _, info := range groups {
+		rooms = append(rooms, info.Room+"/"+info.ControlGroup)
+	}
+
+	h.Logger.Warn("Denied proxied camera request",
+		zap.String("backend", c.Param("backend")),
+		zap.String("path", c.Param("uri")),
+		zap.String("user", user),
+		zap.String("from", c.ClientIP()),
+		zap.Strings("controlGroups", rooms),
+		zap.String("reason", reason))
+
+	if h.EventPublisher == nil {
+		return
+	}
+
+	event := cameraservices.RequestError{
+		RequestInfo: cameraservices.RequestInfo{
+			Action:    "UnauthorizedCameraAccess",
+			Timestamp: time.Now(),
+			SourceIP:  net.ParseIP(c.ClientIP()),
+			Data: map[string]interface{}{
+				"backend":       c.Param("backend"),
+				"path":          c.Param("uri"),
+				"user":          user,
+				"controlGroups": rooms,
+			},
+		},
+		Error: reason,
+	}
+
+	h.events.add()
+	go func() {
+		defer h.events.done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		if err := h.EventPublisher.Error(ctx, event); err != nil {
+			h.Logger.Warn("unable to publish security event", zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/auth/session/cookiestore"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testAuthService struct{}
+
+func (testAuthService) FillAuth(*gin.Context) {}
+
+func (testAuthService) AuthorizeFor(...string) gin.HandlerFunc {
+	return func(c *gin.Context) {}
+}
+
+func (testAuthService) IsAuthorizedFor(context.Context, ...string) bool {
+	return true
+}
+
+func TestAuthorizeCamera(t *testing.T) {
+	publisher := &testPublisher{
+		published: make(chan cameraservices.RequestInfo, 1),
+		errors:    make(chan cameraservices.RequestError, 1),
+	}
+
+	aver, err := url.Parse("http://camera-services-aver.byu.edu")
+	require.NoError(t, err)
+
+	store := cookiestore.NewStore()
+	h := &ControlHandlers{
+		ConfigService: &testConfigService{
+			groups: map[string][]cameraservices.CameraConfig{
+				"ITB-1101/ITB-1101": {
+					{DisplayName: "Front", Stream: "http://camera-services-aver.byu.edu/v1/Pro520/ITB-1101-CAM1.byu.edu/stream"},
+					{DisplayName: "Back", Model: "P5414-E", Address: "ITB-1101-CAM2.byu.edu", Service: "axis"},
+				},
+				"ITB-1102/ITB-1102": {
+					{DisplayName: "Front", Stream: "http://camera-services-aver.byu.edu/v1/Pro520/ITB-1102-CAM1.byu.edu/stream"},
+				},
+			},
+		},
+		AuthService:    testAuthService{},
+		SessionStore:   store,
+		SessionName:    "test",
+		Logger:         zap.NewNop(),
+		EventPublisher: publisher,
+		Backends: testBackends(t, map[string]*url.URL{
+			"aver": aver,
+			"axis": aver,
+		}),
+	}
+
+	// a session for ITB-1101, and an expired one for ITB-1102
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://cameras.av.byu.edu/api/v1/controlInfo", nil)
+	session, err := store.Get(req, "test")
+	require.NoError(t, err)
+
+	session.Values[claimAuthorizedRooms] = map[string]interface{}{
+		"ITB-1101": map[string]interface{}{"ITB-1101": time.Now().Format(time.RFC3339)},
+		"ITB-1102": map[string]interface{}{"ITB-1102": time.Now().Add(-9 * time.Hour).Format(time.RFC3339)},
+	}
+	require.NoError(t, session.Save(req, resp))
+	cookie := resp.Result().Cookies()[0]
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/proxy/:backend/*uri", h.AuthorizeProxy, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(path string, cookie *http.Cookie) int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+
+		r.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/up", cookie))
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/itb-1101-cam1.byu.edu/stream", cookie))
+	require.Equal(t, http.StatusOK, get("/proxy/axis/v1/P5414-E/ITB-1101-CAM2.byu.edu/preset/1", cookie))
+
+	// the camera has to be on the backend it is configured for
+	require.Equal(t, http.StatusForbidden, get("/proxy/axis/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/up", cookie))
+
+	event := <-publisher.errors
+	require.Equal(t, "UnauthorizedCameraAccess", event.Action)
+	require.Equal(t, "/v1/Pro520/ITB-1101-CAM1.byu.edu/pantilt/up", event.Data["path"])
+	require.Equal(t, []string{"ITB-1101/ITB-1101"}, event.Data["controlGroups"])
+
+	// cameras in rooms the session has expired for, or was never authorized for
+	require.Equal(t, http.StatusForbidden, get("/proxy/aver/v1/Pro520/ITB-1102-CAM1.byu.edu/stream", cookie))
+	<-publisher.errors
+	require.Equal(t, http.StatusForbidden, get("/proxy/aver/v1/Pro520/10.0.0.1/stream", cookie))
+	<-publisher.errors
+
+	require.Equal(t, http.StatusForbidden, get("/proxy/aver/v1/Pro520/ITB-1101-CAM1.byu.edu/stream", nil))
+	event = <-publisher.errors
+	require.Equal(t, "no control groups in session", event.Error)
+
+	require.Equal(t, http.StatusForbidden, get("/proxy/aver/v1", cookie))
+	<-publisher.errors
+
+	// camera authorization is skipped with auth disabled
+	h.DisableAuth = true
+	require.Equal(t, http.StatusOK, get("/proxy/aver/v1/Pro520/10.0.0.1/stream", nil))
+}
+
+func TestGroupCameras(t *testing.T) {
+	var lookups int
+	lookup := func(ctx context.Context, info cameraservices.ControlInfo) (map[string]bool, error) {
+		lookups++
+		if info.Room == "ITB-1103" {
+			return nil, errors.New("room not found")
+		}
+
+		return map[string]bool{cameraKey("aver", "/v1/Pro520/"+info.Room+"-CAM1.byu.edu"): true}, nil
+	}
+
+	var g groupCameras
+	ctx := context.Background()
+	info := cameraservices.ControlInfo{Room: "ITB-1101", ControlGroup: "ITB-1101"}
+
+	// each control group is only looked up once
+	for i := 0; i < 2; i++ {
+		cameras, err := g.get(ctx, info, time.Minute, lookup)
+		require.NoError(t, err)
+		require.True(t, cameras[cameraKey("aver", "/v1/Pro520/itb-1101-cam1.byu.edu")])
+	}
+
+	require.Equal(t, 1, lookups)
+
+	cameras, err := g.get(ctx, cameraservices.ControlInfo{Room: "ITB-1102", ControlGroup: "ITB-1102"}, time.Millisecond, lookup)
+	require.NoError(t, err)
+	require.False(t, cameras[cameraKey("aver", "/v1/Pro520/itb-1101-cam1.byu.edu")])
+	require.Equal(t, 2, lookups)
+
+	// expired control groups are looked up again
+	time.Sleep(5 * time.Millisecond)
+	_, err = g.get(ctx, cameraservices.ControlInfo{Room: "ITB-1102", ControlGroup: "ITB-1102"}, time.Millisecond, lookup)
+	require.NoError(t, err)
+	require.Equal(t, 3, lookups)
+
+	// failed lookups aren't cached
+	for i := 0; i < 2; i++ {
+		_, err = g.get(ctx, cameraservices.ControlInfo{Room: "ITB-1103", ControlGroup: "ITB-1103"}, time.Minute, lookup)
+		require.Error(t, err)
+	}
+
+	require.Equal(t, 5, lookups)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+)
+
+// _commandTimeout is how long a queued command can run for once it is started.
+const _commandTimeout = 5 * time.Second
+
+// the keys the queue's figures for a request are stored under in the gin context, and added to its event with
+const (
+	_cQueueDepth     = "queueDepth"
+	_cQueueWait      = "queueWait"
+	_cCommandLatency = "commandLatency"
+	_cCoalesced      = "coalesced"
+)
+
+// _moves are the actions that start a camera moving, and the movement each is a part of.
+var _moves = map[string]string{
+	"TiltUp":    "pantilt",
+	"TiltDown":  "pantilt",
+	"PanLeft":   "pantilt",
+	"PanRight":  "pantilt",
+	"ZoomIn":    "zoom",
+	"ZoomOut":   "zoom",
+	"FocusNear": "focus",
+	"FocusFar":  "focus",
+}
+
+// _stops are the actions that stop a camera moving, and the movement each stops.
+var _stops = map[string]string{
+	"PanTiltStop": "pantilt",
+	"ZoomStop":    "zoom",
+	"FocusStop":   "focus",
+}
+
+// command is a command waiting in a camera's queue. Every request it was coalesced with waits for it.
+// It isn't tied to any one request, so that the first request going away doesn't fail the others.
+type command struct {
+	action string
+	run    func(context.Context) error
+
+	// waiters is how many requests are still waiting for the command. It is guarded by its queue's mu.
+	waiters int
+
+	queued   time.Time
+	started  time.Time
+	finished time.Time
+	err      error
+	done     chan struct{}
+
+	// superseded are the moves this stop replaced, which finish when it does
+	superseded []*command
+}
+
+func (cmd *command) finish(err error) {
+	cmd.err = err
+	cmd.finished = time.Now()
+	close(cmd.done)
+
+	for _, s := range cmd.superseded {
+		s.started = cmd.started
+		s.finish(err)
+	}
+}
+
+// abandoned reports whether every request waiting for cmd, or for a move it superseded, has given up.
+// Its queue's mu must be held.
+func (cmd *command) abandoned() bool {
+	if cmd.waiters > 0 {
+		return false
+	}
+
+	for _, s := range cmd.superseded {
+		if !s.abandoned() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cameraQueue runs the commands sent to a camera one at a time, in the order they arrived.
+type cameraQueue struct {
+	mu      sync.Mutex
+	pending []*command
+	running bool
+	closed  bool
+}
+
+// enqueue adds cmd to the queue, returning the command to wait for (which is an
+// identical pending command if cmd was coalesced with it) and how many commands are ahead of it.
+func (q *cameraQueue) enqueue(cmd *command) (*command, int, bool) {
+	_, move := _moves[cmd.action]
+	group, stop := _stops[cmd.action]
+
+	depth := len(q.pending)
+	if q.running {
+		depth++
+	}
+
+	// consecutive identical moves (or stops) collapse into the first one
+	if n := len(q.pending); n > 0 && (move || stop) && q.pending[n-1].action == cmd.action {
+		return q.pending[n-1], depth - 1, true
+	}
+
+	// a stop supersedes the moves it would stop
+	if stop {
+		pending := q.pending[:0]
+		for _, p := range q.pending {
+			if _moves[p.action] == group {
+				cmd.superseded = append(cmd.superseded, p)
+				continue
+			}
+
+			pending = append(pending, p)
+		}
+
+		for i := len(pending); i < len(q.pending); i++ {
+			q.pending[i] = nil
+		}
+
+		q.pending = pending
+
+		if n := len(q.pending); n > 0 && q.pending[n-1].action == cmd.action {
+			last := q.pending[n-1]
+			last.superseded = append(last.superseded, cmd.superseded...)
+			return last, depth - 1, true
+		}
+	}
+
+	q.pending = append(q.pending, cmd)
+	return cmd, depth, false
+}
+
+// work runs the queue's commands until it is empty, and then removes it from queues.
+func (q *cameraQueue) work(queues *sync.Map, cam cameraservices.Camera) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.closed = true
+			queues.Delete(cam)
+			q.mu.Unlock()
+			return
+		}
+
+		cmd := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		abandoned := cmd.abandoned()
+		q.mu.Unlock()
+
+		cmd.started = time.Now()
+		if abandoned {
+			cmd.finish(context.Canceled)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), _commandTimeout)
+		cmd.finish(cmd.run(ctx))
+		cancel()
+	}
+}
+
+// queue runs cmd on the request's camera once the commands sent to it before have finished, coalescing it
+// with redundant commands. The queue's figures for the request are added to its event.
+func (h *CameraController) queue(ctx context.Context, c *gin.Context, action string, run func(context.Context) error) error {
+	cam, ok := c.MustGet(_cCamera).(cameraservices.Camera)
+	if !ok {
+		return run(ctx)
+	}
+
+	return h.send(ctx, c, cam, action, run)
+}
+
+// send adds a command running run to cam's queue and waits for it. Commands run for up to _commandTimeout,
+// even if ctx is done first; only the wait for them is cut short. If c isn't nil, the queue's figures are set on it.
+// If h doesn't have queues (ie. it wasn't created with NewCameraController), run is called immediately.
+func (h *CameraController) send(ctx context.Context, c *gin.Context, cam cameraservices.Camera, action string, run func(context.Context) error) error {
+	// track which way the camera is moving, so that it can be stopped on shutdown
+	send := func(ctx context.Context) error {
+		if _, move := _moves[action]; move && h.drain.isDraining() {
+			return errShuttingDown
+		}
+
+		h.drain.track(cam, action)
+		return run(ctx)
+	}
+
+	if h.queues == nil {
+		return send(ctx)
+	}
+
+	cmd := &command{
+		action: action,
+		run:    send,
+		queued: time.Now(),
+		done:   make(chan struct{}),
+	}
+
+	var (
+		q         *cameraQueue
+		wait      *command
+		depth     int
+		coalesced bool
+	)
+
+	for {
+		v, _ := h.queues.LoadOrStore(cam, &cameraQueue{})
+		q = v.(*cameraQueue)
+
+		q.mu.Lock()
+		if q.closed {
+			// its worker just finished; try again with a new queue
+			q.mu.Unlock()
+			continue
+		}
+
+		wait, depth, coalesced = q.enqueue(cmd)
+		wait.waiters++
+		if !q.running {
+			q.running = true
+			go q.work(h.queues, cam)
+		}
+		q.mu.Unlock()
+		break
+	}
+
+	if c != nil {
+		c.Set(_cQueueDepth, depth)
+		c.Set(_cCoalesced, coalesced)
+	}
+
+	select {
+	case <-wait.done:
+	case <-ctx.Done():
+		q.mu.Lock()
+		wait.waiters--
+		q.mu.Unlock()
+
+		return ctx.Err()
+	}
+
+	if c != nil {
+		c.Set(_cQueueWait, wait.started.Sub(cmd.queued))
+		c.Set(_cCommandLatency, wait.finished.Sub(wait.started))
+	}
+
+	return wait.err
+}
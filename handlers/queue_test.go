@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newQueueCommand(action string) *command {
+	return &command{
+		action: action,
+		done:   make(chan struct{}),
+	}
+}
+
+func pendingActions(q *cameraQueue) []string {
+	var actions []string
+	for _, cmd := range q.pending {
+		actions = append(actions, cmd.action)
+	}
+
+	return actions
+}
+
+func TestEnqueueCoalesce(t *testing.T) {
+	q := &cameraQueue{running: true}
+
+	left := newQueueCommand("PanLeft")
+	wait, depth, coalesced := q.enqueue(left)
+	require.Same(t, left, wait)
+	require.Equal(t, 1, depth)
+	require.False(t, coalesced)
+
+	// consecutive identical moves collapse
+	wait, depth, coalesced = q.enqueue(newQueueCommand("PanLeft"))
+	require.Same(t, left, wait)
+	require.Equal(t, 1, depth)
+	require.True(t, coalesced)
+
+	// a different move doesn't
+	q.enqueue(newQueueCommand("ZoomIn"))
+	q.enqueue(newQueueCommand("PanRight"))
+	require.Equal(t, []string{"PanLeft", "ZoomIn", "PanRight"}, pendingActions(q))
+
+	// a stop supersedes the pending moves it stops, but not others
+	stop := newQueueCommand("PanTiltStop")
+	wait, depth, coalesced = q.enqueue(stop)
+	require.Same(t, stop, wait)
+	require.Equal(t, 4, depth)
+	require.False(t, coalesced)
+	require.Equal(t, []string{"ZoomIn", "PanTiltStop"}, pendingActions(q))
+	require.Len(t, stop.superseded, 2)
+
+	// superseded moves finish with the stop
+	stop.finish(nil)
+	<-left.done
+
+	// presets are never coalesced
+	q = &cameraQueue{}
+	q.enqueue(newQueueCommand("GoToPreset"))
+	_, _, coalesced = q.enqueue(newQueueCommand("GoToPreset"))
+	require.False(t, coalesced)
+	require.Equal(t, []string{"GoToPreset", "GoToPreset"}, pendingActions(q))
+}
+
+type queueTestCamera struct {
+	cameraservices.Camera
+}
+
+func newQueueContext(cam cameraservices.Camera) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(_cCamera, cam)
+	return c
+}
+
+func TestCameraControllerQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewCameraController(nil)
+	cam := &queueTestCamera{}
+	ctx := context.Background()
+
+	// hold the queue so that the commands below wait behind it
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = h.queue(ctx, newQueueContext(cam), "GoToPreset", func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	var (
+		mu  sync.Mutex
+		ran []string
+		wg  sync.WaitGroup
+	)
+
+	run := func(action string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			ran = append(ran, action)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	contexts := make([]*gin.Context, 0, 4)
+	for _, action := range []string{"PanLeft", "PanLeft", "PanRight", "PanTiltStop"} {
+		c := newQueueContext(cam)
+		contexts = append(contexts, c)
+
+		wg.Add(1)
+		go func(action string) {
+			defer wg.Done()
+			require.NoError(t, h.queue(ctx, c, action, run(action)))
+		}(action)
+
+		// wait for the command to be queued before sending the next one
+		require.Eventually(t, func() bool {
+			_, ok := c.Get(_cQueueDepth)
+			return ok
+		}, time.Second, time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	// the moves were superseded by the stop
+	require.Equal(t, []string{"PanTiltStop"}, ran)
+
+	require.Equal(t, 1, contexts[0].GetInt(_cQueueDepth))
+	require.False(t, contexts[0].GetBool(_cCoalesced))
+	require.True(t, contexts[1].GetBool(_cCoalesced))
+	require.Equal(t, 3, contexts[3].GetInt(_cQueueDepth))
+
+	for _, c := range contexts {
+		_, ok := c.Get(_cQueueWait)
+		require.True(t, ok)
+		_, ok = c.Get(_cCommandLatency)
+		require.True(t, ok)
+	}
+
+	// the queue is removed once it's empty
+	require.Eventually(t, func() bool {
+		_, ok := h.queues.Load(cam)
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	// a controller without queues runs commands immediately
+	ran = nil
+	require.NoError(t, (&CameraController{}).queue(ctx, newQueueContext(cam), "PanLeft", run("PanLeft")))
+	require.Equal(t, []string{"PanLeft"}, ran)
+}
+
+func TestCameraControllerQueueDetached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewCameraController(nil)
+	cam := &queueTestCamera{}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = h.queue(context.Background(), newQueueContext(cam), "GoToPreset", func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+
+	run := func(action string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			ran = append(ran, action)
+			mu.Unlock()
+			return ctx.Err()
+		}
+	}
+
+	send := func(ctx context.Context, action string) chan error {
+		c := newQueueContext(cam)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- h.queue(ctx, c, action, run(action))
+		}()
+
+		require.Eventually(t, func() bool {
+			_, ok := c.Get(_cQueueDepth)
+			return ok
+		}, time.Second, time.Millisecond)
+		return errs
+	}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	zoomCtx, cancelZoom := context.WithCancel(context.Background())
+
+	first := send(firstCtx, "PanLeft")
+	second := send(context.Background(), "PanLeft")
+	zoom := send(zoomCtx, "ZoomIn")
+
+	// the first request going away doesn't cancel the command it shares with the second
+	cancelFirst()
+	require.True(t, errors.Is(<-first, context.Canceled))
+
+	// a command nobody is waiting for isn't sent
+	cancelZoom()
+	require.True(t, errors.Is(<-zoom, context.Canceled))
+
+	close(release)
+	require.NoError(t, <-second)
+
+	require.Eventually(t, func() bool {
+		_, ok := h.queues.Load(cam)
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"PanLeft"}, ran)
+}
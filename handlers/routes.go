@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+)
+
+// AddDriverRoutes serves every driver in reg under /v1/{model}/:address.
+func (h *CameraController) AddDriverRoutes(r gin.IRouter, middleware Middleware, reg *cameraservices.Registry) {
+	for _, d := range reg.Drivers() {
+		h.AddCameraRoutes(r.Group("/v1/"+d.Model+"/:address"), middleware, d)
+	}
+}
+
+// AddCameraRoutes serves the endpoints for cameras created by d on r, which must have an :address param.
+// The standard endpoints are always served; the optional ones are only served if d supports them.
+// Events are only published if h has an EventPublisher.
+func (h *CameraController) AddCameraRoutes(r gin.IRouter, middleware Middleware, d cameraservices.Driver) {
+	dh := *h
+	dh.CreateCamera = d.NewCamera
+
+	publish := func(action string) gin.HandlerFunc {
+		if dh.EventPublisher == nil {
+			return func(c *gin.Context) {}
+		}
+
+		return dh.Publish(action)
+	}
+
+	g := r.Group("", middleware.RequestID, middleware.Log, dh.CameraMiddleware)
+	g.GET("/pantilt/up", publish("TiltUp"), dh.TiltUp)
+	g.GET("/pantilt/down", publish("TiltDown"), dh.TiltDown)
+	g.GET("/pantilt/left", publish("PanLeft"), dh.PanLeft)
+	g.GET("/pantilt/right", publish("PanRight"), dh.PanRight)
+	g.GET("/pantilt/stop", publish("PanTiltStop"), dh.PanTiltStop)
+	g.GET("/zoom/in", publish("ZoomIn"), dh.ZoomIn)
+	g.GET("/zoom/out", publish("ZoomOut"), dh.ZoomOut)
+	g.GET("/zoom/stop", publish("ZoomStop"), dh.ZoomStop)
+	g.GET("/preset/:preset", publish("GoToPreset"), dh.GoToPreset)
+	g.GET("/stream", publish("Stream"), dh.Stream)
+	g.GET("/capabilities", dh.Capabilities)
+
+	if d.Supports(cameraservices.CapabilityAdmin) {
+		g.GET("/reboot", publish("Reboot"), dh.Reboot)
+		g.GET("/savePreset/:preset", publish("SavePreset"), dh.SavePreset)
+	}
+
+	if d.Supports(cameraservices.CapabilityStatus) {
+		g.GET("/status", dh.Status)
+	}
+
+	if d.Supports(cameraservices.CapabilityFocus) {
+		g.GET("/focus/near", publish("FocusNear"), dh.FocusNear)
+		g.GET("/focus/far", publish("FocusFar"), dh.FocusFar)
+		g.GET("/focus/stop", publish("FocusStop"), dh.FocusStop)
+		g.GET("/focus/mode/:mode", publish("SetFocusMode"), dh.FocusMode)
+	}
+
+	if d.Supports(cameraservices.CapabilityOnePushFocus) {
+		g.GET("/focus/onePush", publish("OnePushFocus"), dh.OnePushFocus)
+	}
+
+	if d.Supports(cameraservices.CapabilityExposure) {
+		g.GET("/exposure/mode/:mode", publish("SetExposureMode"), dh.ExposureMode)
+		g.GET("/exposure/iris/:level", publish("SetIris"), dh.Iris)
+		g.GET("/exposure/brightness/:level", publish("SetBrightnessCompensation"), dh.BrightnessCompensation)
+	}
+
+	if d.Supports(cameraservices.CapabilityWhiteBalance) {
+		g.GET("/whiteBalance/:mode", publish("SetWhiteBalance"), dh.WhiteBalance)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAddDriverRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	driver := func(model string, cam cameraservices.Camera) cameraservices.Driver {
+		return cameraservices.Driver{
+			Model: model,
+			NewCamera: func(context.Context, string) (cameraservices.Camera, error) {
+				return cam, nil
+			},
+			Capabilities: cameraservices.CapabilitiesOf(cam),
+		}
+	}
+
+	var reg cameraservices.Registry
+	require.NoError(t, reg.Register(driver("Basic", &goodTestCamera{})))
+	require.NoError(t, reg.Register(driver("Image", &imageTestCamera{})))
+
+	err := reg.Register(driver("Basic", &imageTestCamera{}))
+	require.True(t, errors.Is(err, cameraservices.ErrDriverExists))
+
+	r := gin.New()
+	h := &CameraController{Logger: zap.NewNop()}
+	h.AddDriverRoutes(r, Middleware{Logger: zap.NewNop()}, &reg)
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		require.Equal(t, http.MethodGet, route.Method)
+		routes[route.Path] = true
+	}
+
+	require.True(t, routes["/v1/Basic/:address/pantilt/up"])
+	require.True(t, routes["/v1/Basic/:address/capabilities"])
+	require.False(t, routes["/v1/Basic/:address/exposure/iris/:level"])
+	require.False(t, routes["/v1/Basic/:address/reboot"])
+
+	require.True(t, routes["/v1/Image/:address/preset/:preset"])
+	require.True(t, routes["/v1/Image/:address/exposure/iris/:level"])
+	require.True(t, routes["/v1/Image/:address/whiteBalance/:mode"])
+	require.False(t, routes["/v1/Image/:address/focus/near"])
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// controlInfo binds the control info from the query and makes sure the user is allowed to control it.
+// If false is returned, a response has already been written.
+func (h *ControlHandlers) controlInfo(c *gin.Context) (cameraservices.ControlInfo, bool) {
+	var info cameraservices.ControlInfo
+	if err := c.BindQuery(&info); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return info, false
+	}
+
+	if !h.DisableAuth && !h.authorized(c, info.Room, info.ControlKey, info.ControlGroup) {
+		c.Status(http.StatusUnauthorized)
+		return info, false
+	}
+
+	return info, true
+}
+
+func (h *ControlHandlers) GetScenes(c *gin.Context) {
+	info, ok := h.controlInfo(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	scenes, err := h.SceneService.Scenes(ctx, info.Room, info.ControlGroup)
+	if err != nil {
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get scenes: %s", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, scenes)
+}
+
+func (h *ControlHandlers) GetScene(c *gin.Context) {
+	info, ok := h.controlInfo(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	scene, err := h.SceneService.Scene(ctx, info.Room, info.ControlGroup, c.Param("scene"))
+	switch {
+	case errors.Is(err, cameraservices.ErrSceneNotFound):
+		c.String(http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get scene: %s", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, scene)
+}
+
+// SetScene creates or replaces a scene. Each preset in the scene must exist on a camera in the control group.
+func (h *ControlHandlers) SetScene(c *gin.Context) {
+	info, ok := h.controlInfo(c)
+	if !ok {
+		return
+	}
+
+	var scene cameraservices.Scene
+	if err := c.ShouldBindJSON(&scene); err != nil {
+		c.String(http.StatusBadRequest, fmt.Sprintf("invalid scene: %s", err))
+		return
+	}
+
+	scene.Name = c.Param("scene")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	cameras, err := h.ConfigService.Cameras(ctx, info)
+	if err != nil {
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get cameras: %s", err))
+		return
+	}
+
+	if len(scene.Presets) == 0 {
+		c.String(http.StatusBadRequest, "scene must include at least one preset")
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, sel := range scene.Presets {
+		if seen[sel.Camera] {
+			c.String(http.StatusBadRequest, fmt.Sprintf("camera %q is in the scene more than once", sel.Camera))
+			return
+		}
+
+		seen[sel.Camera] = true
+
+		if _, err := presetURL(cameras, sel); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.SceneService.SetScene(ctx, info.Room, info.ControlGroup, scene); err != nil {
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to set scene: %s", err))
+		return
+	}
+
+	h.Logger.Info("Set scene", zap.String("room", info.Room), zap.String("controlGroup", info.ControlGroup), zap.String("scene", scene.Name))
+	c.JSON(http.StatusOK, scene)
+}
+
+func (h *ControlHandlers) DeleteScene(c *gin.Context) {
+	info, ok := h.controlInfo(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.SceneService.DeleteScene(ctx, info.Room, info.ControlGroup, c.Param("scene"))
+	switch {
+	case errors.Is(err, cameraservices.ErrSceneNotFound):
+		c.String(http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		c.String(http.StatusInternalServerError, fmt.Sprintf("unable to delete scene: %s", err))
+		return
+	}
+
+	h.Logger.Info("Deleted scene", zap.String("room", info.Room), zap.String("controlGroup", info.ControlGroup), zap.String("scene", c.Param("scene")))
+	c.Status(http.StatusOK)
+}
+
+// RecallScene sends each camera in the scene to its preset, in parallel.
+func (h *ControlHandlers) RecallScene(c *gin.Context) {
+	h.group(c, "RecallScene", func(c *gin.Context, info cameraservices.ControlInfo, cameras []cameraservices.CameraConfig) ([]cameraCommand, []CameraResult, bool) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+
+		scene, err := h.SceneService.Scene(ctx, info.Room, info.ControlGroup, c.Param("scene"))
+		switch {
+		case errors.Is(err, cameraservices.ErrSceneNotFound):
+			c.String(http.StatusNotFound, err.Error())
+			return nil, nil, false
+		case err != nil:
+			c.String(http.StatusInternalServerError, fmt.Sprintf("unable to get scene: %s", err))
+			return nil, nil, false
+		}
+
+		var (
+			cmds    []cameraCommand
+			skipped []CameraResult
+		)
+
+		for _, sel := range scene.Presets {
+			u, err := presetURL(cameras, sel)
+			if err != nil {
+				skipped = append(skipped, CameraResult{
+					Camera: sel.Camera,
+					Error:  err.Error(),
+				})
+				continue
+			}
+
+			cmds = append(cmds, cameraCommand{
+				camera: sel.Camera,
+				urls:   []string{u},
+			})
+		}
+
+		return cmds, skipped, true
+	})
+}
+
+// presetURL finds the setPreset url for the selected camera/preset.
+func presetURL(cameras []cameraservices.CameraConfig, sel cameraservices.ScheduledPreset) (string, error) {
+	for _, cam := range cameras {
+		if cam.DisplayName != sel.Camera {
+			continue
+		}
+
+		for _, p := range cam.Presets {
+			if p.DisplayName == sel.Preset {
+				return p.SetPreset, nil
+			}
+		}
+
+		return "", fmt.Errorf("camera %q has no preset named %q", sel.Camera, sel.Preset)
+	}
+
+	return "", fmt.Errorf("no camera named %q", sel.Camera)
+}
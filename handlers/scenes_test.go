@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type testSceneService struct {
+	scenes []cameraservices.Scene
+}
+
+func (t *testSceneService) Scenes(ctx context.Context, room, cg string) ([]cameraservices.Scene, error) {
+	return t.scenes, nil
+}
+
+func (t *testSceneService) Scene(ctx context.Context, room, cg, name string) (cameraservices.Scene, error) {
+	for _, s := range t.scenes {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return cameraservices.Scene{}, cameraservices.ErrSceneNotFound
+}
+
+func (t *testSceneService) SetScene(ctx context.Context, room, cg string, scene cameraservices.Scene) error {
+	t.scenes = append(t.scenes, scene)
+	return nil
+}
+
+func (t *testSceneService) DeleteScene(ctx context.Context, room, cg, name string) error {
+	return cameraservices.ErrSceneNotFound
+}
+
+func TestSetScene(t *testing.T) {
+	h, _, _ := newGroupTest(t)
+	scenes := &testSceneService{}
+	h.SceneService = scenes
+
+	gin.SetMode(gin.TestMode)
+
+	body, err := json.Marshal(cameraservices.Scene{
+		Presets: []cameraservices.ScheduledPreset{{Camera: "Front", Preset: "Wide"}},
+	})
+	require.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodPut, "/api/v1/scenes/Lecture?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", bytes.NewReader(body))
+	c.Params = gin.Params{{Key: "scene", Value: "Lecture"}}
+
+	h.SetScene(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, scenes.scenes, 1)
+	require.Equal(t, "Lecture", scenes.scenes[0].Name)
+
+	// presets that don't exist are rejected
+	body, err = json.Marshal(cameraservices.Scene{
+		Presets: []cameraservices.ScheduledPreset{{Camera: "Front", Preset: "Podium"}},
+	})
+	require.NoError(t, err)
+
+	resp = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodPut, "/api/v1/scenes/Panel?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", bytes.NewReader(body))
+	c.Params = gin.Params{{Key: "scene", Value: "Panel"}}
+
+	h.SetScene(c)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Len(t, scenes.scenes, 1)
+}
+
+func TestRecallScene(t *testing.T) {
+	h, publisher, paths := newGroupTest(t)
+	h.SceneService = &testSceneService{
+		scenes: []cameraservices.Scene{
+			{
+				Name: "Lecture",
+				Presets: []cameraservices.ScheduledPreset{
+					{Camera: "Front", Preset: "Wide"},
+				},
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/scenes/Lecture/recall?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+	c.Params = gin.Params{{Key: "scene", Value: "Lecture"}}
+
+	h.RecallScene(c)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var results []CameraResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+	require.Equal(t, []CameraResult{{Camera: "Front", StatusCode: http.StatusOK}}, results)
+	require.Equal(t, []string{"/v1/Pro520/front/preset/1"}, *paths)
+
+	info := <-publisher.published
+	require.Equal(t, "RecallScene", info.Action)
+	require.Equal(t, "Lecture", info.Data["scene"])
+}
+
+func TestRecallSceneNotFound(t *testing.T) {
+	h, _, _ := newGroupTest(t)
+	h.SceneService = &testSceneService{}
+
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/v1/scenes/Lecture/recall?room=ITB-1101&controlGroup=ITB-1101&controlKey=1234", nil)
+	c.Params = gin.Params{{Key: "scene", Value: "Lecture"}}
+
+	h.RecallScene(c)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+}
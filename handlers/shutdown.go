@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+var errShuttingDown = errors.New("service is shutting down")
+
+// _stopFuncs return the command that stops each movement on a camera.
+var _stopFuncs = map[string]func(cameraservices.Camera) func(context.Context) error{
+	"pantilt": func(cam cameraservices.Camera) func(context.Context) error {
+		return cam.PanTiltStop
+	},
+	"zoom": func(cam cameraservices.Camera) func(context.Context) error {
+		return cam.ZoomStop
+	},
+	"focus": func(cam cameraservices.Camera) func(context.Context) error {
+		if fCam, ok := cam.(cameraservices.FocusCamera); ok {
+			return fCam.FocusStop
+		}
+
+		return func(context.Context) error { return nil }
+	},
+}
+
+// _stopActions are the actions that stop each movement.
+var _stopActions = map[string]string{
+	"pantilt": "PanTiltStop",
+	"zoom":    "ZoomStop",
+	"focus":   "FocusStop",
+}
+
+// movement is a camera that may be moving in a direction that hasn't been stopped.
+type movement struct {
+	cam   cameraservices.Camera
+	group string
+}
+
+// drain keeps track of what a CameraController needs to clean up before the service exits.
+type drain struct {
+	once sync.Once
+	done chan struct{}
+
+	// moving are the movements that haven't been stopped
+	moving sync.Map
+
+	events pending
+}
+
+func newDrain() *drain {
+	return &drain{
+		done: make(chan struct{}),
+	}
+}
+
+// draining returns a channel that is closed once the controller starts shutting down.
+// A nil drain (ie. a controller not created with NewCameraController) never shuts down.
+func (d *drain) draining() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+
+	return d.done
+}
+
+func (d *drain) isDraining() bool {
+	select {
+	case <-d.draining():
+		return true
+	default:
+		return false
+	}
+}
+
+// track records that cam is moving (or has stopped) after it was sent action.
+func (d *drain) track(cam cameraservices.Camera, action string) {
+	if d == nil {
+		return
+	}
+
+	if group, ok := _moves[action]; ok {
+		d.moving.Store(movement{cam: cam, group: group}, struct{}{})
+	}
+
+	if group, ok := _stops[action]; ok {
+		d.moving.Delete(movement{cam: cam, group: group})
+	}
+}
+
+// pending counts the events that are still being published, so that they can be flushed on shutdown.
+type pending struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (p *pending) add() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n++
+}
+
+func (p *pending) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n--
+	if p.n == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// wait waits until there are no events being published, or ctx is done.
+func (p *pending) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if p.n == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+
+	if p.idle == nil {
+		p.idle = make(chan struct{})
+	}
+
+	idle := p.idle
+	p.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown prepares h for the service to exit. Active streams are ended, new move commands are refused,
+// cameras that are still moving are stopped, and the events still being published are flushed.
+// It returns once all of that is done, or ctx is done.
+func (h *CameraController) Shutdown(ctx context.Context) error {
+	if h.drain == nil {
+		return nil
+	}
+
+	h.drain.once.Do(func() {
+		close(h.drain.done)
+	})
+
+	var wg sync.WaitGroup
+	h.drain.moving.Range(func(k, _ interface{}) bool {
+		m := k.(movement)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			log := h.Logger.With(zap.String("address", m.cam.RemoteAddr()), zap.String("movement", m.group))
+			log.Info("Stopping camera")
+
+			// the stop waits in the camera's queue, behind the commands already sent to it
+			if err := h.send(ctx, nil, m.cam, _stopActions[m.group], _stopFuncs[m.group](m.cam)); err != nil {
+				log.Warn("unable to stop camera", zap.Error(err))
+				return
+			}
+
+			h.drain.moving.Delete(m)
+		}()
+
+		return true
+	})
+	wg.Wait()
+
+	return h.drain.events.wait(ctx)
+}
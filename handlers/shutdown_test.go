@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type shutdownTestCamera struct {
+	cameraservices.Camera
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (c *shutdownTestCamera) send(cmd string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sent = append(c.sent, cmd)
+	return nil
+}
+
+func (c *shutdownTestCamera) commands() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.sent...)
+}
+
+func (c *shutdownTestCamera) RemoteAddr() string                       { return "shutdown" }
+func (c *shutdownTestCamera) PanLeft(context.Context) error            { return c.send("PanLeft") }
+func (c *shutdownTestCamera) PanTiltStop(context.Context) error        { return c.send("PanTiltStop") }
+func (c *shutdownTestCamera) ZoomIn(context.Context) error             { return c.send("ZoomIn") }
+func (c *shutdownTestCamera) ZoomStop(context.Context) error           { return c.send("ZoomStop") }
+func (c *shutdownTestCamera) GoToPreset(context.Context, string) error { return c.send("GoToPreset") }
+
+func TestShutdown(t *testing.T) {
+	h := NewCameraController(nil)
+	h.Logger = zap.NewNop()
+
+	cam := &shutdownTestCamera{}
+	ctx := context.Background()
+
+	require.NoError(t, h.queue(ctx, newQueueContext(cam), "PanLeft", cam.PanLeft))
+	require.NoError(t, h.queue(ctx, newQueueContext(cam), "ZoomIn", cam.ZoomIn))
+	require.NoError(t, h.queue(ctx, newQueueContext(cam), "ZoomStop", cam.ZoomStop))
+
+	// pending events are flushed
+	h.drain.events.add()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		h.drain.events.done()
+	}()
+
+	require.NoError(t, h.Shutdown(ctx))
+
+	// only the camera that was still panning is stopped
+	require.Equal(t, []string{"PanLeft", "ZoomIn", "ZoomStop", "PanTiltStop"}, cam.commands())
+
+	// new moves are refused, but other commands still run
+	require.Equal(t, errShuttingDown, h.queue(ctx, newQueueContext(cam), "PanLeft", cam.PanLeft))
+	require.NoError(t, h.queue(ctx, newQueueContext(cam), "GoToPreset", func(ctx context.Context) error {
+		return cam.GoToPreset(ctx, "1")
+	}))
+	require.Equal(t, []string{"PanLeft", "ZoomIn", "ZoomStop", "PanTiltStop", "GoToPreset"}, cam.commands())
+
+	// shutting down again is fine
+	require.NoError(t, h.Shutdown(ctx))
+
+	// controllers not created with NewCameraController have nothing to clean up
+	require.NoError(t, (&CameraController{}).Shutdown(ctx))
+}
+
+func TestShutdownQueuesStops(t *testing.T) {
+	h := NewCameraController(nil)
+	h.Logger = zap.NewNop()
+
+	cam := &shutdownTestCamera{}
+	ctx := context.Background()
+
+	require.NoError(t, h.queue(ctx, newQueueContext(cam), "PanLeft", cam.PanLeft))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	preset := make(chan error, 1)
+
+	go func() {
+		preset <- h.queue(ctx, newQueueContext(cam), "GoToPreset", func(ctx context.Context) error {
+			close(started)
+			<-release
+			return cam.GoToPreset(ctx, "1")
+		})
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- h.Shutdown(ctx)
+	}()
+
+	// the stop waits for the preset to finish
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, []string{"PanLeft"}, cam.commands())
+
+	close(release)
+	require.NoError(t, <-preset)
+	require.NoError(t, <-shutdown)
+	require.Equal(t, []string{"PanLeft", "GoToPreset", "PanTiltStop"}, cam.commands())
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	h := NewCameraController(nil)
+	h.Logger = zap.NewNop()
+
+	// an event that never finishes publishing
+	h.drain.events.add()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, context.DeadlineExceeded, h.Shutdown(ctx))
+}
@@ -34,6 +34,11 @@ func (h *CameraController) Stream(c *gin.Context) {
 		log = log.With(zap.String("requestID", id))
 	}
 
+	if h.drain.isDraining() {
+		c.String(http.StatusServiceUnavailable, errShuttingDown.Error())
+		return
+	}
+
 	log.Info("Subscribing to stream")
 
 	// get the stream, or start it
@@ -115,6 +120,9 @@ func (h *CameraController) Stream(c *gin.Context) {
 		case <-s.done:
 			log.Info("Finished streaming", zap.String("reason", "done chan closed"))
 			return
+		case <-h.drain.draining():
+			log.Info("Finished streaming", zap.String("reason", errShuttingDown.Error()))
+			return
 		}
 	}
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ttlCache caches the result of looking up each key for a while. Concurrent lookups of the same key share
+// one call, and failed lookups aren't cached. The zero value is ready to use, and a nil ttlCache doesn't cache anything.
+type ttlCache struct {
+	mu      sync.Mutex
+	entries map[string]ttlEntry
+	single  singleflight.Group
+}
+
+type ttlEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// get returns the cached value for key, calling lookup and caching its value for ttl if there isn't one.
+// If lookup fails, its value is returned with its error. Values are shared by every caller of get
+// (concurrently and until they expire), so they must not be written to.
+func (c *ttlCache) get(key string, ttl time.Duration, lookup func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return lookup()
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	v, err, _ := c.single.Do(key, func() (interface{}, error) {
+		v, err := lookup()
+		if err != nil {
+			return v, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.entries == nil {
+			c.entries = make(map[string]ttlEntry)
+		}
+
+		// drop expired keys, so that the cache doesn't grow forever
+		for k, e := range c.entries {
+			if time.Now().After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+
+		c.entries[key] = ttlEntry{
+			value:   v,
+			expires: time.Now().Add(ttl),
+		}
+
+		return v, nil
+	})
+
+	return v, err
+}
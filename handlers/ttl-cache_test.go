@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCache(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		lookups int
+	)
+
+	release := make(chan struct{})
+	lookup := func() (interface{}, error) {
+		mu.Lock()
+		lookups++
+		mu.Unlock()
+
+		<-release
+		return "value", nil
+	}
+
+	var c ttlCache
+
+	// concurrent lookups of a key share one call
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := c.get("key", time.Minute, lookup)
+			require.NoError(t, err)
+			require.Equal(t, "value", v)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	_, err := c.get("key", time.Minute, lookup)
+	require.NoError(t, err)
+	require.Equal(t, 1, lookups)
+
+	// failed lookups return their value, but aren't cached
+	fail := func() (interface{}, error) {
+		lookups++
+		return "partial", errors.New("lookup failed")
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := c.get("failed", time.Minute, fail)
+		require.Error(t, err)
+		require.Equal(t, "partial", v)
+	}
+
+	require.Equal(t, 3, lookups)
+
+	// a nil cache looks up every time
+	var nilCache *ttlCache
+	for i := 0; i < 2; i++ {
+		_, err = nilCache.get("key", time.Minute, lookup)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 5, lookups)
+}
@@ -24,7 +24,7 @@ func (h *CameraController) ZoomIn(c *gin.Context) {
 
 	log.Info("Zooming in")
 
-	if err := cam.ZoomIn(ctx); err != nil {
+	if err := h.queue(ctx, c, "ZoomIn", cam.ZoomIn); err != nil {
 		log.Warn("unable to zoom in", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -48,7 +48,7 @@ func (h *CameraController) ZoomOut(c *gin.Context) {
 
 	log.Info("Zooming out")
 
-	if err := cam.ZoomOut(ctx); err != nil {
+	if err := h.queue(ctx, c, "ZoomOut", cam.ZoomOut); err != nil {
 		log.Warn("unable to zoom out", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -72,7 +72,7 @@ func (h *CameraController) ZoomStop(c *gin.Context) {
 
 	log.Info("Stopping zoom")
 
-	if err := cam.ZoomStop(ctx); err != nil {
+	if err := h.queue(ctx, c, "ZoomStop", cam.ZoomStop); err != nil {
 		log.Warn("unable to stop zoom", zap.Error(err))
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,307 @@
+// Package health polls every camera in the config database and keeps track of which ones are working.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+// ConfigService is used to find every camera. It is implemented by the couch config service.
+type ConfigService interface {
+	Rooms(context.Context) ([]string, error)
+	ControlGroups(context.Context, string) ([]string, error)
+	Cameras(context.Context, cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error)
+}
+
+// CameraHealth is the result of the most recent probe of a camera.
+type CameraHealth struct {
+	Room         string `json:"room"`
+	ControlGroup string `json:"controlGroup"`
+	Camera       string `json:"camera"`
+	Address      string `json:"address"`
+
+	Up        bool `json:"up"`
+	Reachable bool `json:"reachable"`
+	Streaming bool `json:"streaming"`
+
+	// Status is the camera's reply to a status inquiry, if it supports them
+	Status *cameraservices.Status `json:"status,omitempty"`
+	Errors []string               `json:"errors,omitempty"`
+
+	LastChecked time.Time `json:"lastChecked"`
+	// LastChange is when the camera last went up or down
+	LastChange time.Time `json:"lastChange"`
+}
+
+// Poller probes every camera on an interval.
+type Poller struct {
+	ConfigService     ConfigService
+	ControlKeyService interface {
+		ControlKey(context.Context, string, string) (string, error)
+	}
+
+	// Services are the urls of the camera services that cameras described by their model, address,
+	// and service are probed through
+	Services cameraservices.CameraServices
+
+	// EventPublisher is optional; up/down transitions are published through it when set
+	EventPublisher cameraservices.EventPublisher
+	Logger         *zap.Logger
+
+	// Interval is how often every camera is probed. Defaults to 5 minutes.
+	Interval time.Duration
+
+	// Concurrency is how many cameras are probed at once. Defaults to 16.
+	Concurrency int
+
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+
+	// Resolver is used to find the camera's IP. Defaults to net.DefaultResolver
+	Resolver *net.Resolver
+
+	// ReachabilityPort is the tcp port dialed to check if a camera is reachable. Defaults to 80.
+	ReachabilityPort string
+
+	mu       sync.RWMutex
+	cameras  map[string]CameraHealth
+	lastPoll time.Time
+
+	// events are the transitions still being published
+	events sync.WaitGroup
+}
+
+// target is a camera to probe.
+type target struct {
+	room string
+	cg   string
+	cam  cameraservices.CameraConfig
+	base string
+	addr string
+}
+
+// Run polls every camera immediately, and then every Interval until ctx is cancelled.
+// It returns once the transitions it is publishing have been sent.
+func (p *Poller) Run(ctx context.Context) error {
+	if p.Interval == 0 {
+		p.Interval = 5 * time.Minute
+	}
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	// flush the transitions being published before returning
+	defer p.events.Wait()
+
+	for {
+		if err := p.Poll(ctx); err != nil {
+			p.Logger.Warn("unable to poll cameras", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll probes every camera once.
+func (p *Poller) Poll(ctx context.Context) error {
+	targets, err := p.targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.Logger.Info("Polling cameras", zap.Int("cameras", len(targets)))
+
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+
+	sem := make(chan struct{}, concurrency)
+	results := make([]CameraHealth, len(targets))
+	wg := sync.WaitGroup{}
+
+	for i := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = p.probe(ctx, targets[i])
+		}(i)
+	}
+
+	wg.Wait()
+	p.update(results)
+	return nil
+}
+
+// targets finds every camera the same way the rooms/control groups/cameras endpoints do.
+// Cameras in more than one control group are only probed once.
+func (p *Poller) targets(ctx context.Context) ([]target, error) {
+	rooms, err := p.ConfigService.Rooms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get rooms: %w", err)
+	}
+
+	var targets []target
+	seen := make(map[string]bool)
+
+	for _, room := range rooms {
+		groups, err := p.ConfigService.ControlGroups(ctx, room)
+		if err != nil {
+			p.Logger.Warn("unable to get control groups", zap.String("room", room), zap.Error(err))
+			continue
+		}
+
+		for _, cg := range groups {
+			cameras, err := p.ConfigService.Cameras(ctx, cameraservices.ControlInfo{
+				Room:         room,
+				ControlGroup: cg,
+			})
+			if err != nil {
+				p.Logger.Warn("unable to get cameras", zap.String("room", room), zap.String("controlGroup", cg), zap.Error(err))
+				continue
+			}
+
+			for _, cam := range p.Services.WithURLs(cameras) {
+				base, addr := baseURL(cam)
+				if base == "" || seen[base] {
+					continue
+				}
+
+				seen[base] = true
+				targets = append(targets, target{
+					room: room,
+					cg:   cg,
+					cam:  cam,
+					base: base,
+					addr: addr,
+				})
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// update stores the results of a poll and publishes any up/down transitions. A camera that is down
+// the first time it is polled is published as down.
+func (p *Poller) update(results []CameraHealth) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prev := p.cameras
+	p.cameras = make(map[string]CameraHealth, len(results))
+	p.lastPoll = time.Now()
+
+	for _, res := range results {
+		key := res.Room + "/" + res.Address
+
+		old, ok := prev[key]
+		switch {
+		case !ok:
+			res.LastChange = res.LastChecked
+			if !res.Up {
+				p.publishTransition(res)
+			}
+		case old.Up == res.Up:
+			res.LastChange = old.LastChange
+		default:
+			res.LastChange = res.LastChecked
+			p.publishTransition(res)
+		}
+
+		p.cameras[key] = res
+	}
+}
+
+func (p *Poller) publishTransition(res CameraHealth) {
+	log := p.Logger.With(zap.String("room", res.Room), zap.String("camera", res.Camera), zap.String("address", res.Address))
+	if res.Up {
+		log.Info("Camera is up")
+	} else {
+		log.Warn("Camera is down", zap.Strings("errors", res.Errors))
+	}
+
+	if p.EventPublisher == nil {
+		return
+	}
+
+	p.events.Add(1)
+	go func() {
+		defer p.events.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		info := cameraservices.RequestInfo{
+			Action:    "CameraUp",
+			Timestamp: res.LastChecked,
+			CameraIP:  p.cameraIP(ctx, res.Address),
+			Data: map[string]interface{}{
+				"room":         res.Room,
+				"controlGroup": res.ControlGroup,
+				"camera":       res.Camera,
+			},
+		}
+
+		var err error
+		if res.Up {
+			err = p.EventPublisher.Publish(ctx, info)
+		} else {
+			info.Action = "CameraDown"
+			err = p.EventPublisher.Error(ctx, cameraservices.RequestError{
+				RequestInfo: info,
+				Error:       strings.Join(res.Errors, "; "),
+			})
+		}
+
+		if err != nil {
+			log.Warn("unable to publish event", zap.Error(err))
+		}
+	}()
+}
+
+// Cameras returns the most recent health of every camera, sorted by room and camera.
+func (p *Poller) Cameras() []CameraHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	cameras := make([]CameraHealth, 0, len(p.cameras))
+	for _, cam := range p.cameras {
+		cameras = append(cameras, cam)
+	}
+
+	sort.Slice(cameras, func(i, j int) bool {
+		if cameras[i].Room != cameras[j].Room {
+			return cameras[i].Room < cameras[j].Room
+		}
+
+		return cameras[i].Camera < cameras[j].Camera
+	})
+
+	return cameras
+}
+
+// LastPoll returns when the last poll finished.
+func (p *Poller) LastPoll() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastPoll
+}
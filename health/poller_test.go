@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testConfigService struct {
+	cameras map[string][]cameraservices.CameraConfig
+}
+
+func (t *testConfigService) Rooms(context.Context) ([]string, error) {
+	var rooms []string
+	for room := range t.cameras {
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+func (t *testConfigService) ControlGroups(ctx context.Context, room string) ([]string, error) {
+	return []string{room}, nil
+}
+
+func (t *testConfigService) Cameras(ctx context.Context, info cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	return t.cameras[info.Room], nil
+}
+
+type testKeyService struct{}
+
+func (testKeyService) ControlKey(context.Context, string, string) (string, error) {
+	return "1234", nil
+}
+
+type testPublisher struct {
+	published chan cameraservices.RequestInfo
+	errors    chan cameraservices.RequestError
+}
+
+func (t *testPublisher) Publish(ctx context.Context, info cameraservices.RequestInfo) error {
+	t.published <- info
+	return nil
+}
+
+func (t *testPublisher) Error(ctx context.Context, err cameraservices.RequestError) error {
+	t.errors <- err
+	return nil
+}
+
+func TestPoll(t *testing.T) {
+	var down int32
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("control-key"); err != nil || c.Value != "1234" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/Pro520/127.0.0.1/stream":
+			if atomic.LoadInt32(&down) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			_, _ = w.Write([]byte("--frame"))
+		case "/v1/Pro520/127.0.0.1/status":
+			_, _ = w.Write([]byte(`{"power":"on"}`))
+		case "/v1/P5414E/127.0.0.1/stream":
+			_, _ = w.Write([]byte("--frame"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer service.Close()
+
+	// something for the reachability check to dial
+	camera, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer camera.Close()
+
+	_, port, err := net.SplitHostPort(camera.Addr().String())
+	require.NoError(t, err)
+
+	publisher := &testPublisher{
+		published: make(chan cameraservices.RequestInfo, 1),
+		errors:    make(chan cameraservices.RequestError, 1),
+	}
+
+	p := &Poller{
+		ConfigService: &testConfigService{
+			cameras: map[string][]cameraservices.CameraConfig{
+				"ITB-1101": {
+					{DisplayName: "Front", Stream: service.URL + "/v1/Pro520/127.0.0.1/stream"},
+				},
+				"ITB-1108": {
+					{DisplayName: "Back", Stream: service.URL + "/v1/P5414E/127.0.0.1/stream"},
+				},
+			},
+		},
+		ControlKeyService: testKeyService{},
+		EventPublisher:    publisher,
+		Logger:            zap.NewNop(),
+		ReachabilityPort:  port,
+	}
+
+	require.NoError(t, p.Poll(context.Background()))
+
+	cameras := p.Cameras()
+	require.Len(t, cameras, 2)
+	require.Equal(t, "ITB-1101", cameras[0].Room)
+	require.True(t, cameras[0].Up, cameras[0].Errors)
+	require.Equal(t, cameraservices.PowerOn, cameras[0].Status.Power)
+
+	// status isn't supported, but the camera is still up
+	require.True(t, cameras[1].Up, cameras[1].Errors)
+	require.Nil(t, cameras[1].Status)
+
+	atomic.StoreInt32(&down, 1)
+	require.NoError(t, p.Poll(context.Background()))
+
+	cameras = p.Cameras()
+	require.False(t, cameras[0].Up)
+	require.True(t, cameras[0].Reachable)
+	require.False(t, cameras[0].Streaming)
+
+	event := <-publisher.errors
+	require.Equal(t, "CameraDown", event.Action)
+	require.Equal(t, "Front", event.Data["camera"])
+
+	atomic.StoreInt32(&down, 0)
+	require.NoError(t, p.Poll(context.Background()))
+
+	info := <-publisher.published
+	require.Equal(t, "CameraUp", info.Action)
+}
+
+func TestPollInitiallyDown(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer service.Close()
+
+	publisher := &testPublisher{
+		published: make(chan cameraservices.RequestInfo, 1),
+		errors:    make(chan cameraservices.RequestError, 2),
+	}
+
+	p := &Poller{
+		ConfigService: &testConfigService{
+			cameras: map[string][]cameraservices.CameraConfig{
+				"ITB-1101": {
+					{DisplayName: "Front", Stream: service.URL + "/v1/Pro520/127.0.0.1/stream"},
+				},
+			},
+		},
+		ControlKeyService: testKeyService{},
+		EventPublisher:    publisher,
+		Logger:            zap.NewNop(),
+	}
+
+	// a camera that is down the first time it's polled is reported
+	require.NoError(t, p.Poll(context.Background()))
+
+	event := <-publisher.errors
+	require.Equal(t, "CameraDown", event.Action)
+	require.Equal(t, "Front", event.Data["camera"])
+
+	// but only once
+	require.NoError(t, p.Poll(context.Background()))
+	p.events.Wait()
+	require.Len(t, publisher.errors, 0)
+}
+
+func TestPollStructured(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/Pro520/127.0.0.1/stream" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte("--frame"))
+	}))
+	defer service.Close()
+
+	camera, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer camera.Close()
+
+	_, port, err := net.SplitHostPort(camera.Addr().String())
+	require.NoError(t, err)
+
+	p := &Poller{
+		ConfigService: &testConfigService{
+			cameras: map[string][]cameraservices.CameraConfig{
+				"ITB-1101": {
+					{DisplayName: "Front", Model: "Pro520", Address: "127.0.0.1", Service: "aver"},
+					// its service isn't known, so it can't be probed
+					{DisplayName: "Back", Model: "P5414-E", Address: "127.0.0.1", Service: "axis"},
+				},
+			},
+		},
+		ControlKeyService: testKeyService{},
+		Services: cameraservices.CameraServices{
+			"aver": service.URL,
+		},
+		Logger:           zap.NewNop(),
+		ReachabilityPort: port,
+	}
+
+	require.NoError(t, p.Poll(context.Background()))
+
+	cameras := p.Cameras()
+	require.Len(t, cameras, 1)
+	require.Equal(t, "Front", cameras[0].Camera)
+	require.Equal(t, "127.0.0.1", cameras[0].Address)
+	require.True(t, cameras[0].Up, cameras[0].Errors)
+}
@@ -0,0 +1,208 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const _probeTimeout = 10 * time.Second
+
+// probe checks if the camera is reachable, if its stream starts, and asks for its status.
+func (p *Poller) probe(ctx context.Context, t target) CameraHealth {
+	ctx, cancel := context.WithTimeout(ctx, _probeTimeout)
+	defer cancel()
+
+	res := CameraHealth{
+		Room:         t.room,
+		ControlGroup: t.cg,
+		Camera:       t.cam.DisplayName,
+		Address:      t.addr,
+	}
+
+	if err := p.reachable(ctx, t.addr); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("unreachable: %s", err))
+	} else {
+		res.Reachable = true
+	}
+
+	key, err := p.ControlKeyService.ControlKey(ctx, t.room, t.cg)
+	if err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("unable to get control key: %s", err))
+		res.LastChecked = time.Now()
+		return res
+	}
+
+	if err := p.stream(ctx, key, t.cam.Stream); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("stream: %s", err))
+	} else {
+		res.Streaming = true
+	}
+
+	status, supported, err := p.status(ctx, key, t.base)
+	switch {
+	case err != nil:
+		res.Errors = append(res.Errors, fmt.Sprintf("status: %s", err))
+	case supported:
+		res.Status = &status
+	}
+
+	res.Up = len(res.Errors) == 0
+	res.LastChecked = time.Now()
+	return res
+}
+
+// reachable dials the camera's host.
+func (p *Poller) reachable(ctx context.Context, addr string) error {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+
+	port := p.ReachabilityPort
+	if port == "" {
+		port = "80"
+	}
+
+	dialer := net.Dialer{
+		Resolver: p.Resolver,
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// stream starts the camera's stream and waits for the first bytes of it.
+func (p *Poller) stream(ctx context.Context, key, u string) error {
+	if u == "" {
+		return fmt.Errorf("no stream url configured")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resp, err := p.get(ctx, key, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := resp.Body.Read(make([]byte, 1)); err != nil {
+		return fmt.Errorf("unable to read stream: %w", err)
+	}
+
+	return nil
+}
+
+// status gets the camera's status. false is returned if the camera doesn't support status inquiries.
+func (p *Poller) status(ctx context.Context, key, base string) (cameraservices.Status, bool, error) {
+	var status cameraservices.Status
+
+	resp, err := p.get(ctx, key, base+"/status")
+	switch {
+	case resp != nil && (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound):
+		// camera services that don't support status
+		return status, false, nil
+	case err != nil:
+		return status, false, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return status, false, fmt.Errorf("unable to decode status: %w", err)
+	}
+
+	return status, true, nil
+}
+
+// get makes a request to a camera service. If the response isn't a 2xx, the response is returned with its body closed.
+func (p *Poller) get(ctx context.Context, key, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "control-key",
+		Value: key,
+	})
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make request: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp, fmt.Errorf("%d response from camera service: %s", resp.StatusCode, body)
+	}
+
+	return resp, nil
+}
+
+// cameraIP finds the ip address of the camera. A nil ip is returned if it can't be found.
+func (p *Poller) cameraIP(ctx context.Context, addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip
+	}
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupHost(ctx, addr)
+	if err != nil || len(addrs) == 0 {
+		return nil
+	}
+
+	return net.ParseIP(addrs[0])
+}
+
+// baseURL returns the /v1/:model/:address url of the camera, and its address.
+func baseURL(cam cameraservices.CameraConfig) (string, string) {
+	urls := []string{cam.Stream, cam.PanTiltStop, cam.ZoomStop}
+	for _, preset := range cam.Presets {
+		urls = append(urls, preset.SetPreset)
+	}
+
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || u == "" {
+			continue
+		}
+
+		split := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(split) < 3 {
+			continue
+		}
+
+		parsed.Path = "/" + strings.Join(split[:3], "/")
+		parsed.RawQuery = ""
+		return parsed.String(), split[2]
+	}
+
+	return "", ""
+}
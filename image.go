@@ -0,0 +1,45 @@
+package cameraservices
+
+import "context"
+
+// The focus and exposure modes a camera can be set to.
+const (
+	ModeAuto   = "auto"
+	ModeManual = "manual"
+)
+
+// The white balance modes a camera can be set to.
+const (
+	WhiteBalanceAuto    = "auto"
+	WhiteBalanceIndoor  = "indoor"
+	WhiteBalanceOutdoor = "outdoor"
+	WhiteBalanceOnePush = "onePush"
+)
+
+// FocusCamera is a camera whose focus can be controlled.
+// FocusNear and FocusFar only have an effect when the camera is in manual focus mode.
+type FocusCamera interface {
+	SetFocusMode(ctx context.Context, mode string) error
+	FocusNear(context.Context) error
+	FocusFar(context.Context) error
+	FocusStop(context.Context) error
+}
+
+// OnePushFocusCamera is a camera that can autofocus once while in manual focus mode.
+type OnePushFocusCamera interface {
+	OnePushFocus(context.Context) error
+}
+
+// ExposureCamera is a camera whose exposure can be controlled.
+// SetIris only has an effect when the camera is in manual exposure mode.
+type ExposureCamera interface {
+	SetExposureMode(ctx context.Context, mode string) error
+	SetIris(ctx context.Context, level int) error
+	SetBrightnessCompensation(ctx context.Context, level int) error
+}
+
+// WhiteBalanceCamera is a camera whose white balance can be controlled.
+// Setting the mode to WhiteBalanceOnePush calibrates the white balance to the current scene.
+type WhiteBalanceCamera interface {
+	SetWhiteBalance(ctx context.Context, mode string) error
+}
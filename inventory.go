@@ -0,0 +1,26 @@
+package cameraservices
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCameraNotFound is returned by an InventoryService when the camera doesn't exist in the control group.
+	ErrCameraNotFound = errors.New("camera not found")
+
+	// ErrCameraExists is returned by an InventoryService when a camera with the same display name is already in the control group.
+	ErrCameraExists = errors.New("camera already exists")
+
+	// ErrControlGroupNotFound is returned by an InventoryService when the room or control group doesn't exist.
+	ErrControlGroupNotFound = errors.New("control group not found")
+)
+
+// InventoryService adds, replaces, and removes the cameras in each room's control groups.
+type InventoryService interface {
+	AddCamera(ctx context.Context, room, controlGroup string, cam CameraConfig) error
+
+	// SetCamera replaces the camera with the display name name.
+	SetCamera(ctx context.Context, room, controlGroup, name string, cam CameraConfig) error
+	DeleteCamera(ctx context.Context, room, controlGroup, name string) error
+}
@@ -0,0 +1,73 @@
+package keys
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the control keys service while it is failing.
+var ErrCircuitOpen = errors.New("control keys service is unavailable")
+
+// breaker stops calling the control keys service after maxFailures lookups in a row fail.
+// Once cooldown has passed, one lookup is let through to test it; the rest keep failing until it works.
+type breaker struct {
+	maxFailures int
+	cooldown    time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	testing  bool
+}
+
+// allow returns ErrCircuitOpen if the service shouldn't be called right now.
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxFailures <= 0 || b.failures < b.maxFailures {
+		return nil
+	}
+
+	if b.testing || now.Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+
+	b.testing = true
+	return nil
+}
+
+// done records the result of calling the service.
+func (b *breaker) done(now time.Time, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.testing = false
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.maxFailures {
+		b.openedAt = now
+	}
+}
+
+// abandon is called instead of done when the result of calling the service isn't known.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.testing = false
+}
+
+// open reports whether the service isn't being called.
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.maxFailures > 0 && b.failures >= b.maxFailures
+}
@@ -0,0 +1,351 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheStats are a Cache's metrics.
+type CacheStats struct {
+	// Keys is how many keys are currently cached, valid or not
+	Keys int `json:"keys"`
+
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+
+	// Stale is how many times an expired key was accepted because the control keys service couldn't be reached
+	Stale uint64 `json:"stale"`
+
+	// Failures is how many lookups couldn't reach the control keys service
+	Failures uint64 `json:"failures"`
+
+	// CircuitOpen is true while the control keys service isn't being called because it keeps failing
+	CircuitOpen bool `json:"circuitOpen"`
+}
+
+// Cache is a ControlKeyService that remembers which room and control group each key is for. Valid and
+// invalid keys are cached separately, concurrent lookups of the same key share one request, and failed
+// requests are retried. If the control keys service can't be reached, keys that were recently valid are
+// still accepted.
+//
+// ControlKey isn't cached, but its requests are retried and go through the same circuit breaker.
+type Cache struct {
+	ks *ControlKeyService
+
+	ttl         time.Duration
+	negativeTTL time.Duration
+	staleTTL    time.Duration
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	log         *zap.Logger
+
+	breaker breaker
+	single  singleflight.Group
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]cachedKey
+	lastSweep time.Time
+
+	// lookups are the lookups in progress
+	lookups map[string]*pendingLookup
+
+	hits     uint64
+	misses   uint64
+	stale    uint64
+	failures uint64
+}
+
+type cachedKey struct {
+	room         string
+	controlGroup string
+	valid        bool
+
+	// expires is when the key has to be looked up again
+	expires time.Time
+
+	// validated is when the control keys service last said the key was valid
+	validated time.Time
+}
+
+type lookupResult struct {
+	room         string
+	controlGroup string
+}
+
+// pendingLookup is a lookup shared by every caller waiting on it, which is cancelled once they have all given up.
+type pendingLookup struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// NewCache creates a Cache in front of ks.
+func NewCache(ks *ControlKeyService, opts ...CacheOption) *Cache {
+	options := cacheOptions{
+		ttl:         _defaultTTL,
+		negativeTTL: _defaultNegativeTTL,
+		staleTTL:    _defaultStaleTTL,
+		timeout:     _defaultTimeout,
+		retries:     _defaultRetries,
+		backoff:     _defaultBackoff,
+		maxFailures: _defaultMaxFailures,
+		cooldown:    _defaultCooldown,
+		log:         zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Cache{
+		ks:          ks,
+		ttl:         options.ttl,
+		negativeTTL: options.negativeTTL,
+		staleTTL:    options.staleTTL,
+		timeout:     options.timeout,
+		retries:     options.retries,
+		backoff:     options.backoff,
+		log:         options.log,
+		breaker: breaker{
+			maxFailures: options.maxFailures,
+			cooldown:    options.cooldown,
+		},
+		now:     time.Now,
+		keys:    make(map[string]cachedKey),
+		lookups: make(map[string]*pendingLookup),
+	}
+}
+
+// Stats returns the cache's metrics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	keys := len(c.keys)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Keys:        keys,
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		Stale:       atomic.LoadUint64(&c.stale),
+		Failures:    atomic.LoadUint64(&c.failures),
+		CircuitOpen: c.breaker.open(),
+	}
+}
+
+func (c *Cache) RoomAndControlGroup(ctx context.Context, key string) (string, string, error) {
+	c.mu.Lock()
+	cached, ok := c.keys[key]
+	c.mu.Unlock()
+
+	if ok && c.now().Before(cached.expires) {
+		atomic.AddUint64(&c.hits, 1)
+
+		if !cached.valid {
+			return "", "", ErrInvalidKey
+		}
+
+		return cached.room, cached.controlGroup, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+
+	ch, leave := c.join(key)
+	defer leave()
+
+	select {
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	case res := <-ch:
+		switch {
+		case res.Err == nil:
+			r := res.Val.(lookupResult)
+			return r.room, r.controlGroup, nil
+		case errors.Is(res.Err, ErrInvalidKey):
+			return "", "", res.Err
+		case ok && cached.valid && c.now().Sub(cached.validated) < c.staleTTL:
+			atomic.AddUint64(&c.stale, 1)
+			c.log.Warn("Accepting expired control key because the control keys service couldn't be reached",
+				zap.String("room", cached.room),
+				zap.String("controlGroup", cached.controlGroup),
+				zap.Time("validated", cached.validated),
+				zap.Error(res.Err))
+
+			return cached.room, cached.controlGroup, nil
+		}
+
+		return "", "", res.Err
+	}
+}
+
+// ControlKey asks the control keys service for the control group's key.
+func (c *Cache) ControlKey(ctx context.Context, room, controlGroup string) (string, error) {
+	var key string
+	err := c.call(ctx, func(ctx context.Context) error {
+		var err error
+		key, err = c.ks.ControlKey(ctx, room, controlGroup)
+		return err
+	})
+
+	return key, err
+}
+
+// join joins the lookup of key in progress, or starts one. The lookup isn't tied to the caller's ctx, so that
+// one caller going away doesn't fail the others waiting on it. leave must be called once the caller is done
+// waiting; the lookup is cancelled once every caller has left.
+func (c *Cache) join(key string) (<-chan singleflight.Result, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.lookups[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		pending = &pendingLookup{ctx: ctx, cancel: cancel}
+		c.lookups[key] = pending
+	}
+
+	pending.waiters++
+	ch := c.single.DoChan(key, func() (interface{}, error) {
+		return c.lookup(pending.ctx, key)
+	})
+
+	leave := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		pending.waiters--
+		if pending.waiters > 0 {
+			return
+		}
+
+		// callers that come later start a new lookup instead of joining the cancelled one
+		pending.cancel()
+		c.single.Forget(key)
+		delete(c.lookups, key)
+	}
+
+	return ch, leave
+}
+
+// lookup asks the control keys service for key's room and control group, and caches the result.
+func (c *Cache) lookup(ctx context.Context, key string) (lookupResult, error) {
+	var res lookupResult
+	err := c.call(ctx, func(ctx context.Context) error {
+		var err error
+		res.room, res.controlGroup, err = c.ks.RoomAndControlGroup(ctx, key)
+		return err
+	})
+
+	now := c.now()
+	switch {
+	case err == nil:
+		c.store(key, cachedKey{
+			room:         res.room,
+			controlGroup: res.controlGroup,
+			valid:        true,
+			expires:      now.Add(c.ttl),
+			validated:    now,
+		})
+	case errors.Is(err, ErrInvalidKey):
+		c.store(key, cachedKey{
+			expires: now.Add(c.negativeTTL),
+		})
+	}
+
+	return res, err
+}
+
+// call calls the control keys service through the circuit breaker, retrying failed requests until ctx is done.
+func (c *Cache) call(ctx context.Context, fn func(context.Context) error) error {
+	if err := c.breaker.allow(c.now()); err != nil {
+		atomic.AddUint64(&c.failures, 1)
+		return err
+	}
+
+	var err error
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err = fn(attemptCtx)
+		cancel()
+
+		if answered(err) || attempt >= c.retries || !sleep(ctx, backoff) {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	// nobody is waiting on the result anymore, so it doesn't say whether the service is working
+	if ctx.Err() != nil {
+		c.breaker.abandon()
+		return ctx.Err()
+	}
+
+	wasOpen := c.breaker.open()
+	if answered(err) {
+		c.breaker.done(c.now(), nil)
+	} else {
+		atomic.AddUint64(&c.failures, 1)
+		c.breaker.done(c.now(), err)
+	}
+
+	switch isOpen := c.breaker.open(); {
+	case !wasOpen && isOpen:
+		c.log.Warn("Control keys service is failing; only accepting cached keys", zap.Error(err))
+	case wasOpen && !isOpen:
+		c.log.Info("Control keys service is back up")
+	}
+
+	return err
+}
+
+// answered reports whether err means the control keys service is working. Invalid keys and control groups
+// without a key aren't retried.
+func answered(err error) bool {
+	return err == nil || errors.Is(err, ErrInvalidKey) || errors.Is(err, cameraservices.ErrControlKeyNotFound)
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (c *Cache) store(key string, cached cachedKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys[key] = cached
+
+	// drop keys that can't be used anymore, so that the cache doesn't grow forever
+	now := c.now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+
+	c.lastSweep = now
+	for k, v := range c.keys {
+		if now.After(v.expires) && (!v.valid || now.Sub(v.validated) >= c.staleTTL) {
+			delete(c.keys, k)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeysServer is a control keys service that knows about key 1234 for ITB-1101, and can be made to fail.
+type testKeysServer struct {
+	requests int32
+	failing  int32
+	delay    time.Duration
+}
+
+func (t *testKeysServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&t.requests, 1)
+	time.Sleep(t.delay)
+
+	switch {
+	case atomic.LoadInt32(&t.failing) == 1:
+		w.WriteHeader(http.StatusBadGateway)
+	case r.URL.Path == "/1234/getPreset":
+		_, _ = w.Write([]byte(`{"RoomID":"ITB-1101","PresetName":"ITB-1101"}`))
+	case r.URL.Path == "/ITB-1101 ITB-1101/getControlKey":
+		_, _ = w.Write([]byte(`{"ControlKey":"1234"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestCache(t *testing.T, opts ...CacheOption) (*Cache, *testKeysServer, *time.Time) {
+	keys := &testKeysServer{}
+	srv := httptest.NewServer(keys)
+	t.Cleanup(srv.Close)
+
+	opts = append([]CacheOption{WithRetries(1, time.Millisecond)}, opts...)
+	c := NewCache(&ControlKeyService{Address: strings.TrimPrefix(srv.URL, "http://")}, opts...)
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	return c, keys, &now
+}
+
+func TestCache(t *testing.T) {
+	c, keys, now := newTestCache(t)
+	ctx := context.Background()
+
+	room, cg, err := c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+	require.Equal(t, "ITB-1101", cg)
+
+	_, _, err = c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&keys.requests))
+
+	// invalid keys are cached, and not retried
+	for i := 0; i < 2; i++ {
+		_, _, err = c.RoomAndControlGroup(ctx, "9999")
+		require.True(t, errors.Is(err, ErrInvalidKey))
+	}
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&keys.requests))
+
+	*now = now.Add(_defaultNegativeTTL + time.Second)
+	_, _, err = c.RoomAndControlGroup(ctx, "9999")
+	require.True(t, errors.Is(err, ErrInvalidKey))
+	require.Equal(t, int32(3), atomic.LoadInt32(&keys.requests))
+
+	*now = now.Add(_defaultTTL)
+	_, _, err = c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.Equal(t, int32(4), atomic.LoadInt32(&keys.requests))
+
+	// the expired invalid key has been dropped
+	stats := c.Stats()
+	require.Equal(t, uint64(2), stats.Hits)
+	require.Equal(t, uint64(4), stats.Misses)
+	require.Equal(t, 1, stats.Keys)
+}
+
+func TestCacheOutage(t *testing.T) {
+	c, keys, now := newTestCache(t, WithCircuitBreaker(2, time.Minute))
+	ctx := context.Background()
+
+	_, _, err := c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+
+	atomic.StoreInt32(&keys.failing, 1)
+	*now = now.Add(_defaultTTL + time.Second)
+
+	// recently valid keys are still accepted, after the failed request is retried
+	room, _, err := c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+	require.Equal(t, int32(3), atomic.LoadInt32(&keys.requests))
+
+	// keys that were never valid aren't
+	_, _, err = c.RoomAndControlGroup(ctx, "5678")
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrInvalidKey))
+	require.Equal(t, int32(5), atomic.LoadInt32(&keys.requests))
+
+	// the service isn't called while the circuit is open
+	require.True(t, c.Stats().CircuitOpen)
+
+	_, _, err = c.RoomAndControlGroup(ctx, "5678")
+	require.True(t, errors.Is(err, ErrCircuitOpen))
+
+	_, _, err = c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.Equal(t, int32(5), atomic.LoadInt32(&keys.requests))
+
+	// too long after the key was validated, it isn't accepted. the cooldown has passed, so the service is tried again
+	*now = now.Add(_defaultStaleTTL)
+	_, _, err = c.RoomAndControlGroup(ctx, "1234")
+	require.Error(t, err)
+	require.Equal(t, int32(7), atomic.LoadInt32(&keys.requests))
+
+	atomic.StoreInt32(&keys.failing, 0)
+	*now = now.Add(time.Minute)
+
+	_, _, err = c.RoomAndControlGroup(ctx, "1234")
+	require.NoError(t, err)
+	require.False(t, c.Stats().CircuitOpen)
+
+	stats := c.Stats()
+	require.Equal(t, uint64(2), stats.Stale)
+	require.Equal(t, uint64(5), stats.Failures)
+}
+
+func TestCacheCoalesce(t *testing.T) {
+	c, keys, _ := newTestCache(t)
+	keys.delay = 50 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := c.RoomAndControlGroup(context.Background(), "1234")
+			require.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&keys.requests))
+
+	// callers can give up without waiting for the lookup
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := c.RoomAndControlGroup(ctx, "9999")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCacheTimeout(t *testing.T) {
+	c, keys, _ := newTestCache(t, WithTimeout(10*time.Millisecond), WithRetries(0, 0))
+	keys.delay = 100 * time.Millisecond
+
+	start := time.Now()
+	_, _, err := c.RoomAndControlGroup(context.Background(), "1234")
+	require.Error(t, err)
+	require.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+}
+
+func TestCacheAbandoned(t *testing.T) {
+	c, keys, _ := newTestCache(t, WithRetries(3, 50*time.Millisecond))
+	atomic.StoreInt32(&keys.failing, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := c.RoomAndControlGroup(ctx, "1234")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// once every caller has given up, the lookup stops retrying, and isn't counted as a failure
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&keys.requests))
+	require.Equal(t, uint64(0), c.Stats().Failures)
+
+	// later callers start a new lookup
+	atomic.StoreInt32(&keys.failing, 0)
+	room, _, err := c.RoomAndControlGroup(context.Background(), "1234")
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+}
+
+func TestCacheControlKey(t *testing.T) {
+	c, keys, _ := newTestCache(t, WithCircuitBreaker(2, time.Minute))
+	ctx := context.Background()
+
+	key, err := c.ControlKey(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+	require.Equal(t, "1234", key)
+
+	// control groups without a key aren't retried
+	_, err = c.ControlKey(ctx, "ITB-1102", "ITB-1102")
+	require.True(t, errors.Is(err, cameraservices.ErrControlKeyNotFound))
+	require.Equal(t, int32(2), atomic.LoadInt32(&keys.requests))
+
+	// failed requests are retried, and open the circuit breaker
+	atomic.StoreInt32(&keys.failing, 1)
+	for i := 0; i < 2; i++ {
+		_, err = c.ControlKey(ctx, "ITB-1101", "ITB-1101")
+		require.Error(t, err)
+	}
+
+	require.Equal(t, int32(6), atomic.LoadInt32(&keys.requests))
+
+	_, err = c.ControlKey(ctx, "ITB-1101", "ITB-1101")
+	require.True(t, errors.Is(err, ErrCircuitOpen))
+	require.Equal(t, int32(6), atomic.LoadInt32(&keys.requests))
+}
@@ -3,13 +3,47 @@ package keys
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+
+	cameraservices "github.com/byuoitav/camera-services"
 )
 
+// ErrInvalidKey is returned when the control keys service says a control key isn't valid.
+var ErrInvalidKey = errors.New("Invalid Control Key")
+
 type ControlKeyService struct {
 	Address string
+
+	// Token is sent as the bearer token of each request. The control keys service requires it to look up a control group's key.
+	Token string
+
+	// Client is used to make requests to the control keys service. Defaults to http.DefaultClient
+	Client *http.Client
+}
+
+func (c *ControlKeyService) client() *http.Client {
+	if c.Client == nil {
+		return http.DefaultClient
+	}
+
+	return c.Client
+}
+
+func (c *ControlKeyService) newRequest(ctx context.Context, u string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	return req, nil
 }
 
 type roomControlGroupResponse struct {
@@ -22,21 +56,24 @@ type keyResponse struct {
 }
 
 func (c *ControlKeyService) RoomAndControlGroup(ctx context.Context, key string) (string, string, error) {
-	url := fmt.Sprintf("http://%s/%s/getPreset", c.Address, key)
+	u := fmt.Sprintf("http://%s/%s/getPreset", c.Address, url.PathEscape(key))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, u)
 	if err != nil {
 		return "", "", fmt.Errorf("unable to build request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
 		return "", "", fmt.Errorf("unable to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode/100 != 2 {
-		return "", "", fmt.Errorf("Invalid Control Key")
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode/100 == 5:
+		return "", "", fmt.Errorf("%d response from control keys service", resp.StatusCode)
+	case resp.StatusCode/100 != 2:
+		return "", "", ErrInvalidKey
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -49,25 +86,33 @@ func (c *ControlKeyService) RoomAndControlGroup(ctx context.Context, key string)
 		return "", "", fmt.Errorf("unable to parse response: %w", err)
 	}
 
+	if room.Room == "" {
+		return "", "", ErrInvalidKey
+	}
+
 	return room.Room, room.ControlGroup, nil
 }
 
 func (c *ControlKeyService) ControlKey(ctx context.Context, room, controlGroup string) (string, error) {
-	url := fmt.Sprintf("http://%s/%s %s/getControlKey", c.Address, room, controlGroup)
+	// the control keys service identifies control groups as "room controlGroup"
+	u := fmt.Sprintf("http://%s/%s/getControlKey", c.Address, url.PathEscape(room+" "+controlGroup))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, u)
 	if err != nil {
 		return "", fmt.Errorf("unable to build request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("unable to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode/100 != 2 {
-		return "", fmt.Errorf("no control key found")
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", cameraservices.ErrControlKeyNotFound
+	case resp.StatusCode/100 != 2:
+		return "", fmt.Errorf("%d response from control keys service", resp.StatusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
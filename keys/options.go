@@ -0,0 +1,97 @@
+package keys
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultTTL         = 5 * time.Minute
+	_defaultNegativeTTL = 30 * time.Second
+	_defaultStaleTTL    = 15 * time.Minute
+	_defaultTimeout     = 2 * time.Second
+	_defaultRetries     = 2
+	_defaultBackoff     = 100 * time.Millisecond
+	_defaultMaxFailures = 5
+	_defaultCooldown    = 30 * time.Second
+)
+
+type cacheOptions struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	staleTTL    time.Duration
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	maxFailures int
+	cooldown    time.Duration
+	log         *zap.Logger
+}
+
+type CacheOption interface {
+	apply(*cacheOptions)
+}
+
+type cacheOptionFunc func(*cacheOptions)
+
+func (f cacheOptionFunc) apply(o *cacheOptions) {
+	f(o)
+}
+
+// WithTTL sets how long a valid key's room and control group are cached for. The default is 5 minutes.
+func WithTTL(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.ttl = d
+	})
+}
+
+// WithNegativeTTL sets how long a key the control keys service said was invalid is remembered for.
+// The default is 30 seconds.
+func WithNegativeTTL(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.negativeTTL = d
+	})
+}
+
+// WithStaleTTL sets how long after a key was last validated it is still accepted if the control keys
+// service can't be reached. Revoked and rotated keys are accepted for this long during an outage, so it
+// should be kept short. The default is 15 minutes. 0 means keys are never accepted once they expire.
+func WithStaleTTL(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.staleTTL = d
+	})
+}
+
+// WithTimeout sets how long each request to the control keys service can take. The default is 2 seconds.
+func WithTimeout(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.timeout = d
+	})
+}
+
+// WithRetries sets how many times a failed request is retried, and how long to wait before the first retry.
+// The wait doubles after each retry. The default is 2 retries, starting at 100 milliseconds.
+func WithRetries(n int, backoff time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.retries = n
+		o.backoff = backoff
+	})
+}
+
+// WithCircuitBreaker sets how many lookups in a row have to fail before the control keys service stops
+// being called, and how long to wait before trying it again. The default is 5 failures and 30 seconds.
+// 0 failures disables the circuit breaker.
+func WithCircuitBreaker(failures int, cooldown time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.maxFailures = failures
+		o.cooldown = cooldown
+	})
+}
+
+// WithCacheLogger sets the logger used to log outages of the control keys service.
+func WithCacheLogger(log *zap.Logger) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.log = log
+	})
+}
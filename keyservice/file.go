@@ -0,0 +1,123 @@
+package keyservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// FileStore is a ControlKeyStore that keeps every key in one json file.
+// The file is replaced on each change, so it is never left half written.
+type FileStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (f *FileStore) ControlKeys(ctx context.Context) ([]cameraservices.IssuedControlKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read()
+}
+
+// SetControlKey creates the control group's key, or replaces its existing key.
+func (f *FileStore) SetControlKey(ctx context.Context, key cameraservices.IssuedControlKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	for i := range keys {
+		if keys[i].Room == key.Room && keys[i].ControlGroup == key.ControlGroup {
+			keys[i] = key
+			return f.write(keys)
+		}
+	}
+
+	return f.write(append(keys, key))
+}
+
+func (f *FileStore) DeleteControlKey(ctx context.Context, room, controlGroup string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	for i := range keys {
+		if keys[i].Room == room && keys[i].ControlGroup == controlGroup {
+			return f.write(append(keys[:i], keys[i+1:]...))
+		}
+	}
+
+	return cameraservices.ErrControlKeyNotFound
+}
+
+// read reads every key from the file. A file that doesn't exist yet has no keys.
+func (f *FileStore) read() ([]cameraservices.IssuedControlKey, error) {
+	var keys []cameraservices.IssuedControlKey
+
+	data, err := ioutil.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return keys, nil
+	case err != nil:
+		return keys, fmt.Errorf("unable to read control keys: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return keys, fmt.Errorf("unable to parse control keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (f *FileStore) write(keys []cameraservices.IssuedControlKey) error {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Room != keys[j].Room {
+			return keys[i].Room < keys[j].Room
+		}
+
+		return keys[i].ControlGroup < keys[j].ControlGroup
+	})
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal control keys: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write control keys: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write control keys: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("unable to replace control keys: %w", err)
+	}
+
+	return nil
+}
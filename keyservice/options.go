@@ -0,0 +1,70 @@
+package keyservice
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultKeyLength       = 6
+	_defaultRotateInterval  = 7 * 24 * time.Hour
+	_defaultGracePeriod     = 8 * time.Hour
+	_defaultRefreshInterval = time.Minute
+)
+
+type options struct {
+	keyLength       int
+	rotateInterval  time.Duration
+	gracePeriod     time.Duration
+	refreshInterval time.Duration
+	log             *zap.Logger
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithKeyLength sets how many digits new keys have. The default is 6, and the minimum is 4.
+func WithKeyLength(n int) Option {
+	return optionFunc(func(o *options) {
+		o.keyLength = n
+	})
+}
+
+// WithRotateInterval sets how long a key is used before it is replaced with a new one.
+// The default is 7 days. 0 means keys are only replaced when they are rotated by hand.
+func WithRotateInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.rotateInterval = d
+	})
+}
+
+// WithGracePeriod sets how long a rotated key is still accepted for. The default is 8 hours,
+// the same length as a control session.
+func WithGracePeriod(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.gracePeriod = d
+	})
+}
+
+// WithRefreshInterval sets how often keys are reloaded from the store and checked for rotation.
+// The default is 1 minute. 0 disables reloading and scheduled rotation.
+func WithRefreshInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.refreshInterval = d
+	})
+}
+
+// WithLogger sets the logger used to log issued, rotated, and revoked keys.
+func WithLogger(log *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.log = log
+	})
+}
@@ -0,0 +1,377 @@
+package keyservice
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/keys"
+	"go.uber.org/zap"
+)
+
+// _maxKeyAttempts is how many random keys are tried before giving up on finding one that isn't in use
+const _maxKeyAttempts = 10
+
+// Service issues control keys for each room's control group and looks up which control group a key is for.
+// It is a ControlKeyService, so it can be used in place of the control keys service.
+//
+// Keys are kept in memory and written through to the store. Changes made by another Service using the same
+// store are only seen when the keys are reloaded, so only one should be issuing keys at a time.
+type Service struct {
+	store cameraservices.ControlKeyStore
+
+	keyLength      int
+	rotateInterval time.Duration
+	gracePeriod    time.Duration
+	log            *zap.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	// changing is held while keys are changed in the store, so that new keys are unique
+	changing sync.Mutex
+
+	mu     sync.RWMutex
+	groups map[groupID]cameraservices.IssuedControlKey
+	keys   map[string]groupID
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type groupID struct {
+	room         string
+	controlGroup string
+}
+
+// New creates a Service, loading the keys that have already been issued from store.
+func New(ctx context.Context, store cameraservices.ControlKeyStore, opts ...Option) (*Service, error) {
+	options := options{
+		keyLength:       _defaultKeyLength,
+		rotateInterval:  _defaultRotateInterval,
+		gracePeriod:     _defaultGracePeriod,
+		refreshInterval: _defaultRefreshInterval,
+		log:             zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	if options.keyLength < 4 {
+		return nil, fmt.Errorf("keys must be at least 4 digits long")
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		store:          store,
+		keyLength:      options.keyLength,
+		rotateInterval: options.rotateInterval,
+		gracePeriod:    options.gracePeriod,
+		log:            options.log,
+		now:            time.Now,
+		cancel:         cancel,
+		done:           make(chan struct{}),
+	}
+
+	if err := s.load(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if options.refreshInterval <= 0 {
+		close(s.done)
+		return s, nil
+	}
+
+	go s.run(runCtx, options.refreshInterval)
+	return s, nil
+}
+
+// Close stops reloading and rotating keys.
+func (s *Service) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Service) run(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.load(ctx); err != nil {
+			s.log.Warn("unable to reload control keys", zap.Error(err))
+			continue
+		}
+
+		s.rotateExpired(ctx)
+	}
+}
+
+// load replaces the keys in memory with the ones in the store.
+func (s *Service) load(ctx context.Context) error {
+	s.changing.Lock()
+	defer s.changing.Unlock()
+
+	issued, err := s.store.ControlKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get control keys: %w", err)
+	}
+
+	groups := make(map[groupID]cameraservices.IssuedControlKey, len(issued))
+	keys := make(map[string]groupID, len(issued))
+
+	for _, key := range issued {
+		cg := groupID{room: key.Room, controlGroup: key.ControlGroup}
+		groups[cg] = key
+		keys[key.Key] = cg
+
+		if key.Previous != "" {
+			keys[key.Previous] = cg
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups = groups
+	s.keys = keys
+	return nil
+}
+
+// rotateExpired rotates every key that has been used for longer than the rotate interval.
+func (s *Service) rotateExpired(ctx context.Context) {
+	if s.rotateInterval <= 0 {
+		return
+	}
+
+	var expired []groupID
+
+	s.mu.RLock()
+	for cg, key := range s.groups {
+		if s.now().Sub(key.Issued) >= s.rotateInterval {
+			expired = append(expired, cg)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, cg := range expired {
+		if _, err := s.Rotate(ctx, cg.room, cg.controlGroup); err != nil {
+			s.log.Warn("unable to rotate control key", zap.String("room", cg.room), zap.String("controlGroup", cg.controlGroup), zap.Error(err))
+		}
+	}
+}
+
+// RoomAndControlGroup returns the room and control group key is for. Keys that were rotated
+// are still accepted until their grace period is over.
+func (s *Service) RoomAndControlGroup(ctx context.Context, key string) (string, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cg, ok := s.keys[key]
+	if !ok {
+		return "", "", keys.ErrInvalidKey
+	}
+
+	issued := s.groups[cg]
+	switch {
+	case key == issued.Key:
+	case key == issued.Previous && s.now().Before(issued.PreviousExpires):
+	default:
+		return "", "", keys.ErrInvalidKey
+	}
+
+	return cg.room, cg.controlGroup, nil
+}
+
+// ControlKey returns the control group's key. Keys are only issued by Issue, so ErrControlKeyNotFound is returned
+// if the control group doesn't have one yet.
+func (s *Service) ControlKey(ctx context.Context, room, controlGroup string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	issued, ok := s.groups[groupID{room: room, controlGroup: controlGroup}]
+	if !ok {
+		return "", cameraservices.ErrControlKeyNotFound
+	}
+
+	return issued.Key, nil
+}
+
+// ControlKeys returns every issued key, sorted by room and control group.
+func (s *Service) ControlKeys() []cameraservices.IssuedControlKey {
+	s.mu.RLock()
+	issued := make([]cameraservices.IssuedControlKey, 0, len(s.groups))
+	for _, key := range s.groups {
+		issued = append(issued, key)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(issued, func(i, j int) bool {
+		if issued[i].Room != issued[j].Room {
+			return issued[i].Room < issued[j].Room
+		}
+
+		return issued[i].ControlGroup < issued[j].ControlGroup
+	})
+
+	return issued
+}
+
+// Issue returns the control group's key, issuing one if it doesn't have one yet.
+func (s *Service) Issue(ctx context.Context, room, controlGroup string) (cameraservices.IssuedControlKey, error) {
+	cg := groupID{room: room, controlGroup: controlGroup}
+
+	s.mu.RLock()
+	issued, ok := s.groups[cg]
+	s.mu.RUnlock()
+
+	if ok {
+		return issued, nil
+	}
+
+	s.changing.Lock()
+	defer s.changing.Unlock()
+
+	// it may have been issued while we were waiting
+	s.mu.RLock()
+	issued, ok = s.groups[cg]
+	s.mu.RUnlock()
+
+	if ok {
+		return issued, nil
+	}
+
+	return s.replace(ctx, cg, cameraservices.IssuedControlKey{})
+}
+
+// Rotate replaces the control group's key with a new one. The old key is still accepted until the grace period is over.
+func (s *Service) Rotate(ctx context.Context, room, controlGroup string) (cameraservices.IssuedControlKey, error) {
+	cg := groupID{room: room, controlGroup: controlGroup}
+
+	s.changing.Lock()
+	defer s.changing.Unlock()
+
+	s.mu.RLock()
+	old := s.groups[cg]
+	s.mu.RUnlock()
+
+	return s.replace(ctx, cg, old)
+}
+
+// Revoke deletes the control group's key. Neither it nor the key it replaced are accepted anymore.
+func (s *Service) Revoke(ctx context.Context, room, controlGroup string) error {
+	cg := groupID{room: room, controlGroup: controlGroup}
+
+	s.changing.Lock()
+	defer s.changing.Unlock()
+
+	if err := s.store.DeleteControlKey(ctx, room, controlGroup); err != nil {
+		return fmt.Errorf("unable to delete control key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.groups[cg]
+	delete(s.groups, cg)
+	delete(s.keys, old.Key)
+	delete(s.keys, old.Previous)
+
+	s.log.Info("Revoked control key", zap.String("room", room), zap.String("controlGroup", controlGroup))
+	return nil
+}
+
+// replace issues a new key for cg, which replaces old. s.changing must be held.
+func (s *Service) replace(ctx context.Context, cg groupID, old cameraservices.IssuedControlKey) (cameraservices.IssuedControlKey, error) {
+	if cg.room == "" || cg.controlGroup == "" {
+		return cameraservices.IssuedControlKey{}, errors.New("room and control group are required")
+	}
+
+	key, err := s.newKey()
+	if err != nil {
+		return cameraservices.IssuedControlKey{}, err
+	}
+
+	now := s.now()
+	issued := cameraservices.IssuedControlKey{
+		Room:         cg.room,
+		ControlGroup: cg.controlGroup,
+		Key:          key,
+		Issued:       now,
+	}
+
+	if old.Key != "" && s.gracePeriod > 0 {
+		issued.Previous = old.Key
+		issued.PreviousExpires = now.Add(s.gracePeriod)
+	}
+
+	if err := s.store.SetControlKey(ctx, issued); err != nil {
+		return cameraservices.IssuedControlKey{}, fmt.Errorf("unable to store control key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[cg] = issued
+	s.keys[issued.Key] = cg
+	if old.Previous != "" {
+		delete(s.keys, old.Previous)
+	}
+
+	if old.Key != "" && issued.Previous == "" {
+		delete(s.keys, old.Key)
+	}
+
+	if old.Key == "" {
+		s.log.Info("Issued control key", zap.String("room", cg.room), zap.String("controlGroup", cg.controlGroup))
+	} else {
+		s.log.Info("Rotated control key", zap.String("room", cg.room), zap.String("controlGroup", cg.controlGroup))
+	}
+
+	return issued, nil
+}
+
+// newKey generates a random key that isn't already in use. Keys don't start with 0, so that they are the same length however they are typed.
+func (s *Service) newKey() (string, error) {
+	for i := 0; i < _maxKeyAttempts; i++ {
+		key := make([]byte, s.keyLength)
+		for j := range key {
+			digits, first := int64(10), byte('0')
+			if j == 0 {
+				digits, first = 9, '1'
+			}
+
+			n, err := rand.Int(rand.Reader, big.NewInt(digits))
+			if err != nil {
+				return "", fmt.Errorf("unable to generate key: %w", err)
+			}
+
+			key[j] = first + byte(n.Int64())
+		}
+
+		s.mu.RLock()
+		_, inUse := s.keys[string(key)]
+		s.mu.RUnlock()
+
+		if !inUse {
+			return string(key), nil
+		}
+	}
+
+	return "", errors.New("unable to generate a key that isn't in use")
+}
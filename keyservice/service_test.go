@@ -0,0 +1,120 @@
+package keyservice
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/byuoitav/camera-services/keys"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, store cameraservices.ControlKeyStore, opts ...Option) (*Service, *time.Time) {
+	opts = append([]Option{WithRefreshInterval(0)}, opts...)
+
+	s, err := New(context.Background(), store, opts...)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	return s, &now
+}
+
+func TestService(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "keys.json")}
+	s, now := newTestService(t, store)
+	ctx := context.Background()
+
+	// keys aren't issued by looking them up
+	_, err := s.ControlKey(ctx, "ITB-1101", "ITB-1101")
+	require.True(t, errors.Is(err, cameraservices.ErrControlKeyNotFound))
+
+	issued, err := s.Issue(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+
+	key := issued.Key
+	require.Len(t, key, _defaultKeyLength)
+	require.NotEqual(t, byte('0'), key[0])
+
+	// the same key is returned until it is rotated
+	again, err := s.ControlKey(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	room, cg, err := s.RoomAndControlGroup(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+	require.Equal(t, "ITB-1101", cg)
+
+	_, _, err = s.RoomAndControlGroup(ctx, "000000")
+	require.True(t, errors.Is(err, keys.ErrInvalidKey))
+
+	// the old key still works until the grace period is over
+	rotated, err := s.Rotate(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+	require.NotEqual(t, key, rotated.Key)
+	require.Equal(t, key, rotated.Previous)
+
+	_, _, err = s.RoomAndControlGroup(ctx, key)
+	require.NoError(t, err)
+
+	*now = now.Add(_defaultGracePeriod)
+	_, _, err = s.RoomAndControlGroup(ctx, key)
+	require.True(t, errors.Is(err, keys.ErrInvalidKey))
+
+	_, _, err = s.RoomAndControlGroup(ctx, rotated.Key)
+	require.NoError(t, err)
+
+	// keys are loaded from the store
+	reloaded, _ := newTestService(t, store)
+	room, _, err = reloaded.RoomAndControlGroup(ctx, rotated.Key)
+	require.NoError(t, err)
+	require.Equal(t, "ITB-1101", room)
+
+	// revoked keys aren't accepted, even if they were just rotated
+	_, err = s.Rotate(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+	require.NoError(t, s.Revoke(ctx, "ITB-1101", "ITB-1101"))
+
+	_, _, err = s.RoomAndControlGroup(ctx, rotated.Key)
+	require.True(t, errors.Is(err, keys.ErrInvalidKey))
+	require.Empty(t, s.ControlKeys())
+
+	err = s.Revoke(ctx, "ITB-1101", "ITB-1101")
+	require.True(t, errors.Is(err, cameraservices.ErrControlKeyNotFound))
+
+	stored, err := store.ControlKeys(ctx)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+}
+
+func TestRotateExpired(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "keys.json")}
+	s, now := newTestService(t, store, WithRotateInterval(24*time.Hour), WithKeyLength(4))
+	ctx := context.Background()
+
+	first, err := s.Issue(ctx, "ITB-1101", "ITB-1101")
+	require.NoError(t, err)
+	require.Len(t, first.Key, 4)
+
+	*now = now.Add(12 * time.Hour)
+	second, err := s.Issue(ctx, "ITB-1102", "ITB-1102")
+	require.NoError(t, err)
+
+	*now = now.Add(12 * time.Hour)
+	s.rotateExpired(ctx)
+
+	issued := s.ControlKeys()
+	require.Len(t, issued, 2)
+	require.NotEqual(t, first.Key, issued[0].Key)
+	require.Equal(t, first.Key, issued[0].Previous)
+	require.Equal(t, second, issued[1])
+
+	_, err = New(ctx, store, WithKeyLength(3))
+	require.Error(t, err)
+}
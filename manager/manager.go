@@ -0,0 +1,310 @@
+// Package manager caches the cameras created by a NewCameraFunc, evicting them once they
+// have been idle or alive for too long, and runs the commands sent to each camera one at a time.
+package manager
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+// Stats are the manager's cache metrics.
+type Stats struct {
+	// Cameras is how many cameras are currently cached
+	Cameras int `json:"cameras"`
+
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+
+	// Evictions is how many cameras have been evicted for being idle or alive too long
+	Evictions uint64 `json:"evictions"`
+}
+
+// Manager caches cameras. The cameras it caches are closed when they are evicted, if they implement io.Closer.
+type Manager struct {
+	idleTimeout time.Duration
+	maxAge      time.Duration
+	log         *zap.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	entries  map[key]*entry
+	byCamera map[cameraservices.Camera]*entry
+	nextFunc int
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// key identifies a camera by the NewCameraFunc that created it and its address.
+type key struct {
+	fn   int
+	addr string
+}
+
+type entry struct {
+	key     key
+	cam     cameraservices.Camera
+	created time.Time
+	used    int64 // unix nanos, accessed atomically
+
+	// queue is held while a command runs. blocked senders on a channel
+	// are woken in the order they blocked, so commands run in the order they were sent.
+	queue   chan struct{}
+	waiting int32 // accessed atomically
+}
+
+// New creates a manager and starts evicting expired cameras in the background. Close must be called to stop it.
+func New(opts ...Option) *Manager {
+	options := options{
+		idleTimeout:   _defaultIdleTimeout,
+		maxAge:        _defaultMaxAge,
+		sweepInterval: _defaultSweepInterval,
+		log:           zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	m := &Manager{
+		idleTimeout: options.idleTimeout,
+		maxAge:      options.maxAge,
+		log:         options.log,
+		now:         time.Now,
+		entries:     make(map[key]*entry),
+		byCamera:    make(map[cameraservices.Camera]*entry),
+		stop:        make(chan struct{}),
+	}
+
+	if options.sweepInterval > 0 {
+		m.wg.Add(1)
+		go m.sweep(options.sweepInterval)
+	}
+
+	return m
+}
+
+// Cameras returns a NewCameraFunc that returns the cached camera at an address, calling create if there isn't one.
+func (m *Manager) Cameras(create cameraservices.NewCameraFunc) cameraservices.NewCameraFunc {
+	m.mu.Lock()
+	fn := m.nextFunc
+	m.nextFunc++
+	m.mu.Unlock()
+
+	return func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return m.get(ctx, key{fn: fn, addr: addr}, create)
+	}
+}
+
+func (m *Manager) get(ctx context.Context, k key, create cameraservices.NewCameraFunc) (cameraservices.Camera, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	e, ok := m.entries[k]
+	if ok && m.expired(e, now) {
+		m.remove(e)
+		atomic.AddUint64(&m.evictions, 1)
+		ok = false
+		go m.close(e, "expired")
+	}
+
+	if ok {
+		atomic.StoreInt64(&e.used, now.UnixNano())
+		m.mu.Unlock()
+
+		atomic.AddUint64(&m.hits, 1)
+		return e.cam, nil
+	}
+	m.mu.Unlock()
+
+	atomic.AddUint64(&m.misses, 1)
+
+	cam, err := create(ctx, k.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// someone else may have created it while we were
+	if e, ok := m.entries[k]; ok {
+		go closeCamera(cam)
+		return e.cam, nil
+	}
+
+	e = &entry{
+		key:     k,
+		cam:     cam,
+		created: now,
+		used:    now.UnixNano(),
+		queue:   make(chan struct{}, 1),
+	}
+
+	m.entries[k] = e
+	m.byCamera[cam] = e
+	return cam, nil
+}
+
+// Do runs cmd once every command sent to cam before it has finished.
+// If cam isn't cached by the manager, cmd is run immediately.
+func (m *Manager) Do(ctx context.Context, cam cameraservices.Camera, cmd func(context.Context) error) error {
+	m.mu.Lock()
+	e, ok := m.byCamera[cam]
+	m.mu.Unlock()
+
+	if !ok {
+		return cmd(ctx)
+	}
+
+	atomic.AddInt32(&e.waiting, 1)
+	select {
+	case e.queue <- struct{}{}:
+		atomic.AddInt32(&e.waiting, -1)
+	case <-ctx.Done():
+		atomic.AddInt32(&e.waiting, -1)
+		return ctx.Err()
+	}
+	defer func() { <-e.queue }()
+
+	atomic.StoreInt64(&e.used, m.now().UnixNano())
+	return cmd(ctx)
+}
+
+// Waiting returns how many commands are waiting for the command running on cam to finish.
+func (m *Manager) Waiting(cam cameraservices.Camera) int {
+	m.mu.Lock()
+	e, ok := m.byCamera[cam]
+	m.mu.Unlock()
+
+	if !ok {
+		return 0
+	}
+
+	return int(atomic.LoadInt32(&e.waiting))
+}
+
+// Stats returns the manager's current cache metrics.
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	cameras := len(m.entries)
+	m.mu.Unlock()
+
+	return Stats{
+		Cameras:   cameras,
+		Hits:      atomic.LoadUint64(&m.hits),
+		Misses:    atomic.LoadUint64(&m.misses),
+		Evictions: atomic.LoadUint64(&m.evictions),
+	}
+}
+
+// Close stops evicting cameras and closes every cached camera.
+func (m *Manager) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		m.wg.Wait()
+
+		m.mu.Lock()
+		entries := make([]*entry, 0, len(m.entries))
+		for _, e := range m.entries {
+			entries = append(entries, e)
+			m.remove(e)
+		}
+		m.mu.Unlock()
+
+		for _, e := range entries {
+			m.close(e, "manager closed")
+		}
+	})
+
+	return nil
+}
+
+func (m *Manager) sweep(interval time.Duration) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.evictExpired()
+		}
+	}
+}
+
+// evictExpired evicts every camera that has been idle or alive for too long.
+func (m *Manager) evictExpired() {
+	now := m.now()
+
+	m.mu.Lock()
+	var expired []*entry
+	for _, e := range m.entries {
+		if m.expired(e, now) {
+			expired = append(expired, e)
+			m.remove(e)
+			atomic.AddUint64(&m.evictions, 1)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, e := range expired {
+		m.close(e, "expired")
+	}
+}
+
+// expired returns true if e has been idle or alive for too long.
+func (m *Manager) expired(e *entry, now time.Time) bool {
+	used := time.Unix(0, atomic.LoadInt64(&e.used))
+
+	switch {
+	case m.idleTimeout > 0 && now.Sub(used) >= m.idleTimeout:
+		return true
+	case m.maxAge > 0 && now.Sub(e.created) >= m.maxAge:
+		return true
+	}
+
+	return false
+}
+
+// remove removes e from the cache. m.mu must be held.
+func (m *Manager) remove(e *entry) {
+	delete(m.entries, e.key)
+	delete(m.byCamera, e.cam)
+}
+
+// close waits for the command running on e's camera (if any) to finish, and then closes it.
+func (m *Manager) close(e *entry, reason string) {
+	e.queue <- struct{}{}
+	defer func() { <-e.queue }()
+
+	m.log.Debug("Evicting camera", zap.String("address", e.key.addr), zap.String("reason", reason))
+
+	if err := closeCamera(e.cam); err != nil {
+		m.log.Warn("unable to close camera", zap.String("address", e.key.addr), zap.Error(err))
+	}
+}
+
+func closeCamera(cam cameraservices.Camera) error {
+	if c, ok := cam.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+type testCamera struct {
+	cameraservices.Camera
+
+	addr   string
+	closed int32
+}
+
+func (c *testCamera) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestManager(opts ...Option) (*Manager, *clock, cameraservices.NewCameraFunc) {
+	m := New(append([]Option{WithSweepInterval(0)}, opts...)...)
+
+	clk := &clock{now: time.Now()}
+	m.now = clk.Now
+
+	create := m.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return &testCamera{addr: addr}, nil
+	})
+
+	return m, clk, create
+}
+
+func TestCache(t *testing.T) {
+	m, _, create := newTestManager()
+	defer m.Close()
+
+	ctx := context.Background()
+
+	a, err := create(ctx, "a")
+	require.NoError(t, err)
+
+	again, err := create(ctx, "a")
+	require.NoError(t, err)
+	require.Same(t, a, again)
+
+	b, err := create(ctx, "b")
+	require.NoError(t, err)
+	require.False(t, a == b)
+
+	// cameras from another func aren't shared
+	other := m.Cameras(func(ctx context.Context, addr string) (cameraservices.Camera, error) {
+		return &testCamera{addr: addr}, nil
+	})
+
+	c, err := other(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, a == c)
+
+	require.Equal(t, Stats{Cameras: 3, Hits: 1, Misses: 3}, m.Stats())
+
+	require.NoError(t, m.Close())
+	require.Equal(t, int32(1), atomic.LoadInt32(&a.(*testCamera).closed))
+	require.Equal(t, int32(1), atomic.LoadInt32(&c.(*testCamera).closed))
+	require.Equal(t, 0, m.Stats().Cameras)
+}
+
+func TestEviction(t *testing.T) {
+	m, clk, create := newTestManager(WithIdleTimeout(time.Minute), WithMaxAge(time.Hour))
+	defer m.Close()
+
+	ctx := context.Background()
+
+	idle, err := create(ctx, "idle")
+	require.NoError(t, err)
+
+	busy, err := create(ctx, "busy")
+	require.NoError(t, err)
+
+	// keep busy in use for longer than the idle timeout
+	for i := 0; i < 3; i++ {
+		clk.Add(30 * time.Second)
+		require.NoError(t, m.Do(ctx, busy, func(context.Context) error { return nil }))
+	}
+
+	m.evictExpired()
+	require.Equal(t, int32(1), atomic.LoadInt32(&idle.(*testCamera).closed))
+	require.Equal(t, int32(0), atomic.LoadInt32(&busy.(*testCamera).closed))
+	require.Equal(t, Stats{Cameras: 1, Misses: 2, Evictions: 1}, m.Stats())
+
+	// a new camera is created the next time it's used
+	again, err := create(ctx, "idle")
+	require.NoError(t, err)
+	require.False(t, idle == again)
+
+	// max age evicts even cameras in use
+	for i := 0; i < 120; i++ {
+		clk.Add(30 * time.Second)
+		_, err := create(ctx, "busy")
+		require.NoError(t, err)
+
+		if atomic.LoadInt32(&busy.(*testCamera).closed) > 0 {
+			break
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&busy.(*testCamera).closed) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDo(t *testing.T) {
+	m, _, create := newTestManager()
+	defer m.Close()
+
+	ctx := context.Background()
+
+	cam, err := create(ctx, "a")
+	require.NoError(t, err)
+
+	// hold the queue so that the commands below wait in order
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = m.Do(ctx, cam, func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	var (
+		mu      sync.Mutex
+		order   []int
+		running int32
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := m.Do(ctx, cam, func(context.Context) error {
+				require.Equal(t, int32(1), atomic.AddInt32(&running, 1))
+				defer atomic.AddInt32(&running, -1)
+
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				return nil
+			})
+			require.NoError(t, err)
+		}(i)
+
+		// wait for the command to be queued before sending the next one
+		require.Eventually(t, func() bool { return m.Waiting(cam) == i+1 }, time.Second, time.Millisecond)
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, order)
+
+	// commands waiting in the queue give up when their context is done
+	release = make(chan struct{})
+	started = make(chan struct{})
+	go func() {
+		_ = m.Do(ctx, cam, func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	err = m.Do(timeout, cam, func(context.Context) error {
+		return fmt.Errorf("shouldn't run")
+	})
+	require.Equal(t, context.DeadlineExceeded, err)
+	close(release)
+
+	// cameras the manager doesn't know about run immediately
+	ran := false
+	require.NoError(t, m.Do(ctx, &testCamera{}, func(context.Context) error {
+		ran = true
+		return nil
+	}))
+	require.True(t, ran)
+}
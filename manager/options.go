@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultIdleTimeout   = 15 * time.Minute
+	_defaultMaxAge        = time.Hour
+	_defaultSweepInterval = time.Minute
+)
+
+type options struct {
+	idleTimeout   time.Duration
+	maxAge        time.Duration
+	sweepInterval time.Duration
+	log           *zap.Logger
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithIdleTimeout sets how long a camera can go unused before it is evicted.
+// The default is 15 minutes. 0 means cameras are never evicted for being idle.
+func WithIdleTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.idleTimeout = d
+	})
+}
+
+// WithMaxAge sets how long a camera is kept before it is evicted and recreated,
+// so that changes to how cameras are created (ie. new credentials) are picked up.
+// The default is 1 hour. 0 means cameras are never evicted for their age.
+func WithMaxAge(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.maxAge = d
+	})
+}
+
+// WithSweepInterval sets how often expired cameras are looked for. The default is 1 minute.
+func WithSweepInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.sweepInterval = d
+	})
+}
+
+// WithLogger sets the logger used to log evictions.
+func WithLogger(log *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.log = log
+	})
+}
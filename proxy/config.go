@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a backend's entry in the routing table.
+type Config struct {
+	// Upstreams are the base urls of the backend's replicas
+	Upstreams []string `yaml:"upstreams"`
+
+	// Hosts are the hosts of camera urls in the config that belong to this backend,
+	// in addition to the hosts of its upstreams
+	Hosts []string `yaml:"hosts"`
+
+	// Timeout is how long to wait for an upstream to start responding (ie. 5s). Defaults to 10 seconds.
+	Timeout string `yaml:"timeout"`
+
+	// HealthCheck is the path upstreams are health checked at. Defaults to /debug/healthz.
+	// "none" disables health checks for the backend.
+	HealthCheck string `yaml:"healthCheck"`
+}
+
+// Load reads a routing table from the yaml file at path, keyed by backend name:
+//
+//	aver:
+//	  upstreams: [http://aver-1.av.byu.edu, http://aver-2.av.byu.edu]
+//	  hosts: [aver.av.byu.edu]
+//	  timeout: 5s
+func Load(path string) (map[string]Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read routing table: %w", err)
+	}
+
+	var routes map[string]Config
+	if err := yaml.UnmarshalStrict(data, &routes); err != nil {
+		return nil, fmt.Errorf("unable to parse routing table: %w", err)
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultTimeout        = 10 * time.Second
+	_defaultHealthPath     = "/debug/healthz"
+	_defaultHealthInterval = 10 * time.Second
+)
+
+type options struct {
+	interval time.Duration
+	client   *http.Client
+	log      *zap.Logger
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithHealthInterval sets how often every upstream is health checked. The default is 10 seconds.
+// 0 means upstreams are never checked, and only go down when a request to them fails.
+func WithHealthInterval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.interval = d
+	})
+}
+
+// WithHealthClient sets the client used for health checks. The default is a client with a 2 second timeout.
+func WithHealthClient(client *http.Client) Option {
+	return optionFunc(func(o *options) {
+		o.client = client
+	})
+}
+
+// WithLogger sets the logger used to log upstreams going up and down.
+func WithLogger(log *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.log = log
+	})
+}
@@ -0,0 +1,379 @@
+// Package proxy is the control service's routing table, which maps the name of a camera service
+// to its upstream replicas. Requests are balanced across the replicas that are passing health checks.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnavailable is returned when every upstream of a backend is down.
+var ErrUnavailable = errors.New("no healthy upstreams")
+
+// Table is a routing table. Close must be called to stop health checking its upstreams.
+type Table struct {
+	backends map[string]*Backend
+	names    []string
+
+	client *http.Client
+	log    *zap.Logger
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Backend is a camera service in the routing table.
+type Backend struct {
+	name       string
+	upstreams  []*upstream
+	hosts      []string
+	timeout    time.Duration
+	healthPath string
+	transport  http.RoundTripper
+	log        *zap.Logger
+
+	next uint32 // accessed atomically
+}
+
+type upstream struct {
+	url  *url.URL
+	down int32 // accessed atomically
+
+	mu        sync.Mutex
+	lastError string
+	lastCheck time.Time
+}
+
+// UpstreamStatus is the health of an upstream.
+type UpstreamStatus struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	LastError string    `json:"lastError,omitempty"`
+	LastCheck time.Time `json:"lastCheck,omitempty"`
+}
+
+// New builds a routing table from routes, keyed by backend name, and starts health checking its upstreams.
+func New(routes map[string]Config, opts ...Option) (*Table, error) {
+	options := options{
+		interval: _defaultHealthInterval,
+		client:   &http.Client{Timeout: 2 * time.Second},
+		log:      zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	t := &Table{
+		backends: make(map[string]*Backend, len(routes)),
+		client:   options.client,
+		log:      options.log,
+		stop:     make(chan struct{}),
+	}
+
+	for name, config := range routes {
+		b, err := newBackend(name, config, options.log)
+		if err != nil {
+			return nil, err
+		}
+
+		t.backends[name] = b
+		t.names = append(t.names, name)
+	}
+
+	sort.Strings(t.names)
+
+	if options.interval > 0 {
+		t.wg.Add(1)
+		go t.check(options.interval)
+	}
+
+	return t, nil
+}
+
+func newBackend(name string, config Config, log *zap.Logger) (*Backend, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid backend name %q", name)
+	}
+
+	if len(config.Upstreams) == 0 {
+		return nil, fmt.Errorf("%s: no upstreams", name)
+	}
+
+	b := &Backend{
+		name:       name,
+		timeout:    _defaultTimeout,
+		healthPath: _defaultHealthPath,
+		log:        log.With(zap.String("backend", name)),
+	}
+
+	if config.Timeout != "" {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%s: invalid timeout %q", name, config.Timeout)
+		}
+
+		b.timeout = d
+	}
+
+	switch config.HealthCheck {
+	case "":
+	case "none":
+		b.healthPath = ""
+	default:
+		b.healthPath = "/" + strings.TrimPrefix(config.HealthCheck, "/")
+	}
+
+	for _, u := range config.Upstreams {
+		parsed, err := url.Parse(u)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("%s: invalid upstream: %w", name, err)
+		case parsed.Scheme == "" || parsed.Host == "":
+			return nil, fmt.Errorf("%s: upstream %q must be an absolute url", name, u)
+		}
+
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+		b.upstreams = append(b.upstreams, &upstream{url: parsed})
+		b.hosts = append(b.hosts, parsed.Host)
+	}
+
+	b.hosts = append(b.hosts, config.Hosts...)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = b.timeout
+	transport.DialContext = (&net.Dialer{
+		Timeout:   b.timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+
+	b.transport = transport
+	return b, nil
+}
+
+// Close stops health checking the table's upstreams.
+func (t *Table) Close() {
+	t.closeOnce.Do(func() {
+		close(t.stop)
+	})
+
+	t.wg.Wait()
+}
+
+// Backend returns the backend called name, or nil if there isn't one.
+func (t *Table) Backend(name string) *Backend {
+	if t == nil {
+		return nil
+	}
+
+	return t.backends[name]
+}
+
+// Names returns the name of every backend, sorted.
+func (t *Table) Names() []string {
+	if t == nil {
+		return nil
+	}
+
+	return t.names
+}
+
+// Match returns the name of the backend that the camera service url u belongs to, or "" if there isn't one.
+// urls on one of a backend's hosts belong to it.
+func (t *Table) Match(u *url.URL) string {
+	if t == nil {
+		return ""
+	}
+
+	for _, name := range t.names {
+		for _, host := range t.backends[name].hosts {
+			if strings.EqualFold(host, u.Host) {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// Status returns the health of every backend's upstreams.
+func (t *Table) Status() map[string][]UpstreamStatus {
+	status := make(map[string][]UpstreamStatus)
+	for _, name := range t.Names() {
+		for _, u := range t.backends[name].upstreams {
+			u.mu.Lock()
+			status[name] = append(status[name], UpstreamStatus{
+				URL:       u.url.String(),
+				Healthy:   atomic.LoadInt32(&u.down) == 0,
+				LastError: u.lastError,
+				LastCheck: u.lastCheck,
+			})
+			u.mu.Unlock()
+		}
+	}
+
+	return status
+}
+
+func (t *Table) check(interval time.Duration) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var wg sync.WaitGroup
+		for _, name := range t.names {
+			b := t.backends[name]
+			if b.healthPath == "" {
+				continue
+			}
+
+			for _, u := range b.upstreams {
+				wg.Add(1)
+
+				go func(b *Backend, u *upstream) {
+					defer wg.Done()
+					b.setHealth(u, t.probe(b, u))
+				}(b, u)
+			}
+		}
+
+		wg.Wait()
+
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Table) probe(b *Backend, u *upstream) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-t.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url.String()+b.healthPath, nil)
+	if err != nil {
+		return fmt.Errorf("unable to build request: %w", err)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%d response", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Name returns the backend's name.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Timeout returns how long the backend's upstreams have to start responding.
+func (b *Backend) Timeout() time.Duration {
+	return b.timeout
+}
+
+// Transport returns the transport to send requests to the backend's upstreams with, which enforces its timeout.
+func (b *Backend) Transport() http.RoundTripper {
+	return b.transport
+}
+
+// URL returns the base url of the backend's first upstream, which identifies the backend in camera urls.
+func (b *Backend) URL() *url.URL {
+	u := *b.upstreams[0].url
+	return &u
+}
+
+// Next returns the base url of the next healthy upstream, going round robin through them.
+// ErrUnavailable is returned if every upstream is down.
+func (b *Backend) Next() (*url.URL, error) {
+	n := uint32(len(b.upstreams))
+	start := atomic.AddUint32(&b.next, 1) - 1
+
+	for i := uint32(0); i < n; i++ {
+		u := b.upstreams[(start+i)%n]
+		if atomic.LoadInt32(&u.down) == 0 {
+			to := *u.url
+			return &to, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", b.name, ErrUnavailable)
+}
+
+// Fail marks the upstream with the base url to as down, until it passes its next health check.
+// Backends without health checks keep sending requests to failed upstreams.
+func (b *Backend) Fail(to *url.URL, err error) {
+	if b.healthPath == "" {
+		return
+	}
+
+	for _, u := range b.upstreams {
+		if u.url.Scheme == to.Scheme && u.url.Host == to.Host {
+			b.setHealth(u, err)
+			return
+		}
+	}
+}
+
+// Unreachable returns true if err (from sending a request to an upstream) means the upstream couldn't be
+// connected to, or dropped the connection. Other errors, like an upstream that is slow to respond to one
+// request, don't mean the upstream is down.
+func Unreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+func (b *Backend) setHealth(u *upstream, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.lastCheck = time.Now()
+	u.lastError = ""
+
+	if err != nil {
+		u.lastError = err.Error()
+		if atomic.CompareAndSwapInt32(&u.down, 0, 1) {
+			b.log.Warn("Upstream is down", zap.String("upstream", u.url.String()), zap.Error(err))
+		}
+
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&u.down, 1, 0) {
+		b.log.Info("Upstream is back up", zap.String("upstream", u.url.String()))
+	}
+}
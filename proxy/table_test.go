@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	table, err := New(map[string]Config{
+		"aver": {Upstreams: []string{"http://aver-1.byu.edu", "http://aver-2.byu.edu/base/"}},
+	}, WithHealthInterval(0))
+	require.NoError(t, err)
+	defer table.Close()
+
+	b := table.Backend("aver")
+	require.NotNil(t, b)
+	require.Nil(t, table.Backend("axis"))
+
+	var hosts []string
+	for i := 0; i < 4; i++ {
+		to, err := b.Next()
+		require.NoError(t, err)
+		hosts = append(hosts, to.Host+to.Path)
+	}
+
+	require.Equal(t, []string{"aver-1.byu.edu", "aver-2.byu.edu/base", "aver-1.byu.edu", "aver-2.byu.edu/base"}, hosts)
+
+	// failed upstreams are skipped
+	b.Fail(&url.URL{Scheme: "http", Host: "aver-1.byu.edu"}, errors.New("connection refused"))
+	for i := 0; i < 2; i++ {
+		to, err := b.Next()
+		require.NoError(t, err)
+		require.Equal(t, "aver-2.byu.edu", to.Host)
+	}
+
+	b.Fail(&url.URL{Scheme: "http", Host: "aver-2.byu.edu"}, errors.New("connection refused"))
+	_, err = b.Next()
+	require.True(t, errors.Is(err, ErrUnavailable))
+}
+
+func TestUnreachable(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:1")
+	require.True(t, Unreachable(err))
+
+	require.True(t, Unreachable(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("i/o timeout")}))
+	require.True(t, Unreachable(fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	require.False(t, Unreachable(errors.New("net/http: timeout awaiting response headers")))
+	require.False(t, Unreachable(context.Canceled))
+}
+
+func TestHealthCheck(t *testing.T) {
+	var healthy int32 = 1
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/healthz", r.URL.Path)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer up.Close()
+
+	table, err := New(map[string]Config{
+		"aver": {Upstreams: []string{up.URL, "http://127.0.0.1:1"}, HealthCheck: "healthz"},
+	}, WithHealthInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer table.Close()
+
+	healthyUpstreams := func() int {
+		var n int
+		for _, u := range table.Status()["aver"] {
+			if u.Healthy {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	require.Eventually(t, func() bool { return healthyUpstreams() == 1 }, time.Second, 10*time.Millisecond)
+
+	to, err := table.Backend("aver").Next()
+	require.NoError(t, err)
+	require.Equal(t, up.URL, to.String())
+
+	atomic.StoreInt32(&healthy, 0)
+	require.Eventually(t, func() bool { return healthyUpstreams() == 0 }, time.Second, 10*time.Millisecond)
+	require.NotEmpty(t, table.Status()["aver"][0].LastError)
+
+	atomic.StoreInt32(&healthy, 1)
+	require.Eventually(t, func() bool { return healthyUpstreams() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestMatch(t *testing.T) {
+	table, err := New(map[string]Config{
+		"aver":   {Upstreams: []string{"http://camera-services-1.byu.edu"}},
+		"axis":   {Upstreams: []string{"http://camera-services-2.byu.edu"}, Hosts: []string{"cameras.av.byu.edu"}},
+		"visca":  {Upstreams: []string{"http://camera-services-3.byu.edu"}},
+		"onvif2": {Upstreams: []string{"http://camera-services-4.byu.edu"}},
+	}, WithHealthInterval(0))
+	require.NoError(t, err)
+	defer table.Close()
+
+	match := func(u string) string {
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		return table.Match(parsed)
+	}
+
+	require.Equal(t, "aver", match("http://camera-services-1.byu.edu/v1/Pro520/cam/stream"))
+	require.Equal(t, "axis", match("http://cameras.av.byu.edu/v1/P5414-E/cam/stream"))
+	require.Equal(t, "", match("http://onvif.av.byu.edu/v1/ONVIF/cam/stream"))
+
+	// backends aren't found by their name in the host
+	require.Equal(t, "", match("http://visca.av.byu.edu/v1/VISCA/cam/stream"))
+
+	// the camera's hostname isn't used
+	require.Equal(t, "", match("http://cameras.byu.edu/v1/P5414-E/ITB-AXIS-CAM1.byu.edu/stream"))
+
+	var nilTable *Table
+	require.Equal(t, "", nilTable.Match(&url.URL{Host: "aver.av.byu.edu"}))
+}
+
+func TestNewInvalid(t *testing.T) {
+	for name, routes := range map[string]map[string]Config{
+		"NoUpstreams":    {"aver": {}},
+		"RelativeURL":    {"aver": {Upstreams: []string{"aver.av.byu.edu"}}},
+		"InvalidName":    {"av/er": {Upstreams: []string{"http://aver.av.byu.edu"}}},
+		"InvalidTimeout": {"aver": {Upstreams: []string{"http://aver.av.byu.edu"}, Timeout: "ten"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(routes, WithHealthInterval(0))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backends.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+aver:
+  upstreams: [http://aver-1.av.byu.edu, http://aver-2.av.byu.edu]
+  hosts: [aver.av.byu.edu]
+  timeout: 5s
+visca:
+  upstreams: [http://visca.av.byu.edu]
+  healthCheck: none
+`), 0600))
+
+	routes, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	require.Equal(t, []string{"aver.av.byu.edu"}, routes["aver"].Hosts)
+
+	table, err := New(routes, WithHealthInterval(0))
+	require.NoError(t, err)
+	defer table.Close()
+
+	require.Equal(t, []string{"aver", "visca"}, table.Names())
+	require.Equal(t, 5*time.Second, table.Backend("aver").Timeout())
+	require.Equal(t, _defaultTimeout, table.Backend("visca").Timeout())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("aver:\n  upstream: [http://aver.av.byu.edu]\n"), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+}
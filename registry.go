@@ -0,0 +1,86 @@
+package cameraservices
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrDriverExists is returned when a driver is registered for a model that already has one.
+var ErrDriverExists = errors.New("a driver is already registered for this model")
+
+// Driver creates cameras of a single model.
+type Driver struct {
+	// Model is the name of the model, used in the camera's url (ie. /v1/Pro520/:address).
+	Model string
+
+	// NewCamera creates (or returns an existing) camera at an address.
+	NewCamera NewCameraFunc
+
+	// Capabilities are the optional capabilities every camera of this model has.
+	// They decide which optional endpoints are served for the model.
+	Capabilities Capabilities
+}
+
+// Supports returns true if the driver's cameras support the capability.
+func (d Driver) Supports(capability string) bool {
+	return d.Capabilities[capability].Supported
+}
+
+// Registry maps model names to the driver for that model.
+// The zero value is an empty registry ready to use.
+type Registry struct {
+	mu      sync.RWMutex
+	drivers map[string]Driver
+}
+
+// Register adds a driver to the registry.
+func (r *Registry) Register(d Driver) error {
+	switch {
+	case d.Model == "":
+		return errors.New("driver must have a model")
+	case d.NewCamera == nil:
+		return fmt.Errorf("%s: driver must have a NewCamera func", d.Model)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.drivers[d.Model]; ok {
+		return fmt.Errorf("%s: %w", d.Model, ErrDriverExists)
+	}
+
+	if r.drivers == nil {
+		r.drivers = make(map[string]Driver)
+	}
+
+	r.drivers[d.Model] = d
+	return nil
+}
+
+// Driver returns the driver registered for model.
+func (r *Registry) Driver(model string) (Driver, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.drivers[model]
+	return d, ok
+}
+
+// Drivers returns every registered driver, sorted by model.
+func (r *Registry) Drivers() []Driver {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	drivers := make([]Driver, 0, len(r.drivers))
+	for _, d := range r.drivers {
+		drivers = append(drivers, d)
+	}
+
+	sort.Slice(drivers, func(i, j int) bool {
+		return drivers[i].Model < drivers[j].Model
+	})
+
+	return drivers
+}
@@ -0,0 +1,77 @@
+package cameraservices
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoomConfig is a room's document in the ui-configuration database, which lists the cameras in each
+// of the room's control groups.
+type RoomConfig struct {
+	ID            string `json:"_id"`
+	ControlGroups []struct {
+		ID      string         `json:"name"`
+		Cameras []CameraConfig `json:"cameras"`
+	} `json:"presets"`
+}
+
+// Cameras returns a copy of the cameras in info's control group, which callers can change without
+// changing config (ie. when it is cached).
+func (config RoomConfig) Cameras(info ControlInfo) ([]CameraConfig, error) {
+	for _, cg := range config.ControlGroups {
+		if cg.ID == info.ControlGroup && len(cg.Cameras) > 0 {
+			cameras := make([]CameraConfig, len(cg.Cameras))
+			for i := range cg.Cameras {
+				cameras[i] = cg.Cameras[i].Copy()
+			}
+
+			return cameras, nil
+		}
+	}
+
+	return []CameraConfig{}, fmt.Errorf("no cameras found in %s/%s", info.Room, info.ControlGroup)
+}
+
+// ControlGroupNames returns the name of each control group that has cameras.
+func (config RoomConfig) ControlGroupNames() []string {
+	var groups []string
+	for _, cg := range config.ControlGroups {
+		if len(cg.Cameras) > 0 {
+			groups = append(groups, cg.ID)
+		}
+	}
+
+	return groups
+}
+
+// Streams returns the stream url of every camera, or its address if it doesn't have a stream url.
+func (config RoomConfig) Streams() []string {
+	var IP []string
+	for _, cg := range config.ControlGroups {
+		for _, cam := range cg.Cameras {
+			if cam.Stream == "" && cam.Address != "" {
+				IP = append(IP, cam.Address)
+				continue
+			}
+
+			IP = append(IP, cam.Stream)
+		}
+	}
+
+	return IP
+}
+
+// PresetName returns the name of the preset whose setPreset url is for camID and ends with presetID.
+func (config RoomConfig) PresetName(camID, presetID string) (string, bool) {
+	for _, cg := range config.ControlGroups {
+		for _, cam := range cg.Cameras {
+			for _, preset := range cam.Presets {
+				if strings.Contains(preset.SetPreset, camID) && strings.HasSuffix(preset.SetPreset, presetID) {
+					return preset.DisplayName, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
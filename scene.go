@@ -0,0 +1,24 @@
+package cameraservices
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSceneNotFound is returned by a SceneService when the requested scene doesn't exist.
+var ErrSceneNotFound = errors.New("scene not found")
+
+// SceneService stores the scenes for each room's control groups.
+type SceneService interface {
+	Scenes(ctx context.Context, room, controlGroup string) ([]Scene, error)
+	Scene(ctx context.Context, room, controlGroup, name string) (Scene, error)
+	SetScene(ctx context.Context, room, controlGroup string, scene Scene) error
+	DeleteScene(ctx context.Context, room, controlGroup, name string) error
+}
+
+// Scene is a named combination of presets across the cameras in a control group,
+// ie. "Panel Discussion" sends camera A to preset 2 and camera B to preset 5.
+type Scene struct {
+	Name    string            `json:"name"`
+	Presets []ScheduledPreset `json:"presets"`
+}
@@ -0,0 +1,34 @@
+package cameraservices
+
+import "context"
+
+// ScheduleService provides the scheduled preset recalls for every room.
+type ScheduleService interface {
+	Schedules(context.Context) ([]ScheduleEntry, error)
+}
+
+// ScheduleEntry describes when a set of presets should be recalled in a room's control group.
+// Exactly one of Cron or Calendar should be set.
+type ScheduleEntry struct {
+	Name         string `json:"name"`
+	Room         string `json:"room"`
+	ControlGroup string `json:"controlGroup"`
+
+	// Cron is a standard five field cron expression (minute hour day-of-month month day-of-week)
+	Cron string `json:"cron,omitempty"`
+
+	// Calendar is the contents of an iCalendar file. Each event in the
+	// calendar recalls Presets (or the event's own X-CAMERA-PRESET properties) when it starts.
+	Calendar string `json:"calendar,omitempty"`
+
+	// TimeZone is the IANA time zone Cron is evaluated in. Defaults to local time.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	Presets []ScheduledPreset `json:"presets,omitempty"`
+}
+
+// ScheduledPreset is the display name of a camera and the display name of the preset to recall on it.
+type ScheduledPreset struct {
+	Camera string `json:"camera"`
+	Preset string `json:"preset"`
+}
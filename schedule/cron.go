@@ -0,0 +1,177 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSpec is a parsed five field cron expression.
+type cronSpec struct {
+	minute map[int]bool
+	hour   map[int]bool
+	dom    map[int]bool
+	month  map[int]bool
+	dow    map[int]bool
+
+	// domStar and dowStar track if the day fields were "*", which changes how they are combined
+	domStar bool
+	dowStar bool
+}
+
+var (
+	_monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	_dayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// parseCron parses a standard five field cron expression.
+// Each field supports *, lists (1,2), ranges (1-5), steps (*/15, 1-30/5), and month/day names.
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var (
+		spec cronSpec
+		err  error
+	)
+
+	if spec.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+
+	if spec.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+
+	if spec.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+
+	if spec.month, err = parseCronField(fields[3], 1, 12, _monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+
+	if spec.dow, err = parseCronField(fields[4], 0, 7, _dayNames); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %w", err)
+	}
+
+	// 7 is also sunday
+	if spec.dow[7] {
+		spec.dow[0] = true
+	}
+
+	spec.domStar = strings.HasPrefix(fields[2], "*")
+	spec.dowStar = strings.HasPrefix(fields[4], "*")
+	return &spec, nil
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (map[int]bool, error) {
+	vals := make(map[int]bool)
+
+	value := func(s string) (int, error) {
+		if v, ok := names[strings.ToLower(s)]; ok {
+			return v, nil
+		}
+
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+
+		if v < min || v > max {
+			return 0, fmt.Errorf("%d out of range [%d, %d]", v, min, max)
+		}
+
+		return v, nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rng == "*":
+			start, end = min, max
+		case strings.Contains(rng, "-"):
+			split := strings.SplitN(rng, "-", 2)
+
+			var err error
+			if start, err = value(split[0]); err != nil {
+				return nil, err
+			}
+
+			if end, err = value(split[1]); err != nil {
+				return nil, err
+			}
+
+			if end < start {
+				return nil, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			var err error
+			if start, err = value(rng); err != nil {
+				return nil, err
+			}
+
+			end = start
+			if step > 1 {
+				end = max
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			vals[i] = true
+		}
+	}
+
+	return vals, nil
+}
+
+// matches returns true if t (truncated to the minute) matches the cron spec.
+func (s *cronSpec) matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+
+	dom := s.dom[t.Day()]
+	dow := s.dow[int(t.Weekday())]
+
+	// same as vixie cron: if both day fields are restricted, either one matching is enough
+	switch {
+	case s.domStar || s.dowStar:
+		return dom && dow
+	default:
+		return dom || dow
+	}
+}
+
+// between returns each minute in (from, to] that matches the cron spec.
+func (s *cronSpec) between(from, to time.Time) []time.Time {
+	var times []time.Time
+
+	for t := from.Truncate(time.Minute).Add(time.Minute); !t.After(to); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			times = append(times, t)
+		}
+	}
+
+	return times
+}
@@ -0,0 +1,46 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	spec, err := parseCron("0 9 * * mon-fri")
+	require.NoError(t, err)
+
+	// 2026-10-19 is a monday
+	require.True(t, spec.matches(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)))
+	require.False(t, spec.matches(time.Date(2026, 10, 19, 9, 1, 0, 0, time.UTC)))
+	require.False(t, spec.matches(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestParseCronSteps(t *testing.T) {
+	spec, err := parseCron("*/15 8-17/3 1,15 * *")
+	require.NoError(t, err)
+
+	require.True(t, spec.matches(time.Date(2026, 10, 1, 11, 45, 0, 0, time.UTC)))
+	require.True(t, spec.matches(time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)))
+	require.False(t, spec.matches(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)))
+	require.False(t, spec.matches(time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC)))
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := parseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronBetween(t *testing.T) {
+	spec, err := parseCron("0 14 * * *")
+	require.NoError(t, err)
+
+	from := time.Date(2026, 10, 19, 13, 59, 30, 0, time.UTC)
+	times := spec.between(from, from.Add(time.Minute))
+	require.Equal(t, []time.Time{time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)}, times)
+
+	require.Empty(t, spec.between(from.Add(time.Minute), from.Add(2*time.Minute)))
+}
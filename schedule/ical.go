@@ -0,0 +1,400 @@
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+const (
+	_icalDateTime    = "20060102T150405"
+	_icalDateTimeUTC = "20060102T150405Z"
+	_icalDate        = "20060102"
+
+	// _maxRecurrenceDays is how far past DTSTART a rule with a COUNT is expanded to find its last occurrence
+	_maxRecurrenceDays = 10 * 366
+)
+
+// calendar is a parsed iCalendar file. Only the parts of RFC 5545 needed
+// to schedule preset recalls are supported.
+type calendar struct {
+	// Room and ControlGroup come from the X-CAMERA-ROOM and X-CAMERA-CONTROL-GROUP calendar properties
+	Room         string
+	ControlGroup string
+
+	Events []calEvent
+}
+
+type calEvent struct {
+	Summary string
+	Start   time.Time
+	Rule    *recurrence
+	Except  map[int64]bool
+
+	// Presets come from X-CAMERA-PRESET:<camera>=<preset> properties
+	Presets []cameraservices.ScheduledPreset
+}
+
+type recurrence struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    map[time.Weekday]bool
+
+	// WeekStart is the day weeks start on when counting INTERVAL weeks. Defaults to Monday, as in RFC 5545
+	WeekStart time.Weekday
+}
+
+type property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+var _icalDays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseCalendar parses the iCalendar data in data. Times without a zone are interpreted in loc.
+func parseCalendar(data string, loc *time.Location) (*calendar, error) {
+	props, err := unfold(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &calendar{}
+	var event *calEvent
+
+	// components is the stack of components we are in, ie. [VCALENDAR VEVENT VALARM]
+	var components []string
+
+	for _, prop := range props {
+		current := ""
+		if len(components) > 0 {
+			current = components[len(components)-1]
+		}
+
+		switch {
+		case prop.Name == "BEGIN":
+			components = append(components, prop.Value)
+			if prop.Value == "VEVENT" {
+				event = &calEvent{Except: make(map[int64]bool)}
+			}
+		case prop.Name == "END":
+			if current != prop.Value {
+				return nil, fmt.Errorf("unexpected END:%s", prop.Value)
+			}
+
+			components = components[:len(components)-1]
+			if prop.Value == "VEVENT" && event != nil {
+				if event.Start.IsZero() {
+					return nil, fmt.Errorf("event %q is missing DTSTART", event.Summary)
+				}
+
+				event.resolveCount()
+				cal.Events = append(cal.Events, *event)
+				event = nil
+			}
+		case current == "VEVENT" && event != nil:
+			if err := event.apply(prop, loc); err != nil {
+				return nil, fmt.Errorf("event %q: %w", event.Summary, err)
+			}
+		case current == "VCALENDAR" && prop.Name == "X-CAMERA-ROOM":
+			cal.Room = prop.Value
+		case current == "VCALENDAR" && prop.Name == "X-CAMERA-CONTROL-GROUP":
+			cal.ControlGroup = prop.Value
+		}
+	}
+
+	if len(components) != 0 {
+		return nil, fmt.Errorf("unbalanced BEGIN/END")
+	}
+
+	return cal, nil
+}
+
+func (e *calEvent) apply(prop property, loc *time.Location) error {
+	switch prop.Name {
+	case "SUMMARY":
+		e.Summary = prop.Value
+	case "DTSTART":
+		t, err := parseICalTime(prop, loc)
+		if err != nil {
+			return fmt.Errorf("invalid DTSTART: %w", err)
+		}
+
+		e.Start = t
+	case "EXDATE":
+		for _, v := range strings.Split(prop.Value, ",") {
+			t, err := parseICalTime(property{Params: prop.Params, Value: v}, loc)
+			if err != nil {
+				return fmt.Errorf("invalid EXDATE: %w", err)
+			}
+
+			e.Except[t.Unix()] = true
+		}
+	case "RRULE":
+		rule, err := parseRRule(prop.Value, loc)
+		if err != nil {
+			return fmt.Errorf("invalid RRULE: %w", err)
+		}
+
+		e.Rule = rule
+	case "X-CAMERA-PRESET":
+		split := strings.SplitN(prop.Value, "=", 2)
+		if len(split) != 2 {
+			return fmt.Errorf("invalid X-CAMERA-PRESET %q: expected <camera>=<preset>", prop.Value)
+		}
+
+		e.Presets = append(e.Presets, cameraservices.ScheduledPreset{
+			Camera: strings.TrimSpace(split[0]),
+			Preset: strings.TrimSpace(split[1]),
+		})
+	}
+
+	return nil
+}
+
+func parseRRule(val string, loc *time.Location) (*recurrence, error) {
+	rule := &recurrence{Interval: 1, WeekStart: time.Monday}
+
+	for _, part := range strings.Split(val, ";") {
+		split := strings.SplitN(part, "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+
+		switch split[0] {
+		case "FREQ":
+			rule.Freq = split[1]
+		case "INTERVAL":
+			i, err := strconv.Atoi(split[1])
+			if err != nil || i <= 0 {
+				return nil, fmt.Errorf("invalid interval %q", split[1])
+			}
+
+			rule.Interval = i
+		case "COUNT":
+			i, err := strconv.Atoi(split[1])
+			if err != nil || i <= 0 {
+				return nil, fmt.Errorf("invalid count %q", split[1])
+			}
+
+			rule.Count = i
+		case "UNTIL":
+			t, err := parseICalTime(property{Value: split[1]}, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid until: %w", err)
+			}
+
+			rule.Until = t
+		case "BYDAY":
+			rule.ByDay = make(map[time.Weekday]bool)
+			for _, day := range strings.Split(split[1], ",") {
+				wd, ok := _icalDays[day]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", day)
+				}
+
+				rule.ByDay[wd] = true
+			}
+		case "WKST":
+			wd, ok := _icalDays[split[1]]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST value %q", split[1])
+			}
+
+			rule.WeekStart = wd
+		}
+	}
+
+	switch rule.Freq {
+	case "DAILY", "WEEKLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", rule.Freq)
+	}
+
+	return rule, nil
+}
+
+func parseICalTime(prop property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := prop.Params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q: %w", tzid, err)
+		}
+
+		loc = l
+	}
+
+	switch {
+	case strings.HasSuffix(prop.Value, "Z"):
+		return time.Parse(_icalDateTimeUTC, prop.Value)
+	case len(prop.Value) == len(_icalDate):
+		return time.ParseInLocation(_icalDate, prop.Value, loc)
+	default:
+		return time.ParseInLocation(_icalDateTime, prop.Value, loc)
+	}
+}
+
+// between returns the start time of each occurrence of the event in (from, to].
+func (e *calEvent) between(from, to time.Time) []time.Time {
+	var times []time.Time
+
+	add := func(t time.Time) {
+		if t.After(from) && !t.After(to) && !e.Except[t.Unix()] {
+			times = append(times, t)
+		}
+	}
+
+	if e.Rule == nil {
+		add(e.Start)
+		return times
+	}
+
+	// skip straight to the day before the window. the rule's COUNT has already been turned into an UNTIL,
+	// so earlier occurrences don't need to be counted
+	day := e.Start
+	if skip := dayNumber(from.In(e.Start.Location())) - dayNumber(e.Start) - 1; skip > 0 {
+		day = e.Start.AddDate(0, 0, int(skip))
+	}
+
+	weekStart := startOfWeek(e.Start, e.Rule.WeekStart)
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !e.Rule.Until.IsZero() && day.After(e.Rule.Until) {
+			break
+		}
+
+		if e.Rule.occursOn(e.Start, weekStart, day) {
+			add(day)
+		}
+	}
+
+	return times
+}
+
+// resolveCount replaces the rule's COUNT with an UNTIL of its last occurrence, so that occurrences don't
+// have to be counted from the start of the event every time they are looked up.
+func (e *calEvent) resolveCount() {
+	if e.Rule == nil || e.Rule.Count == 0 {
+		return
+	}
+
+	count := 0
+	weekStart := startOfWeek(e.Start, e.Rule.WeekStart)
+	last := e.Start.AddDate(0, 0, _maxRecurrenceDays)
+
+	for day := e.Start; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !e.Rule.Until.IsZero() && day.After(e.Rule.Until) {
+			break
+		}
+
+		if !e.Rule.occursOn(e.Start, weekStart, day) {
+			continue
+		}
+
+		count++
+		if count == e.Rule.Count {
+			last = day
+			break
+		}
+	}
+
+	if e.Rule.Until.IsZero() || last.Before(e.Rule.Until) {
+		e.Rule.Until = last
+	}
+
+	e.Rule.Count = 0
+}
+
+func (r *recurrence) occursOn(start, weekStart, day time.Time) bool {
+	days := int(dayNumber(day) - dayNumber(start))
+
+	switch r.Freq {
+	case "DAILY":
+		if days%r.Interval != 0 {
+			return false
+		}
+
+		return len(r.ByDay) == 0 || r.ByDay[day.Weekday()]
+	case "WEEKLY":
+		weeks := int(dayNumber(day)-dayNumber(weekStart)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+
+		return r.ByDay[day.Weekday()]
+	}
+
+	return false
+}
+
+// dayNumber is the number of calendar days since the unix epoch, ignoring daylight savings changes.
+func dayNumber(t time.Time) int64 {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// startOfWeek returns the day the week t is in starts on, for weeks that start on wkst.
+func startOfWeek(t time.Time, wkst time.Weekday) time.Time {
+	return t.AddDate(0, 0, -int((t.Weekday()-wkst+7)%7))
+}
+
+// unfold splits iCalendar data into its content lines, joining folded lines back together.
+func unfold(data string) ([]property, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case line == "":
+		case (line[0] == ' ' || line[0] == '\t') && len(lines) > 0:
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read calendar: %w", err)
+	}
+
+	props := make([]property, 0, len(lines))
+	for _, line := range lines {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid content line %q", line)
+		}
+
+		prop := property{
+			Params: make(map[string]string),
+			Value:  line[i+1:],
+		}
+
+		params := strings.Split(line[:i], ";")
+		prop.Name = strings.ToUpper(params[0])
+
+		for _, param := range params[1:] {
+			split := strings.SplitN(param, "=", 2)
+			if len(split) == 2 {
+				prop.Params[strings.ToUpper(split[0])] = strings.Trim(split[1], `"`)
+			}
+		}
+
+		props = append(props, prop)
+	}
+
+	return props, nil
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCalendar(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ITB-1101.ics")
+	require.NoError(t, err)
+
+	cal, err := parseCalendar(string(data), time.UTC)
+	require.NoError(t, err)
+
+	require.Equal(t, "ITB-1101", cal.Room)
+	require.Equal(t, "ITB-1101", cal.ControlGroup)
+	require.Len(t, cal.Events, 2)
+	require.Equal(t, []cameraservices.ScheduledPreset{
+		{Camera: "Front", Preset: "Panel Table"},
+		{Camera: "Back", Preset: "Wide"},
+	}, cal.Events[1].Presets)
+}
+
+func TestCalendarRecurrence(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ITB-1101.ics")
+	require.NoError(t, err)
+
+	cal, err := parseCalendar(string(data), time.UTC)
+	require.NoError(t, err)
+
+	denver, err := time.LoadLocation("America/Denver")
+	require.NoError(t, err)
+
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, denver)
+	to := from.AddDate(0, 0, 7)
+
+	var got []time.Time
+	for _, t := range cal.Events[0].between(from, to) {
+		got = append(got, t.In(denver))
+	}
+
+	// wednesday is excluded
+	require.Equal(t, []time.Time{
+		time.Date(2026, 10, 19, 9, 0, 0, 0, denver),
+		time.Date(2026, 10, 23, 9, 0, 0, 0, denver),
+	}, got)
+}
+
+func TestParseCalendarInvalid(t *testing.T) {
+	_, err := parseCalendar("BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:no start\nEND:VEVENT\nEND:VCALENDAR\n", time.UTC)
+	require.Error(t, err)
+
+	_, err = parseCalendar("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20261019T090000Z\nRRULE:FREQ=MONTHLY\nEND:VEVENT\nEND:VCALENDAR\n", time.UTC)
+	require.Error(t, err)
+
+	_, err = parseCalendar("BEGIN:VCALENDAR\n", time.UTC)
+	require.Error(t, err)
+}
+
+func TestCalendarWeekStart(t *testing.T) {
+	// the examples from RFC 5545 section 3.8.5.3, which only differ by WKST
+	occurrences := func(wkst string) []int {
+		cal, err := parseCalendar("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:19970805T090000Z\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU"+wkst+"\nEND:VEVENT\nEND:VCALENDAR\n", time.UTC)
+		require.NoError(t, err)
+
+		var days []int
+		for _, t := range cal.Events[0].between(time.Date(1997, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(1997, 12, 31, 0, 0, 0, 0, time.UTC)) {
+			days = append(days, t.Day())
+		}
+
+		return days
+	}
+
+	require.Equal(t, []int{5, 10, 19, 24}, occurrences(";WKST=MO"))
+	require.Equal(t, []int{5, 10, 19, 24}, occurrences(""))
+	require.Equal(t, []int{5, 17, 19, 31}, occurrences(";WKST=SU"))
+}
+
+func TestCalendarComponents(t *testing.T) {
+	cal, err := parseCalendar(`BEGIN:VCALENDAR
+X-CAMERA-ROOM:ITB-1101
+BEGIN:VEVENT
+SUMMARY:Lecture
+DTSTART:20200106T090000Z
+RRULE:FREQ=DAILY
+BEGIN:VALARM
+SUMMARY:Reminder
+TRIGGER:-PT5M
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`, time.UTC)
+	require.NoError(t, err)
+
+	// the alarm's summary isn't the event's
+	require.Equal(t, "ITB-1101", cal.Room)
+	require.Equal(t, "Lecture", cal.Events[0].Summary)
+
+	// occurrences long after the event started are found
+	from := time.Date(2026, 10, 19, 8, 59, 0, 0, time.UTC)
+	require.Equal(t, []time.Time{time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)}, cal.Events[0].between(from, from.Add(time.Minute)))
+
+	_, err = parseCalendar("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20261019T090000Z\nEND:VCALENDAR\nEND:VEVENT\n", time.UTC)
+	require.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// HTTPRecaller recalls presets by requesting the preset's setPreset url on the camera service,
+// the same way the control UI does.
+type HTTPRecaller struct {
+	ConfigService     cameraservices.ConfigService
+	ControlKeyService interface {
+		ControlKey(context.Context, string, string) (string, error)
+	}
+
+	// Services are the urls of the camera services that cameras described by their model, address,
+	// and service are controlled through
+	Services cameraservices.CameraServices
+
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+
+	// Resolver is used to find the camera's IP for events. Defaults to net.DefaultResolver
+	Resolver *net.Resolver
+}
+
+func (r *HTTPRecaller) RecallPreset(ctx context.Context, room, controlGroup, camera, preset string) (net.IP, error) {
+	cameras, err := r.ConfigService.Cameras(ctx, cameraservices.ControlInfo{
+		Room:         room,
+		ControlGroup: controlGroup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get cameras: %w", err)
+	}
+
+	presetURL, err := findPreset(r.Services.WithURLs(cameras), camera, preset)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(presetURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse preset url: %w", err)
+	}
+
+	ip := r.cameraIP(ctx, u)
+
+	key, err := r.ControlKeyService.ControlKey(ctx, room, controlGroup)
+	if err != nil {
+		return ip, fmt.Errorf("unable to get control key: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return ip, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "control-key",
+		Value: key,
+	})
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ip, fmt.Errorf("unable to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return ip, fmt.Errorf("%d response from camera service: %s", resp.StatusCode, body)
+	}
+
+	return ip, nil
+}
+
+func findPreset(cameras []cameraservices.CameraConfig, camera, preset string) (string, error) {
+	for _, cam := range cameras {
+		if cam.DisplayName != camera {
+			continue
+		}
+
+		for _, p := range cam.Presets {
+			if p.DisplayName == preset {
+				if p.SetPreset == "" {
+					return "", fmt.Errorf("preset %q on %q has no url", preset, camera)
+				}
+
+				return p.SetPreset, nil
+			}
+		}
+
+		return "", fmt.Errorf("preset %q not found on %q", preset, camera)
+	}
+
+	return "", fmt.Errorf("camera %q not found", camera)
+}
+
+// cameraIP finds the ip address of the camera from a camera service url (/v1/:model/:address/...).
+// A nil ip is returned if it can't be found.
+func (r *HTTPRecaller) cameraIP(ctx context.Context, u *url.URL) net.IP {
+	split := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(split) < 3 {
+		return nil
+	}
+
+	addr := split[2]
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip
+	}
+
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupHost(ctx, addr)
+	if err != nil || len(addrs) == 0 {
+		return nil
+	}
+
+	return net.ParseIP(addrs[0])
+}
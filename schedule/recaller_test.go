@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfig struct {
+	cameras []cameraservices.CameraConfig
+}
+
+func (f *fakeConfig) Cameras(context.Context, cameraservices.ControlInfo) ([]cameraservices.CameraConfig, error) {
+	return f.cameras, nil
+}
+
+func (f *fakeConfig) ControlIP(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+type fakeKeys struct{}
+
+func (fakeKeys) ControlKey(ctx context.Context, room, cg string) (string, error) {
+	return "1234", nil
+}
+
+func TestHTTPRecaller(t *testing.T) {
+	var gotPath, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if c, err := r.Cookie("control-key"); err == nil {
+			gotKey = c.Value
+		}
+	}))
+	defer server.Close()
+
+	r := &HTTPRecaller{
+		ConfigService: &fakeConfig{
+			cameras: []cameraservices.CameraConfig{
+				{
+					DisplayName: "Front",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Lectern", SetPreset: server.URL + "/v1/Pro520/10.0.0.5:52381/preset/2"},
+					},
+				},
+			},
+		},
+		ControlKeyService: fakeKeys{},
+	}
+
+	ip, err := r.RecallPreset(context.Background(), "ITB-1101", "ITB-1101", "Front", "Lectern")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.5", ip.String())
+	require.Equal(t, "/v1/Pro520/10.0.0.5:52381/preset/2", gotPath)
+	require.Equal(t, "1234", gotKey)
+
+	_, err = r.RecallPreset(context.Background(), "ITB-1101", "ITB-1101", "Front", "Missing")
+	require.Error(t, err)
+
+	_, err = r.RecallPreset(context.Background(), "ITB-1101", "ITB-1101", "Back", "Lectern")
+	require.Error(t, err)
+}
+
+func TestHTTPRecallerStructured(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}))
+	defer server.Close()
+
+	r := &HTTPRecaller{
+		ConfigService: &fakeConfig{
+			cameras: []cameraservices.CameraConfig{
+				{
+					DisplayName: "Front",
+					Model:       "Pro520",
+					Address:     "10.0.0.5",
+					Service:     "aver",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Lectern", Preset: "2"},
+					},
+				},
+				{
+					DisplayName: "Back",
+					Model:       "P5414-E",
+					Address:     "10.0.0.6",
+					Service:     "axis",
+					Presets: []cameraservices.CameraPreset{
+						{DisplayName: "Wide", Preset: "3"},
+					},
+				},
+			},
+		},
+		ControlKeyService: fakeKeys{},
+		Services: cameraservices.CameraServices{
+			"aver": server.URL,
+		},
+	}
+
+	ip, err := r.RecallPreset(context.Background(), "ITB-1101", "ITB-1101", "Front", "Lectern")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.5", ip.String())
+	require.Equal(t, "/v1/Pro520/10.0.0.5/preset/2", gotPath)
+
+	// the camera's service isn't known, so it has no url
+	_, err = r.RecallPreset(context.Background(), "ITB-1101", "ITB-1101", "Back", "Wide")
+	require.Error(t, err)
+}
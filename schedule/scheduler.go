@@ -0,0 +1,294 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"go.uber.org/zap"
+)
+
+// Recaller recalls a preset on a camera in a room's control group.
+type Recaller interface {
+	RecallPreset(ctx context.Context, room, controlGroup, camera, preset string) (net.IP, error)
+}
+
+// Scheduler recalls presets at the times described by the entries in Source.
+type Scheduler struct {
+	Source         cameraservices.ScheduleService
+	Recaller       Recaller
+	EventPublisher cameraservices.EventPublisher
+	Logger         *zap.Logger
+
+	// RefreshInterval is how often entries are reloaded from Source. Defaults to 5 minutes.
+	RefreshInterval time.Duration
+
+	// Location is used for entries that don't set a TimeZone. Defaults to time.Local.
+	Location *time.Location
+
+	// Now is used to get the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	entries []entry
+}
+
+// entry is a ScheduleEntry that has been parsed.
+type entry struct {
+	cameraservices.ScheduleEntry
+
+	loc  *time.Location
+	cron *cronSpec
+	cal  *calendar
+}
+
+// firing is a set of presets that should be recalled in a room.
+type firing struct {
+	entry   string
+	room    string
+	cg      string
+	at      time.Time
+	presets []cameraservices.ScheduledPreset
+}
+
+// Run checks for due entries once a minute until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.RefreshInterval == 0 {
+		s.RefreshInterval = 5 * time.Minute
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		return fmt.Errorf("unable to load schedules: %w", err)
+	}
+
+	refresh := time.NewTicker(s.RefreshInterval)
+	defer refresh.Stop()
+
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+
+	last := s.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-refresh.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.Logger.Warn("unable to refresh schedules, using previous schedules", zap.Error(err))
+			}
+		case <-tick.C:
+			now := s.now()
+			s.Fire(ctx, last, now)
+			last = now
+		}
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+
+	return s.Now()
+}
+
+// Refresh reloads the entries from Source. Invalid entries are logged and skipped.
+func (s *Scheduler) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	raw, err := s.Source.Schedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]entry, 0, len(raw))
+	for _, r := range raw {
+		e, err := parseEntry(r, s.Location)
+		if err != nil {
+			s.Logger.Warn("skipping invalid schedule entry", zap.String("room", r.Room), zap.String("name", r.Name), zap.Error(err))
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	s.Logger.Info("Loaded schedules", zap.Int("count", len(entries)))
+	s.entries = entries
+	return nil
+}
+
+func parseEntry(raw cameraservices.ScheduleEntry, loc *time.Location) (entry, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	e := entry{
+		ScheduleEntry: raw,
+		loc:           loc,
+	}
+
+	if raw.TimeZone != "" {
+		loc, err := time.LoadLocation(raw.TimeZone)
+		if err != nil {
+			return e, fmt.Errorf("invalid time zone: %w", err)
+		}
+
+		e.loc = loc
+	}
+
+	switch {
+	case raw.Cron != "" && raw.Calendar != "":
+		return e, fmt.Errorf("only one of cron or calendar may be set")
+	case raw.Cron != "":
+		spec, err := parseCron(raw.Cron)
+		if err != nil {
+			return e, fmt.Errorf("invalid cron: %w", err)
+		}
+
+		if len(raw.Presets) == 0 {
+			return e, fmt.Errorf("no presets to recall")
+		}
+
+		e.cron = spec
+	case raw.Calendar != "":
+		cal, err := parseCalendar(raw.Calendar, e.loc)
+		if err != nil {
+			return e, fmt.Errorf("invalid calendar: %w", err)
+		}
+
+		if e.Room == "" {
+			e.Room = cal.Room
+		}
+
+		if e.ControlGroup == "" {
+			e.ControlGroup = cal.ControlGroup
+		}
+
+		e.cal = cal
+	default:
+		return e, fmt.Errorf("one of cron or calendar must be set")
+	}
+
+	if e.Room == "" || e.ControlGroup == "" {
+		return e, fmt.Errorf("room and control group are required")
+	}
+
+	return e, nil
+}
+
+// due returns everything that should have been recalled in (from, to].
+func (s *Scheduler) due(from, to time.Time) []firing {
+	var firings []firing
+
+	for _, e := range s.entries {
+		switch {
+		case e.cron != nil:
+			for _, t := range e.cron.between(from.In(e.loc), to.In(e.loc)) {
+				firings = append(firings, firing{
+					entry:   e.Name,
+					room:    e.Room,
+					cg:      e.ControlGroup,
+					at:      t,
+					presets: e.Presets,
+				})
+			}
+		case e.cal != nil:
+			for _, event := range e.cal.Events {
+				presets := event.Presets
+				if len(presets) == 0 {
+					presets = e.Presets
+				}
+
+				name := e.Name
+				if event.Summary != "" {
+					name += "/" + event.Summary
+				}
+
+				for _, t := range event.between(from, to) {
+					firings = append(firings, firing{
+						entry:   name,
+						room:    e.Room,
+						cg:      e.ControlGroup,
+						at:      t,
+						presets: presets,
+					})
+				}
+			}
+		}
+	}
+
+	return firings
+}
+
+// Fire recalls every preset that is due in (from, to].
+func (s *Scheduler) Fire(ctx context.Context, from, to time.Time) {
+	wg := sync.WaitGroup{}
+
+	for _, f := range s.due(from, to) {
+		for _, p := range f.presets {
+			wg.Add(1)
+
+			go func(f firing, p cameraservices.ScheduledPreset) {
+				defer wg.Done()
+				s.recall(ctx, f, p)
+			}(f, p)
+		}
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) recall(ctx context.Context, f firing, p cameraservices.ScheduledPreset) {
+	log := s.Logger.With(zap.String("entry", f.entry), zap.String("room", f.room), zap.String("controlGroup", f.cg), zap.String("camera", p.Camera), zap.String("preset", p.Preset))
+
+	info := cameraservices.RequestInfo{
+		Action:    "ScheduledGoToPreset",
+		Timestamp: s.now(),
+		Data: map[string]interface{}{
+			"schedule":     f.entry,
+			"scheduledFor": f.at.Format(time.RFC3339),
+			"room":         f.room,
+			"controlGroup": f.cg,
+			"camera":       p.Camera,
+			"preset":       p.Preset,
+		},
+	}
+
+	log.Info("Recalling scheduled preset")
+
+	rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ip, err := s.Recaller.RecallPreset(rctx, f.room, f.cg, p.Camera, p.Preset)
+	info.CameraIP = ip
+	info.Duration = s.now().Sub(info.Timestamp)
+
+	if err != nil {
+		log.Warn("unable to recall scheduled preset", zap.Error(err))
+	} else {
+		log.Info("Recalled scheduled preset", zap.Duration("took", info.Duration))
+	}
+
+	if s.EventPublisher == nil {
+		return
+	}
+
+	pctx, pcancel := context.WithTimeout(ctx, 3*time.Second)
+	defer pcancel()
+
+	if err != nil {
+		err = s.EventPublisher.Error(pctx, cameraservices.RequestError{
+			RequestInfo: info,
+			Error:       err.Error(),
+		})
+	} else {
+		err = s.EventPublisher.Publish(pctx, info)
+	}
+
+	if err != nil {
+		log.Warn("unable to publish event", zap.Error(err))
+	}
+}
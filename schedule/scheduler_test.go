@@ -0,0 +1,138 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	cameraservices "github.com/byuoitav/camera-services"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recall struct {
+	room, cg, camera, preset string
+}
+
+type fakeRecaller struct {
+	sync.Mutex
+	recalls []recall
+}
+
+func (f *fakeRecaller) RecallPreset(ctx context.Context, room, cg, camera, preset string) (net.IP, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.recalls = append(f.recalls, recall{room, cg, camera, preset})
+	if camera == "Broken" {
+		return nil, errors.New("camera is broken")
+	}
+
+	return net.ParseIP("10.0.0.1"), nil
+}
+
+type fakePublisher struct {
+	sync.Mutex
+	published []cameraservices.RequestInfo
+	errors    []cameraservices.RequestError
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, info cameraservices.RequestInfo) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.published = append(f.published, info)
+	return nil
+}
+
+func (f *fakePublisher) Error(ctx context.Context, err cameraservices.RequestError) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.errors = append(f.errors, err)
+	return nil
+}
+
+type staticSource []cameraservices.ScheduleEntry
+
+func (s staticSource) Schedules(context.Context) ([]cameraservices.ScheduleEntry, error) {
+	return s, nil
+}
+
+func TestSchedulerCron(t *testing.T) {
+	recaller := &fakeRecaller{}
+	publisher := &fakePublisher{}
+
+	s := &Scheduler{
+		Source: staticSource{
+			{
+				Name:         "morning",
+				Room:         "ITB-1101",
+				ControlGroup: "ITB-1101",
+				Cron:         "0 9 * * *",
+				Presets: []cameraservices.ScheduledPreset{
+					{Camera: "Front", Preset: "Lectern"},
+					{Camera: "Broken", Preset: "Lectern"},
+				},
+			},
+			{
+				Name: "invalid",
+				Cron: "0 9 * * *",
+			},
+		},
+		Recaller:       recaller,
+		EventPublisher: publisher,
+		Logger:         zap.NewNop(),
+		Location:       time.UTC,
+	}
+
+	// Now isn't set, and the scheduler isn't running
+	require.NoError(t, s.Refresh(context.Background()))
+	require.Len(t, s.entries, 1)
+
+	s.Fire(context.Background(), time.Date(2026, 10, 19, 8, 59, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+
+	require.Len(t, recaller.recalls, 2)
+	require.Len(t, publisher.published, 1)
+	require.Len(t, publisher.errors, 1)
+
+	require.Equal(t, "ScheduledGoToPreset", publisher.published[0].Action)
+	require.Equal(t, "Front", publisher.published[0].Data["camera"])
+	require.Equal(t, "camera is broken", publisher.errors[0].Error)
+
+	// nothing is due a minute later
+	s.Fire(context.Background(), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 1, 0, 0, time.UTC))
+	require.Len(t, recaller.recalls, 2)
+}
+
+func TestSchedulerFileSource(t *testing.T) {
+	recaller := &fakeRecaller{}
+
+	s := &Scheduler{
+		Source:   &FileSource{Dir: "testdata"},
+		Recaller: recaller,
+		Logger:   zap.NewNop(),
+		Now:      time.Now,
+	}
+
+	require.NoError(t, s.Refresh(context.Background()))
+	require.Len(t, s.entries, 1)
+
+	denver, err := time.LoadLocation("America/Denver")
+	require.NoError(t, err)
+
+	s.Fire(context.Background(), time.Date(2026, 10, 20, 13, 59, 0, 0, denver), time.Date(2026, 10, 20, 14, 0, 0, 0, denver))
+
+	sort.Slice(recaller.recalls, func(i, j int) bool {
+		return recaller.recalls[i].camera < recaller.recalls[j].camera
+	})
+
+	require.Equal(t, []recall{
+		{"ITB-1101", "ITB-1101", "Back", "Wide"},
+		{"ITB-1101", "ITB-1101", "Front", "Panel Table"},
+	}, recaller.recalls)
+}
@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	cameraservices "github.com/byuoitav/camera-services"
+)
+
+// FileSource is a ScheduleService that reads iCalendar (.ics) files from a directory.
+// Each calendar must set X-CAMERA-ROOM and X-CAMERA-CONTROL-GROUP, and each event
+// lists the presets to recall with X-CAMERA-PRESET:<camera>=<preset>.
+type FileSource struct {
+	Dir string
+}
+
+func (f *FileSource) Schedules(ctx context.Context) ([]cameraservices.ScheduleEntry, error) {
+	files, err := filepath.Glob(filepath.Join(f.Dir, "*.ics"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list calendars: %w", err)
+	}
+
+	entries := make([]cameraservices.ScheduleEntry, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", file, err)
+		}
+
+		entries = append(entries, cameraservices.ScheduleEntry{
+			Name:     strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+			Calendar: string(data),
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const _defaultTimeout = 30 * time.Second
+
+type options struct {
+	timeout  time.Duration
+	log      *zap.Logger
+	shutdown []func(context.Context) error
+}
+
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithTimeout sets how long the server has to shut down before its remaining connections are closed.
+// The default is 30 seconds.
+func WithTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.timeout = d
+	})
+}
+
+// WithLogger sets the logger used to log the server shutting down.
+func WithLogger(log *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.log = log
+	})
+}
+
+// WithShutdown adds a func that is called when the server starts shutting down, alongside the requests
+// it is still serving. The server isn't finished shutting down until every func has returned.
+// The context passed to fn is done once the shutdown timeout has passed.
+func WithShutdown(fn func(context.Context) error) Option {
+	return optionFunc(func(o *options) {
+		o.shutdown = append(o.shutdown, fn)
+	})
+}
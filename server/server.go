@@ -0,0 +1,80 @@
+// Package server serves http handlers until the service is told to stop, and then shuts them down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// SignalContext returns a context that is done once the service receives SIGINT or SIGTERM.
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// Serve serves handler on lis until ctx is done, and then shuts the server down gracefully:
+// lis is closed so that no new requests are accepted, and Serve waits for the requests being served
+// and every WithShutdown func to finish. If they haven't finished by the timeout,
+// the remaining connections are closed and an error is returned.
+func Serve(ctx context.Context, lis net.Listener, handler http.Handler, opts ...Option) error {
+	options := options{
+		timeout: _defaultTimeout,
+		log:     zap.NewNop(),
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	srv := &http.Server{
+		Handler: handler,
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	options.log.Info("Shutting down server", zap.Duration("timeout", options.timeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), options.timeout)
+	defer cancel()
+
+	g, gctx := errgroup.WithContext(shutdownCtx)
+	g.Go(func() error {
+		return srv.Shutdown(shutdownCtx)
+	})
+
+	for _, fn := range options.shutdown {
+		fn := fn
+		g.Go(func() error {
+			return fn(gctx)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		srv.Close()
+		return fmt.Errorf("unable to shut down gracefully: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	options.log.Info("Server shut down")
+	return nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.Handler, opts ...Option) (string, context.CancelFunc, chan error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, lis, handler, opts...)
+	}()
+
+	return "http://" + lis.Addr().String(), cancel, served
+}
+
+func TestServe(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	shutdown := make(chan struct{})
+	url, cancel, served := serve(t, handler, WithShutdown(func(ctx context.Context) error {
+		close(shutdown)
+		return nil
+	}))
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+
+		b, _ := ioutil.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+	<-shutdown
+
+	// new requests are refused while the server is shutting down
+	require.Eventually(t, func() bool {
+		_, err := http.Get(url)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// but the request being served finishes
+	select {
+	case err := <-served:
+		t.Fatalf("returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	require.Equal(t, "done", <-body)
+	require.NoError(t, <-served)
+}
+
+func TestServeTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	_, cancel, served := serve(t, handler,
+		WithTimeout(10*time.Millisecond),
+		WithShutdown(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+
+	cancel()
+	require.Error(t, <-served)
+}
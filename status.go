@@ -0,0 +1,27 @@
+package cameraservices
+
+import "context"
+
+// The power states a camera can report.
+const (
+	PowerOn      = "on"
+	PowerStandby = "standby"
+)
+
+// StatusCamera is a camera that can report its current state.
+type StatusCamera interface {
+	Status(context.Context) (Status, error)
+}
+
+// Status is the state of a camera. Fields the camera can't report are left empty.
+type Status struct {
+	Power    string `json:"power,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+
+	Position *Position `json:"position,omitempty"`
+
+	// Temperature is the internal temperature of the camera, in celsius
+	Temperature *float64 `json:"temperature,omitempty"`
+}